@@ -0,0 +1,337 @@
+package motley
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+)
+
+const (
+	// metadataLocked and metadataLockedAt are the fields we add to the actor's metadata to show it was locked from motley.
+	// They are only informative, FedBOX enforces the lock because the password was replaced and the tokens revoked.
+	metadataLocked   = "locked"
+	metadataLockedAt = "lockedAt"
+)
+
+// metadataLastLogin are the fields where the last login time can be found, depending on what wrote it.
+var metadataLastLogin = []string{"lastLogin", "LastLogin", "last_login"}
+
+var (
+	accountKey = key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "manage account of current actor"),
+	)
+	accountPasswordKey = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "set password"),
+	)
+	accountLockKey = key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "lock"),
+	)
+)
+
+// accountMetadata is the metadata the storage keeps for an actor.
+// We keep it as raw values, so we don't lose the fields we don't know about when saving it back.
+type accountMetadata map[string]json.RawMessage
+
+func loadAccountMetadata(st Store, iri pub.IRI) (accountMetadata, error) {
+	m := make(accountMetadata)
+	if err := st.s.LoadMetadata(iri, &m); err != nil && !errors.IsNotFound(err) {
+		return m, err
+	}
+	return m, nil
+}
+
+func (m accountMetadata) locked() bool {
+	locked := false
+	_ = json.Unmarshal(m[metadataLocked], &locked)
+	return locked
+}
+
+func (m accountMetadata) time(names ...string) time.Time {
+	var t time.Time
+	for _, name := range names {
+		if raw, ok := m[name]; ok && json.Unmarshal(raw, &t) == nil {
+			return t
+		}
+	}
+	return t
+}
+
+func (m accountMetadata) setLocked(locked bool) {
+	if !locked {
+		delete(m, metadataLocked)
+		delete(m, metadataLockedAt)
+		return
+	}
+	m[metadataLocked], _ = json.Marshal(true)
+	m[metadataLockedAt], _ = json.Marshal(time.Now().UTC())
+}
+
+// actorIsLocked returns whether the actor at iri has been locked from motley.
+func actorIsLocked(f *fedbox, iri pub.IRI) bool {
+	st, err := f.storeFor(iri)
+	if err != nil {
+		return false
+	}
+	m, _ := loadAccountMetadata(st, iri)
+	return m.locked()
+}
+
+// markLocked adds or removes the lock fields in the metadata of the actor, if they need changing.
+// NOTE(marius): the storage has no way of changing a single field of the metadata, so we need to save all of it back.
+func markLocked(st Store, iri pub.IRI, locked bool) error {
+	m, err := loadAccountMetadata(st, iri)
+	if err != nil {
+		return err
+	}
+	if m.locked() == locked {
+		return nil
+	}
+	m.setLocked(locked)
+	return st.s.SaveMetadata(iri, m)
+}
+
+// userIRI returns the actor an OAuth2 authorization or token was issued for.
+func userIRI(data any) pub.IRI {
+	switch u := data.(type) {
+	case pub.IRI:
+		return u
+	case string:
+		return pub.IRI(u)
+	case fmt.Stringer:
+		return pub.IRI(u.String())
+	}
+	return ""
+}
+
+// revokeActorTokens removes the OAuth2 authorizations and tokens issued for the actor, and returns how many it removed.
+// NOTE(marius): like for the OAuth2 view, they can't be enumerated for the boltdb storage.
+func revokeActorTokens(f *fedbox, st Store, iri pub.IRI) (int, error) {
+	codes, err := oauthCodes(st)
+	if err != nil {
+		return 0, errors.Annotatef(err, "unable to list tokens")
+	}
+	revoked := 0
+	for _, token := range codes["access"] {
+		access, err := st.s.LoadAccess(token)
+		if err != nil || !userIRI(access.UserData).Equals(iri, false) {
+			continue
+		}
+		if access.RefreshToken != "" {
+			if err := st.s.RemoveRefresh(access.RefreshToken); err != nil {
+				return revoked, errors.Annotatef(err, "unable to remove refresh token")
+			}
+		}
+		if err := st.s.RemoveAccess(token); err != nil {
+			return revoked, errors.Annotatef(err, "unable to revoke token")
+		}
+		f.recordRevoke(st, "access", iri)
+		revoked++
+	}
+	for _, code := range codes["authorize"] {
+		auth, err := st.s.LoadAuthorize(code)
+		if err != nil || !userIRI(auth.UserData).Equals(iri, false) {
+			continue
+		}
+		if err := st.s.RemoveAuthorize(code); err != nil {
+			return revoked, errors.Annotatef(err, "unable to remove authorization")
+		}
+		f.recordRevoke(st, "authorize", iri)
+		revoked++
+	}
+	return revoked, nil
+}
+
+// lockAccount replaces the password of the actor with a random one, and revokes its OAuth2 tokens,
+// so it can't log in to FedBOX anymore. Setting a new password unlocks it.
+func lockAccount(f *fedbox, st Store, iri pub.IRI) (string, error) {
+	if err := st.s.PasswordSet(iri, []byte(randomSecret())); err != nil {
+		return "", errors.Annotatef(err, "unable to replace password")
+	}
+	revoked, err := revokeActorTokens(f, st, iri)
+	if err != nil {
+		return "", err
+	}
+	if err := markLocked(st, iri, true); err != nil {
+		return "", err
+	}
+	if !oauthListable(st) {
+		return "account locked, revoke its tokens from the OAuth2 view", nil
+	}
+	return fmt.Sprintf("account locked, tokens revoked: %d", revoked), nil
+}
+
+// setAccountPassword sets the password through the storage, which unlocks the account if it was locked.
+func setAccountPassword(st Store, iri pub.IRI, pw string) error {
+	if err := st.s.PasswordSet(iri, []byte(pw)); err != nil {
+		return errors.Annotatef(err, "unable to set password")
+	}
+	return markLocked(st, iri, false)
+}
+
+type accountLoadedMsg struct {
+	a    *AccountModel
+	meta accountMetadata
+	err  error
+}
+
+type accountChangedMsg struct {
+	a       *AccountModel
+	message string
+	err     error
+}
+
+// AccountModel shows the credentials metadata of a local actor and allows setting its password and locking it.
+type AccountModel struct {
+	*commonModel
+
+	node    *n
+	loading bool
+	meta    accountMetadata
+	err     error
+}
+
+func newAccountModel(common *commonModel, node *n) *AccountModel {
+	return &AccountModel{commonModel: common, node: node}
+}
+
+func (a *AccountModel) iri() pub.IRI {
+	return a.node.GetLink()
+}
+
+func (a *AccountModel) Init() tea.Cmd {
+	st, err := a.f.storeFor(a.iri())
+	if err != nil {
+		return errCmd(err)
+	}
+	a.loading = true
+	iri := a.iri()
+	return func() tea.Msg {
+		meta, err := loadAccountMetadata(st, iri)
+		return accountLoadedMsg{a: a, meta: meta, err: err}
+	}
+}
+
+func (a *AccountModel) change(fn func(*fedbox, Store, pub.IRI) (string, error)) tea.Cmd {
+	st, err := a.f.storeFor(a.iri())
+	if err != nil {
+		return errCmd(err)
+	}
+	f := a.f
+	iri := a.iri()
+	return confirmWrites(func() tea.Cmd {
+		return func() tea.Msg {
+			var message string
+			err := st.action(func() error {
+				return f.changeMetadata(st, "account", iri, func() (err error) {
+					message, err = fn(f, st, iri)
+					return err
+				})
+			})
+			return accountChangedMsg{a: a, message: message, err: err}
+		}
+	}, st)
+}
+
+func (a *AccountModel) setPassword() tea.Cmd {
+	return passwordPromptCmd("New password", func(pw string) tea.Cmd {
+		if pw == "" {
+			return errCmd(fmt.Errorf("empty password"))
+		}
+		return passwordPromptCmd("Confirm password", func(confirm string) tea.Cmd {
+			if confirm != pw {
+				return errCmd(fmt.Errorf("passwords don't match"))
+			}
+			return a.change(func(_ *fedbox, st Store, iri pub.IRI) (string, error) {
+				return "password set", setAccountPassword(st, iri, pw)
+			})
+		})
+	})
+}
+
+func (a *AccountModel) lock() tea.Cmd {
+	if a.meta.locked() {
+		return errCmd(fmt.Errorf("account is already locked, set a new password to unlock it"))
+	}
+	question := fmt.Sprintf("Lock %s? Its password will be replaced and its tokens revoked", a.iri())
+	return confirmCmd(question, func() tea.Cmd {
+		return a.change(lockAccount)
+	})
+}
+
+func (a *AccountModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case accountLoadedMsg:
+		if mm.a != a {
+			return a, noop
+		}
+		a.loading = false
+		a.meta = mm.meta
+		a.err = mm.err
+		if a.meta.locked() {
+			a.node.s |= NodeLocked
+		} else {
+			a.node.s &^= NodeLocked
+		}
+	case accountChangedMsg:
+		if mm.a != a {
+			return a, noop
+		}
+		if mm.err != nil {
+			return a, errCmd(mm.err)
+		}
+		return a, tea.Batch(a.Init(), statusMessageCmd("%s: %s", mm.message, a.iri()))
+	case tea.KeyPressMsg:
+		if a.loading {
+			return a, noop
+		}
+		switch {
+		case key.Matches(mm, accountPasswordKey):
+			return a, a.setPassword()
+		case key.Matches(mm, accountLockKey):
+			return a, a.lock()
+		}
+	}
+	return a, noop
+}
+
+func (a *AccountModel) View() tea.View {
+	field := func(name, value string) string {
+		return fmt.Sprintf("%s %s", fieldStyle.Render(name+":"), value)
+	}
+	pieces := []string{viewTitleStyle.Render("Account"), field("Actor", a.iri().String())}
+	if a.err != nil {
+		pieces = append(pieces, faintRedFg.Render(a.err.Error()))
+	}
+	if a.loading {
+		pieces = append(pieces, "Loading"+ellipsis)
+		return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+	}
+
+	status := "active"
+	if a.meta.locked() {
+		status = faintRedFg.Render(lockIcon + " locked")
+		if at := a.meta.time(metadataLockedAt); !at.IsZero() {
+			status += fmt.Sprintf(" since %s", at.Local().Format(oauthTimeFmt))
+		}
+	}
+	lastLogin := faintStyle.Render("not recorded")
+	if t := a.meta.time(metadataLastLogin...); !t.IsZero() {
+		lastLogin = t.Local().Format(oauthTimeFmt)
+	}
+	pieces = append(pieces,
+		field("Status", status),
+		field("Last login", lastLogin),
+	)
+
+	pieces = append(pieces, "", helpLine(accountPasswordKey, accountLockKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,87 @@
+package motley
+
+import (
+	"testing"
+	"time"
+
+	pub "github.com/go-ap/activitypub"
+	"github.com/openshift/osin"
+)
+
+func saveTestToken(t *testing.T, st Store, token string, actor pub.IRI) {
+	t.Helper()
+	c := &osin.DefaultClient{Id: "client", Secret: "secret", RedirectUri: "https://example.com/callback"}
+	if err := st.s.CreateClient(c); err != nil {
+		t.Fatalf("unable to save client: %s", err)
+	}
+	access := &osin.AccessData{Client: c, AccessToken: token, ExpiresIn: 3600, CreatedAt: time.Now().UTC(), UserData: actor}
+	if err := st.s.SaveAccess(access); err != nil {
+		t.Fatalf("unable to save token: %s", err)
+	}
+}
+
+func TestAccount_lock(t *testing.T) {
+	f, st := newTestFedbox(t)
+	actor := pub.IRI("https://example.com/actors/jdoe")
+	other := pub.IRI("https://example.com/actors/other")
+	for _, iri := range []pub.IRI{actor, other} {
+		if _, err := st.s.Save(&pub.Actor{ID: iri, Type: pub.PersonType}); err != nil {
+			t.Fatalf("unable to save actor: %s", err)
+		}
+	}
+	if err := setAccountPassword(st, actor, "secret"); err != nil {
+		t.Fatalf("unable to set password: %s", err)
+	}
+	saveTestToken(t, st, "actor-token", actor)
+	saveTestToken(t, st, "other-token", other)
+
+	message, err := lockAccount(f, st, actor)
+	if err != nil {
+		t.Fatalf("unable to lock account: %s", err)
+	}
+	if message != "account locked, tokens revoked: 1" {
+		t.Errorf("unexpected message %q", message)
+	}
+	if err := st.s.PasswordCheck(actor, []byte("secret")); err == nil {
+		t.Errorf("the old password should not work on a locked account")
+	}
+	if _, err := st.s.LoadAccess("actor-token"); err == nil {
+		t.Errorf("the token of the locked actor should be revoked")
+	}
+	if _, err := st.s.LoadAccess("other-token"); err != nil {
+		t.Errorf("the token of the other actor should be kept: %s", err)
+	}
+	if !actorIsLocked(f, actor) || actorIsLocked(f, other) {
+		t.Errorf("only the locked actor should be marked as locked")
+	}
+
+	if err := setAccountPassword(st, actor, "new secret"); err != nil {
+		t.Fatalf("unable to set password: %s", err)
+	}
+	if err := st.s.PasswordCheck(actor, []byte("new secret")); err != nil {
+		t.Errorf("the new password should work: %s", err)
+	}
+	if actorIsLocked(f, actor) {
+		t.Errorf("setting a new password should unlock the account")
+	}
+}
+
+func TestDepsLoaded_marksLockedActors(t *testing.T) {
+	m, st := newTestModel(t)
+	actor := &pub.Actor{ID: "https://example.com/actors/jdoe", Type: pub.PersonType}
+	if _, err := st.s.Save(actor); err != nil {
+		t.Fatalf("unable to save actor: %s", err)
+	}
+	if err := markLocked(st, actor.ID, true); err != nil {
+		t.Fatalf("unable to lock account: %s", err)
+	}
+	nn := node(actor)
+	msg, ok := findMsg[depsLoadedMsg](m.loadDepsCmd(nn))
+	if !ok {
+		t.Fatalf("the node should be loaded by a command")
+	}
+	m.update(msg)
+	if !nn.s.Is(NodeLocked) {
+		t.Errorf("the loaded actor should be marked as locked")
+	}
+}
@@ -1,6 +1,7 @@
 package motley
 
 import (
+	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	vocab "github.com/go-ap/activitypub"
@@ -13,8 +14,8 @@ type ActivityModel struct {
 	Object ObjectModel
 }
 
-func newActivityModel() ActivityModel {
-	return ActivityModel{}
+func newActivityModel(langs []vocab.LangRef) ActivityModel {
+	return ActivityModel{ObjectModel: newObjectModel(langs), Actor: newObjectModel(langs), Object: newObjectModel(langs)}
 }
 
 func (l ActivityModel) Init() tea.Cmd {
@@ -22,6 +23,16 @@ func (l ActivityModel) Init() tea.Cmd {
 }
 
 func (l ActivityModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if mm, ok := msg.(tea.KeyPressMsg); ok && key.Matches(mm, rawToggleKey) {
+		l.raw = !l.raw
+		l.Actor.raw = l.raw
+		l.Object.raw = l.raw
+	}
+	if mm, ok := msg.(tea.KeyPressMsg); ok && key.Matches(mm, languageKey) {
+		l.nextLanguage()
+		l.Actor.nextLanguage()
+		l.Object.nextLanguage()
+	}
 	return l, noop
 }
 
@@ -0,0 +1,74 @@
+package motley
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"git.sr.ht/~mariusor/motley/internal/config"
+	"git.sr.ht/~mariusor/motley/internal/env"
+	pub "github.com/go-ap/activitypub"
+)
+
+const auditFileName = "audit.log"
+
+// auditLog appends a line for each write motley makes to storage, with who made it and when.
+// Unlike the journal, it is never compacted.
+type auditLog struct {
+	mu    sync.Mutex
+	path  string
+	user  string
+	logFn loggerFn
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func openAuditLog(logFn loggerFn) *auditLog {
+	dir, err := config.StateDir()
+	if err != nil {
+		logFn("unable to open audit log, writes won't be recorded: %s", err)
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		logFn("unable to open audit log, writes won't be recorded: %s", err)
+		return nil
+	}
+	return &auditLog{path: filepath.Join(dir, auditFileName), user: currentUser(), logFn: logFn}
+}
+
+func (a *auditLog) record(e env.Type, op string, iri pub.IRI, err error) {
+	if a == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = fmt.Sprintf("error: %q", err.Error())
+	}
+	if e == "" {
+		e = "-"
+	}
+	line := fmt.Sprintf("%s user=%s env=%s op=%s iri=%s result=%s\n", time.Now().UTC().Format(time.RFC3339), a.user, e, op, iri, result)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, ferr := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if ferr != nil {
+		a.logFn("unable to write audit log: %s", ferr)
+		return
+	}
+	defer f.Close()
+	if _, ferr = f.WriteString(line); ferr != nil {
+		a.logFn("unable to write audit log: %s", ferr)
+	}
+}
@@ -0,0 +1,310 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+	"github.com/go-ap/filters"
+)
+
+var (
+	blocksKey = key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "show blocked actors and domains"),
+	)
+	blocksAddKey = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "block actor or domain"),
+	)
+	blocksRemoveKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "remove block"),
+	)
+)
+
+// blockEntry is an actor or a domain blocked by the root actor of a store.
+type blockEntry struct {
+	store Store
+	iri   pub.IRI
+	// activities holds the Block activities which have iri as object.
+	activities pub.IRIs
+	// inCollection shows if iri is present in the blocked collection of the root actor.
+	inCollection bool
+}
+
+func iriIsDomain(iri pub.IRI) bool {
+	u, err := iri.URL()
+	if err != nil {
+		return false
+	}
+	return u.Path == "" || u.Path == "/"
+}
+
+func (b blockEntry) isDomain() bool {
+	return iriIsDomain(b.iri)
+}
+
+// parseBlockTarget accepts either an actor IRI, or a host name, which is converted to a domain IRI.
+func parseBlockTarget(s string) (pub.IRI, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("empty block target")
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", errors.Annotatef(err, "invalid block target %s", s)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid block target %s: missing host", s)
+	}
+	if u.Path == "" || u.Path == "/" {
+		return pub.IRI(fmt.Sprintf("%s://%s", u.Scheme, u.Host)), nil
+	}
+	return pub.IRI(u.String()), nil
+}
+
+func loadBlocks(ctx context.Context, f *fedbox) ([]blockEntry, error) {
+	entries := make([]blockEntry, 0)
+	errs := make([]error, 0)
+	for _, st := range f.stores {
+		byIRI := make(map[pub.IRI]*blockEntry)
+		entryFor := func(iri pub.IRI) *blockEntry {
+			if e, ok := byIRI[iri]; ok {
+				return e
+			}
+			e := &blockEntry{store: st, iri: iri}
+			byIRI[iri] = e
+			return e
+		}
+
+		blocked := func(_ context.Context, col pub.CollectionInterface) error {
+			for _, it := range col.Collection() {
+				if !pub.IsNil(it) {
+					entryFor(it.GetLink()).inCollection = true
+				}
+			}
+			return nil
+		}
+		if err := accumFn(blocked).LoadFromSearch(ctx, f, filters.BlockedType.IRI(st.root), filters.WithMaxCount(moderationMaxItems)); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+
+		outbox := pub.Outbox.IRI(st.root)
+		undone := activityObjects(ctx, f, outbox, pub.UndoType)
+		blocks := func(_ context.Context, col pub.CollectionInterface) error {
+			for _, it := range col.Collection() {
+				if undone.Contains(it.GetLink()) {
+					continue
+				}
+				_ = pub.OnActivity(it, func(act *pub.Activity) error {
+					for _, ob := range itemIRIs(act.Object) {
+						e := entryFor(ob)
+						e.activities = append(e.activities, act.GetLink())
+					}
+					return nil
+				})
+			}
+			return nil
+		}
+		ff := []filters.Check{filters.HasType(pub.BlockType), filters.WithMaxCount(moderationMaxItems)}
+		if err := accumFn(blocks).LoadFromSearch(ctx, f, outbox, ff...); err != nil {
+			errs = append(errs, err)
+		}
+
+		storeEntries := make([]blockEntry, 0, len(byIRI))
+		for _, e := range byIRI {
+			storeEntries = append(storeEntries, *e)
+		}
+		sort.Slice(storeEntries, func(i, j int) bool {
+			if storeEntries[i].isDomain() != storeEntries[j].isDomain() {
+				return storeEntries[i].isDomain()
+			}
+			return storeEntries[i].iri < storeEntries[j].iri
+		})
+		entries = append(entries, storeEntries...)
+	}
+	return entries, errors.Join(errs...)
+}
+
+type blocksLoadedMsg struct {
+	entries []blockEntry
+	err     error
+}
+
+type blocksChangedMsg struct {
+	err error
+}
+
+// BlocksModel lists the actors and domains blocked by the root actors of the stores,
+// and allows adding and removing blocks.
+// Adding a block emits a Block activity and appends the IRI to the blocked collection,
+// removing it emits an Undo for every corresponding Block activity and removes the IRI from the collection.
+type BlocksModel struct {
+	*commonModel
+
+	loading bool
+	cursor  int
+	entries []blockEntry
+	err     error
+}
+
+func newBlocksModel(common *commonModel) *BlocksModel {
+	return &BlocksModel{commonModel: common}
+}
+
+func (b *BlocksModel) Init() tea.Cmd {
+	if b.f == nil {
+		return noop
+	}
+	b.loading = true
+	f := b.f
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		entries, err := loadBlocks(ctx, f)
+		return blocksLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// currentStore returns the store of the root actor which is currently selected in the tree,
+// falling back to the first store.
+func (b *BlocksModel) currentStore() (Store, error) {
+	if !pub.IsNil(b.root) {
+		return b.f.storeFor(b.root.GetLink())
+	}
+	if len(b.f.stores) == 0 {
+		return Store{}, fmt.Errorf("no storage available")
+	}
+	return b.f.stores[0], nil
+}
+
+func (b *BlocksModel) block(iri pub.IRI) tea.Cmd {
+	st, err := b.currentStore()
+	if err != nil {
+		return errCmd(err)
+	}
+	f := b.f
+	return confirmWrites(func() tea.Cmd {
+		return func() tea.Msg {
+			release, err := beginActions(st)
+			if err != nil {
+				return blocksChangedMsg{err: err}
+			}
+			defer release()
+			if err := f.AddTo(filters.BlockedType.IRI(st.root), iri); err != nil {
+				return blocksChangedMsg{err: errors.Annotatef(err, "unable to block %s", iri)}
+			}
+			_, err = f.emit(st, pub.BlockNew("", iri))
+			return blocksChangedMsg{err: err}
+		}
+	}, st)
+}
+
+func (b *BlocksModel) unblock(e blockEntry) tea.Cmd {
+	f := b.f
+	return confirmWrites(func() tea.Cmd {
+		return func() tea.Msg {
+			release, err := beginActions(e.store)
+			if err != nil {
+				return blocksChangedMsg{err: err}
+			}
+			defer release()
+			if e.inCollection {
+				if err := f.RemoveFrom(filters.BlockedType.IRI(e.store.root), e.iri); err != nil {
+					return blocksChangedMsg{err: errors.Annotatef(err, "unable to unblock %s", e.iri)}
+				}
+			}
+			for _, act := range e.activities {
+				if _, err := f.emit(e.store, pub.UndoNew("", act)); err != nil {
+					return blocksChangedMsg{err: err}
+				}
+			}
+			return blocksChangedMsg{}
+		}
+	}, e.store)
+}
+
+func (b *BlocksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case blocksLoadedMsg:
+		b.loading = false
+		b.entries = mm.entries
+		b.err = mm.err
+		b.cursor = clamp(b.cursor, 0, max(0, len(b.entries)-1))
+	case blocksChangedMsg:
+		if mm.err != nil {
+			return b, errCmd(mm.err)
+		}
+		return b, b.Init()
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(mm, listUpKey):
+			b.cursor = clamp(b.cursor-1, 0, max(0, len(b.entries)-1))
+		case key.Matches(mm, listDownKey):
+			b.cursor = clamp(b.cursor+1, 0, max(0, len(b.entries)-1))
+		case key.Matches(mm, blocksAddKey):
+			return b, promptCmd("Block actor IRI or host", "", func(s string) tea.Cmd {
+				iri, err := parseBlockTarget(s)
+				if err != nil {
+					return errCmd(err)
+				}
+				return b.block(iri)
+			})
+		case key.Matches(mm, blocksRemoveKey):
+			if b.cursor >= 0 && b.cursor < len(b.entries) {
+				e := b.entries[b.cursor]
+				return b, confirmCmd("Unblock "+e.iri.String()+"?", func() tea.Cmd {
+					return b.unblock(e)
+				})
+			}
+		}
+	}
+	return b, noop
+}
+
+func (b *BlocksModel) View() tea.View {
+	pieces := []string{viewTitleStyle.Render("Blocks")}
+	if b.err != nil {
+		pieces = append(pieces, faintRedFg.Render(b.err.Error()))
+	}
+	switch {
+	case b.loading:
+		pieces = append(pieces, "Loading blocks"+ellipsis)
+	case len(b.entries) == 0:
+		pieces = append(pieces, "No blocked actors or domains")
+	default:
+		var root pub.IRI
+		for i, e := range b.entries {
+			if r := e.store.root.GetLink(); r != root {
+				root = r
+				pieces = append(pieces, "", lipgloss.NewStyle().Bold(true).Render(getNameFromItem(e.store.root)))
+			}
+			kind := "actor "
+			if e.isDomain() {
+				kind = "domain"
+			}
+			line := fmt.Sprintf("%s %s", kind, e.iri)
+			if !e.inCollection {
+				line += " (not in blocked collection)"
+			}
+			if i == b.cursor {
+				line = hintFg.Render(line)
+			}
+			pieces = append(pieces, line)
+		}
+	}
+	pieces = append(pieces, "", helpLine(blocksAddKey, blocksRemoveKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
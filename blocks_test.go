@@ -0,0 +1,65 @@
+package motley
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestParseBlockTarget(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    pub.IRI
+		wantErr bool
+	}{
+		{in: "example.com", want: "https://example.com"},
+		{in: " https://example.com/ ", want: "https://example.com"},
+		{in: "http://example.com", want: "http://example.com"},
+		{in: "https://example.com/actors/jdoe", want: "https://example.com/actors/jdoe"},
+		{in: "", wantErr: true},
+		{in: "https://", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBlockTarget(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBlockTarget(%q) error = %v, expected error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBlockTarget(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIRIIsDomain(t *testing.T) {
+	tests := map[pub.IRI]bool{
+		"https://example.com":              true,
+		"https://example.com/":             true,
+		"https://example.com/actors/jdoe":  false,
+		"https://example.com/actors/jdoe/": false,
+	}
+	for iri, want := range tests {
+		if got := iriIsDomain(iri); got != want {
+			t.Errorf("iriIsDomain(%q) = %t, expected %t", iri, got, want)
+		}
+	}
+}
+
+func TestBlocks_unblockConfirms(t *testing.T) {
+	f, st := newTestFedbox(t)
+	b := newBlocksModel(&commonModel{f: f})
+	b.entries = []blockEntry{{store: st, iri: "https://example.org/actors/spam", inCollection: true}}
+
+	_, cmd := b.Update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	prompt, ok := findMsg[promptMsg](cmd)
+	if !ok {
+		t.Fatalf("unblocking should ask for confirmation")
+	}
+	if cmd := prompt.submitFn("n"); cmd != nil {
+		t.Errorf("declining should not unblock")
+	}
+	if cmd := prompt.submitFn("y"); cmd == nil {
+		t.Errorf("confirming should unblock")
+	}
+}
@@ -0,0 +1,227 @@
+package motley
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	vocab "github.com/go-ap/activitypub"
+)
+
+const bookmarksStateName = "bookmarks"
+
+var (
+	bookmarkAddKey = key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "bookmark current element"),
+	)
+	bookmarksKey = key.NewBinding(
+		key.WithKeys("'"),
+		key.WithHelp("'", "show bookmarks"),
+	)
+	bookmarkJumpKey = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "jump to bookmark"),
+	)
+	bookmarkRemoveKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "remove bookmark"),
+	)
+	popupCloseKey = key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc", "close"),
+	)
+)
+
+type bookmark struct {
+	IRI   vocab.IRI `json:"iri"`
+	Name  string    `json:"name"`
+	Added time.Time `json:"added"`
+}
+
+// bookmarks holds the bookmarks for each instance, keyed by the IRI of its root actor.
+type bookmarks map[vocab.IRI][]bookmark
+
+func loadBookmarks() (bookmarks, error) {
+	b := make(bookmarks)
+	if err := config.LoadState(bookmarksStateName, &b); err != nil {
+		return b, err
+	}
+	return b, nil
+}
+
+func (b bookmarks) add(instance vocab.IRI, bm bookmark) bool {
+	for _, existing := range b[instance] {
+		if existing.IRI.Equals(bm.IRI, false) {
+			return false
+		}
+	}
+	b[instance] = append(b[instance], bm)
+	return true
+}
+
+func (b bookmarks) remove(instance, iri vocab.IRI) {
+	kept := b[instance][:0]
+	for _, existing := range b[instance] {
+		if !existing.IRI.Equals(iri, false) {
+			kept = append(kept, existing)
+		}
+	}
+	b[instance] = kept
+}
+
+func (b bookmarks) save() error {
+	return config.SaveState(bookmarksStateName, b)
+}
+
+// instanceFor returns the IRI of the root actor of the store containing iri.
+func (f *fedbox) instanceFor(iri vocab.IRI) (vocab.IRI, error) {
+	st, err := f.storeFor(iri)
+	if err != nil {
+		return "", err
+	}
+	return st.root.GetLink(), nil
+}
+
+func (m *model) addBookmark() tea.Cmd {
+	if m.currentNode == nil || m.f == nil {
+		return errCmd(fmt.Errorf("no element selected"))
+	}
+	iri := m.currentNode.GetLink()
+	instance, err := m.f.instanceFor(iri)
+	if err != nil {
+		return errCmd(err)
+	}
+	b, err := loadBookmarks()
+	if err != nil {
+		return errCmd(err)
+	}
+	if !b.add(instance, bookmark{IRI: iri, Name: m.currentNode.n, Added: time.Now().UTC()}) {
+		return errCmd(fmt.Errorf("%s is already bookmarked", iri))
+	}
+	if err := b.save(); err != nil {
+		return errCmd(err)
+	}
+	return statusMessageCmd("bookmarked %s", iri)
+}
+
+type closePopupMsg struct{}
+
+func closePopupCmd() tea.Msg {
+	return closePopupMsg{}
+}
+
+type bookmarkEntry struct {
+	instance vocab.IRI
+	bookmark
+}
+
+// BookmarksModel lists the bookmarks of the instances that are currently opened.
+type BookmarksModel struct {
+	*commonModel
+
+	entries []bookmarkEntry
+	cursor  int
+	err     error
+}
+
+func newBookmarksModel(common *commonModel) *BookmarksModel {
+	return &BookmarksModel{commonModel: common}
+}
+
+func (b *BookmarksModel) Init() tea.Cmd {
+	all, err := loadBookmarks()
+	b.err = err
+	b.entries = b.entries[:0]
+	for _, st := range b.f.stores {
+		instance := st.root.GetLink()
+		for _, bm := range all[instance] {
+			b.entries = append(b.entries, bookmarkEntry{instance: instance, bookmark: bm})
+		}
+	}
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return b.entries[i].instance < b.entries[j].instance
+	})
+	b.cursor = clamp(b.cursor, 0, max(0, len(b.entries)-1))
+	return noop
+}
+
+func (b *BookmarksModel) remove(e bookmarkEntry) tea.Cmd {
+	all, err := loadBookmarks()
+	if err != nil {
+		return errCmd(err)
+	}
+	all.remove(e.instance, e.IRI)
+	if err := all.save(); err != nil {
+		return errCmd(err)
+	}
+	return b.Init()
+}
+
+func (b *BookmarksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	mm, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return b, noop
+	}
+	switch {
+	case key.Matches(mm, listUpKey):
+		b.cursor = clamp(b.cursor-1, 0, max(0, len(b.entries)-1))
+	case key.Matches(mm, listDownKey):
+		b.cursor = clamp(b.cursor+1, 0, max(0, len(b.entries)-1))
+	case key.Matches(mm, bookmarkJumpKey):
+		if b.cursor < len(b.entries) {
+			return b, tea.Batch(closePopupCmd, followCmd(b.entries[b.cursor].IRI))
+		}
+	case key.Matches(mm, bookmarkRemoveKey):
+		if b.cursor < len(b.entries) {
+			return b, b.remove(b.entries[b.cursor])
+		}
+	case key.Matches(mm, popupCloseKey):
+		return b, closePopupCmd
+	}
+	return b, noop
+}
+
+func (b *BookmarksModel) View() tea.View {
+	pieces := []string{viewTitleStyle.Render("Bookmarks")}
+	if b.err != nil {
+		pieces = append(pieces, faintRedFg.Render(b.err.Error()))
+	}
+	if len(b.entries) == 0 {
+		pieces = append(pieces, "No bookmarks, press "+bookmarkAddKey.Help().Key+" to bookmark the current element")
+	}
+	var instance vocab.IRI
+	for i, e := range b.entries {
+		if e.instance != instance {
+			instance = e.instance
+			pieces = append(pieces, lipgloss.NewStyle().Bold(true).Render(instance.String()))
+		}
+		line := fmt.Sprintf("%s %s", e.Name, faintStyle.Render(e.IRI.String()))
+		if i == b.cursor {
+			line = hintFg.Render(fmt.Sprintf("%s %s", e.Name, e.IRI))
+		}
+		pieces = append(pieces, line)
+	}
+	pieces = append(pieces, "", helpLine(bookmarkJumpKey, bookmarkRemoveKey, popupCloseKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
+
+// renderPopup draws the popup content in a bordered box, centered over the base view.
+func renderPopup(base, content string, width, height int) string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(hintColor).
+		Padding(0, 1).
+		MaxWidth(width).
+		Render(content)
+	x := max(0, (width-lipgloss.Width(box))/2)
+	y := max(0, (height-lipgloss.Height(box))/2)
+	return lipgloss.NewCompositor(
+		lipgloss.NewLayer(base),
+		lipgloss.NewLayer(box).X(x).Y(y).Z(1),
+	).Render()
+}
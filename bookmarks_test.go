@@ -0,0 +1,67 @@
+package motley
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestBookmarks_add(t *testing.T) {
+	m, st := newTestModel(t)
+	m.currentNode = node(pub.Inbox.IRI(st.root))
+
+	if _, ok := findMsg[error](m.addBookmark()); ok {
+		t.Fatalf("bookmarking the current node should not fail")
+	}
+	if _, ok := findMsg[error](m.addBookmark()); !ok {
+		t.Errorf("bookmarking the current node again should fail")
+	}
+	all, err := loadBookmarks()
+	if err != nil {
+		t.Fatalf("unable to load bookmarks: %s", err)
+	}
+	if bms := all[testRoot]; len(bms) != 1 || bms[0].IRI != pub.Inbox.IRI(st.root) {
+		t.Errorf("expected the inbox to be bookmarked for %s, got %v", testRoot, all)
+	}
+}
+
+func TestBookmarks_jumpAndRemove(t *testing.T) {
+	m, st := newTestModel(t)
+	inbox, outbox := pub.Inbox.IRI(st.root), pub.Outbox.IRI(st.root)
+	all := bookmarks{
+		testRoot:                       {{IRI: inbox, Name: "inbox", Added: time.Now()}, {IRI: outbox, Name: "outbox", Added: time.Now()}},
+		pub.IRI("https://example.org"): {{IRI: "https://example.org/inbox", Name: "other", Added: time.Now()}},
+	}
+	if err := all.save(); err != nil {
+		t.Fatalf("unable to save bookmarks: %s", err)
+	}
+
+	b := newBookmarksModel(m.commonModel)
+	b.Init()
+	if len(b.entries) != 2 {
+		t.Fatalf("only the bookmarks of the open instances should be listed, got %d", len(b.entries))
+	}
+
+	b.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	_, cmd := b.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if msg, ok := findMsg[followMsg](cmd); !ok || pub.IRI(msg) != outbox {
+		t.Errorf("jumping should follow %s, got %v", outbox, msg)
+	}
+	if _, ok := findMsg[closePopupMsg](cmd); !ok {
+		t.Errorf("jumping should close the bookmarks")
+	}
+
+	b.Update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	if len(b.entries) != 1 || b.entries[0].IRI != inbox {
+		t.Fatalf("the selected bookmark should be removed, got %v", b.entries)
+	}
+	saved, err := loadBookmarks()
+	if err != nil {
+		t.Fatalf("unable to load bookmarks: %s", err)
+	}
+	if len(saved[testRoot]) != 1 || len(saved["https://example.org"]) != 1 {
+		t.Errorf("the removal should be saved and keep the other instances, got %v", saved)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"git.sr.ht/~mariusor/lw"
 	"git.sr.ht/~mariusor/motley/internal/cmd"
@@ -23,9 +24,13 @@ import (
 var version = "HEAD"
 
 var Motley struct {
-	Version kong.VersionFlag
-	Path    []string `flag:"" name:"path" help:"Storage DSN strings of form type:/path/to/storage. Possible types: ${types}"`
-	URL     []string `flag:"" name:"url" help:"The url used by the application."`
+	Version         kong.VersionFlag
+	Path            []string      `flag:"" name:"path" help:"Storage DSN strings of form type:/path/to/storage. Possible types: ${types}"`
+	URL             []string      `flag:"" name:"url" help:"The url used by the application."`
+	Lang            []string      `flag:"" name:"lang" env:"MOTLEY_LANGUAGES" help:"Preferred languages for showing content, in order of preference."`
+	Env             string        `flag:"" name:"env" env:"MOTLEY_ENV" help:"The environment of the storage: ${envs}."`
+	ReadOnly        string        `flag:"" name:"read-only" env:"MOTLEY_READ_ONLY" enum:"auto,on,off" default:"auto" help:"Prevent changes to storage: auto (only in ${prod}), on, off."`
+	ProdWriteWindow time.Duration `flag:"" name:"prod-write-window" env:"MOTLEY_PROD_WRITE_WINDOW" default:"0s" help:"How long changes to ${prod} storage don't need the hostname confirmed again. By default every change needs it."`
 }
 
 func openlog(name string) io.Writer {
@@ -59,6 +64,7 @@ func main() {
 			"envs":    strings.Join([]string{string(env.DEV), string(env.QA), string(env.PROD)}, ", "),
 			"types":   strings.Join([]string{string(config.StorageBoltDB), string(config.StorageBadger), string(config.StorageFS)}, ", "),
 			"version": version,
+			"prod":    string(env.PROD),
 		},
 	)
 
@@ -76,6 +82,9 @@ func main() {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		ktx.Exit(1)
 	}
+	if conf.Layout, err = config.LoadLayout(); err != nil {
+		l.Warnf("unable to load layout: %s", err)
+	}
 
 	l.Infof("Started")
 	if err := cmd.ShowTui(conf, l); err != nil {
@@ -104,12 +113,16 @@ func loadArguments(conf *config.Options) ([]storage.FullStorage, error) {
 		}
 		conf.URLs = append(conf.URLs, u)
 	}
+	if Motley.Env != "" && !env.ValidType(env.Type(Motley.Env)) {
+		errs = append(errs, fmt.Errorf("invalid environment value %s", Motley.Env))
+	}
 	for _, sto := range Motley.Path {
 		if sto == "" {
 			continue
 		}
 		typ, path := config.ParseStorageDSN(sto)
 		st := config.Storage{
+			Env:  env.Type(Motley.Env),
 			Type: typ,
 			Path: filepath.Clean(path),
 		}
@@ -119,6 +132,11 @@ func loadArguments(conf *config.Options) ([]storage.FullStorage, error) {
 		}
 		conf.Storage = append(conf.Storage, st)
 	}
+	conf.ReadOnly = config.ReadOnly(Motley.ReadOnly)
+	conf.ProdWriteWindow = Motley.ProdWriteWindow
+	for _, lang := range Motley.Lang {
+		conf.Languages = append(conf.Languages, config.ParseLanguages(lang)...)
+	}
 	if len(errs) > 0 {
 		return nil, xerrors.Join(errs...)
 	}
@@ -15,8 +15,8 @@ type CollectionModel struct {
 	Total uint
 }
 
-func newCollectionModel() CollectionModel {
-	return CollectionModel{}
+func newCollectionModel(langs []vocab.LangRef) CollectionModel {
+	return CollectionModel{ObjectModel: newObjectModel(langs)}
 }
 
 func (c CollectionModel) Init() tea.Cmd {
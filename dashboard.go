@@ -0,0 +1,258 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/env"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+)
+
+const (
+	dashboardMaxItems = 10000
+	dashboardDays     = 30
+)
+
+var dashboardKey = key.NewBinding(
+	key.WithKeys("D"),
+	key.WithHelp("D", "show statistics dashboard"),
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders the values as a single line of block characters scaled to the maximum value.
+func sparkline(values []int) string {
+	top := 0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	s := strings.Builder{}
+	for _, v := range values {
+		if top == 0 || v == 0 {
+			s.WriteRune(' ')
+			continue
+		}
+		idx := (v*len(sparkTicks) - 1) / top
+		s.WriteRune(sparkTicks[clamp(idx, 0, len(sparkTicks)-1)])
+	}
+	return s.String()
+}
+
+type typeCounts map[string]int
+
+func (t typeCounts) total() int {
+	total := 0
+	for _, c := range t {
+		total += c
+	}
+	return total
+}
+
+func (t typeCounts) sorted() []string {
+	types := make([]string, 0, len(t))
+	for typ := range t {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if t[types[i]] == t[types[j]] {
+			return types[i] < types[j]
+		}
+		return t[types[i]] > t[types[j]]
+	})
+	return types
+}
+
+type collectionSize struct {
+	name  string
+	total uint
+}
+
+type storeStats struct {
+	root pub.Item
+	env  env.Type
+
+	actors      typeCounts
+	objects     typeCounts
+	activities  typeCounts
+	collections []collectionSize
+	// growth holds the number of items published per day, for the last dashboardDays days, oldest first.
+	growth []int
+
+	err error
+}
+
+func (s *storeStats) countPublished(it pub.Item, now time.Time) {
+	p := published(it)
+	if p.IsZero() {
+		return
+	}
+	days := int(now.Sub(p).Hours() / 24)
+	if days < 0 || days >= len(s.growth) {
+		return
+	}
+	s.growth[len(s.growth)-1-days]++
+}
+
+func loadStoreStats(ctx context.Context, f *fedbox, st Store) storeStats {
+	stats := storeStats{
+		root:       st.root,
+		env:        st.env,
+		actors:     make(typeCounts),
+		objects:    make(typeCounts),
+		activities: make(typeCounts),
+		growth:     make([]int, dashboardDays),
+	}
+	now := time.Now()
+
+	countInto := func(counts typeCounts, total *uint) accumFn {
+		seen := 0
+		return func(_ context.Context, col pub.CollectionInterface) error {
+			if c := col.Count(); c > *total {
+				*total = c
+			}
+			for _, it := range col.Collection() {
+				if pub.IsNil(it) {
+					continue
+				}
+				counts[ItemType(it)]++
+				stats.countPublished(it, now)
+				seen++
+			}
+			if seen >= dashboardMaxItems {
+				return StopLoad{}
+			}
+			return nil
+		}
+	}
+
+	collections := []struct {
+		path   pub.CollectionPath
+		counts typeCounts
+	}{
+		{path: filters.ActorsType, counts: stats.actors},
+		{path: filters.ObjectsType, counts: stats.objects},
+		{path: filters.ActivitiesType, counts: stats.activities},
+	}
+	for _, c := range collections {
+		var total uint
+		iri := c.path.IRI(st.root)
+		if err := countInto(c.counts, &total).LoadFromSearch(ctx, f, iri, filters.WithMaxCount(100)); err != nil {
+			stats.err = err
+		}
+		stats.collections = append(stats.collections, collectionSize{name: string(c.path), total: total})
+	}
+	for _, path := range []pub.CollectionPath{pub.Inbox, pub.Outbox, pub.Followers, pub.Following} {
+		col, err := f.Load(path.IRI(st.root), filters.WithMaxCount(1))
+		if err != nil {
+			continue
+		}
+		_ = pub.OnCollectionIntf(col, func(c pub.CollectionInterface) error {
+			stats.collections = append(stats.collections, collectionSize{name: string(path), total: c.Count()})
+			return nil
+		})
+	}
+	return stats
+}
+
+type dashboardLoadedMsg []storeStats
+
+// DashboardModel shows an overview of the contents of all the opened stores.
+type DashboardModel struct {
+	*commonModel
+
+	loading bool
+	stats   []storeStats
+}
+
+func newDashboardModel(common *commonModel) *DashboardModel {
+	return &DashboardModel{commonModel: common}
+}
+
+func (d *DashboardModel) Init() tea.Cmd {
+	if d.f == nil {
+		return noop
+	}
+	d.loading = true
+	f := d.f
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		stats := make([]storeStats, 0, len(f.stores))
+		for _, st := range f.stores {
+			stats = append(stats, loadStoreStats(ctx, f, st))
+		}
+		return dashboardLoadedMsg(stats)
+	}
+}
+
+func (d *DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case dashboardLoadedMsg:
+		d.loading = false
+		d.stats = mm
+	}
+	return d, noop
+}
+
+func renderTypeCounts(label string, counts typeCounts) string {
+	labelStyle := lipgloss.NewStyle().Bold(true).Width(12).MarginRight(1)
+	if len(counts) == 0 {
+		return lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render(label), "0")
+	}
+	lines := []string{fmt.Sprintf("%d", counts.total())}
+	for _, typ := range counts.sorted() {
+		lines = append(lines, fmt.Sprintf("  %-20s %6d", typ, counts[typ]))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render(label), lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (s storeStats) View() string {
+	labelStyle := lipgloss.NewStyle().Bold(true).Width(12).MarginRight(1)
+
+	pieces := make([]string, 0)
+	pieces = append(pieces, viewTitleStyle.Render(logoView(name(s.root), s.env)+" "+s.root.GetLink().String()))
+	if s.err != nil {
+		pieces = append(pieces, faintRedFg.Render(s.err.Error()))
+	}
+	pieces = append(pieces, renderTypeCounts("Actors", s.actors))
+	pieces = append(pieces, renderTypeCounts("Objects", s.objects))
+	pieces = append(pieces, renderTypeCounts("Activities", s.activities))
+
+	sizes := make([]string, 0, len(s.collections))
+	for _, c := range s.collections {
+		sizes = append(sizes, fmt.Sprintf("%-12s %6d", c.name, c.total))
+	}
+	pieces = append(pieces, lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render("Collections"), lipgloss.JoinVertical(lipgloss.Left, sizes...)))
+
+	growth := 0
+	for _, g := range s.growth {
+		growth += g
+	}
+	spark := lipgloss.NewStyle().Foreground(Indigo).Render(sparkline(s.growth))
+	pieces = append(pieces, lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render("Growth"),
+		fmt.Sprintf("%s %d in the last %d days", spark, growth, dashboardDays)))
+
+	return lipgloss.JoinVertical(lipgloss.Left, pieces...)
+}
+
+func (d *DashboardModel) View() tea.View {
+	if d.loading {
+		return tea.NewView("Loading statistics" + ellipsis)
+	}
+	if len(d.stats) == 0 {
+		return M.View()
+	}
+	pieces := make([]string, 0, len(d.stats))
+	for _, s := range d.stats {
+		pieces = append(pieces, s.View(), "")
+	}
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,71 @@
+package motley
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int{0, 1, 2, 4}); got != " ▂▄█" {
+		t.Errorf("sparkline() = %q, expected %q", got, " ▂▄█")
+	}
+	if got := sparkline([]int{0, 0}); got != "  " {
+		t.Errorf("sparkline() of no values = %q, expected blanks", got)
+	}
+}
+
+func TestLoadStoreStats(t *testing.T) {
+	f, st := newTestFedbox(t)
+	now := time.Now().UTC()
+	items := map[pub.CollectionPath][]pub.Item{
+		filters.ActorsType: {
+			&pub.Actor{ID: filters.ActorsType.IRI(testRoot).AddPath("jdoe"), Type: pub.PersonType, Published: now},
+		},
+		filters.ObjectsType: {
+			&pub.Object{ID: filters.ObjectsType.IRI(testRoot).AddPath("1"), Type: pub.NoteType, Published: now},
+			&pub.Object{ID: filters.ObjectsType.IRI(testRoot).AddPath("2"), Type: pub.NoteType, Published: now.Add(-48 * time.Hour)},
+			&pub.Object{ID: filters.ObjectsType.IRI(testRoot).AddPath("3"), Type: pub.ImageType},
+		},
+		filters.ActivitiesType: {
+			&pub.Activity{ID: filters.ActivitiesType.IRI(testRoot).AddPath("1"), Type: pub.CreateType, Published: now},
+		},
+	}
+	for path, col := range items {
+		if _, err := st.s.Create(&pub.OrderedCollection{ID: path.IRI(testRoot), Type: pub.OrderedCollectionType}); err != nil {
+			t.Fatalf("unable to create collection %s: %s", path, err)
+		}
+		for _, it := range col {
+			if _, err := st.s.Save(it); err != nil {
+				t.Fatalf("unable to save %s: %s", it.GetLink(), err)
+			}
+			if err := st.s.AddTo(path.IRI(testRoot), it); err != nil {
+				t.Fatalf("unable to add %s to %s: %s", it.GetLink(), path, err)
+			}
+		}
+	}
+
+	stats := loadStoreStats(context.Background(), f, st)
+	if stats.err != nil {
+		t.Fatalf("unable to load stats: %s", stats.err)
+	}
+	if stats.actors[string(pub.PersonType)] != 1 || stats.actors.total() != 1 {
+		t.Errorf("expected 1 Person, got %v", stats.actors)
+	}
+	if stats.objects[string(pub.NoteType)] != 2 || stats.objects[string(pub.ImageType)] != 1 {
+		t.Errorf("expected 2 Notes and 1 Image, got %v", stats.objects)
+	}
+	if sorted := stats.objects.sorted(); len(sorted) != 2 || sorted[0] != string(pub.NoteType) {
+		t.Errorf("the most used type should be first, got %v", sorted)
+	}
+	if stats.activities[string(pub.CreateType)] != 1 {
+		t.Errorf("expected 1 Create, got %v", stats.activities)
+	}
+	last := len(stats.growth) - 1
+	if stats.growth[last] != 3 || stats.growth[last-2] != 1 {
+		t.Errorf("expected 3 items published today and 1 two days ago, got %v", stats.growth)
+	}
+}
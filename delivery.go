@@ -0,0 +1,293 @@
+package motley
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	"git.sr.ht/~mariusor/motley/internal/httpsig"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/jsonld"
+)
+
+const (
+	deliveryStateName   = "delivery"
+	deliveryTimeout     = 30 * time.Second
+	deliveryMaxBodySize = 4096
+	activityJSONType    = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+)
+
+var (
+	deliverKey = key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "deliver current activity to an inbox"),
+	)
+	deliverySendKey = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "send"),
+	)
+	deliveryInboxKey = key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "change inbox"),
+	)
+	deliveryEditKey = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit activity"),
+	)
+)
+
+// deliveryState is saved between sessions, so we don't need to type the inbox URL every time.
+type deliveryState struct {
+	Inbox string `json:"inbox"`
+}
+
+func isActivity(it vocab.Item) bool {
+	if vocab.IsNil(it) {
+		return false
+	}
+	return vocab.ActivityTypes.Match(it.GetType()) || vocab.IntransitiveActivityTypes.Match(it.GetType())
+}
+
+func activityActor(it vocab.Item) vocab.IRI {
+	var actor vocab.IRI
+	_ = vocab.OnIntransitiveActivity(it, func(act *vocab.IntransitiveActivity) error {
+		if !vocab.IsNil(act.Actor) {
+			actor = act.Actor.GetLink()
+		}
+		return nil
+	})
+	return actor
+}
+
+type deliveryResultMsg struct {
+	d        *DeliveryModel
+	request  string
+	response string
+	err      error
+}
+
+type deliveryInboxMsg string
+
+type deliveryBodyMsg []byte
+
+// DeliveryModel POSTs an activity to an inbox, signed with the key of its actor, and shows the exchange.
+type DeliveryModel struct {
+	*commonModel
+
+	activity vocab.Item
+	body     []byte
+	inbox    string
+
+	sending  bool
+	request  string
+	response string
+	err      error
+}
+
+func newDeliveryModel(common *commonModel, activity vocab.Item) *DeliveryModel {
+	st := deliveryState{}
+	_ = config.LoadState(deliveryStateName, &st)
+	d := &DeliveryModel{commonModel: common, activity: activity, inbox: st.Inbox}
+	d.body, d.err = jsonld.WithContext(jsonld.IRI(vocab.ActivityBaseURI)).Marshal(activity)
+	return d
+}
+
+func (d *DeliveryModel) Init() tea.Cmd {
+	return noop
+}
+
+func (d *DeliveryModel) setInbox(inbox string) error {
+	u, err := url.Parse(strings.TrimSpace(inbox))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid inbox URL %q", inbox)
+	}
+	d.inbox = u.String()
+	return config.SaveState(deliveryStateName, deliveryState{Inbox: d.inbox})
+}
+
+func (d *DeliveryModel) setBody(body []byte) error {
+	compact := bytes.Buffer{}
+	if err := json.Compact(&compact, body); err != nil {
+		return fmt.Errorf("invalid activity: %w", err)
+	}
+	body = compact.Bytes()
+	it, err := vocab.UnmarshalJSON(body)
+	if err != nil {
+		return fmt.Errorf("invalid activity: %w", err)
+	}
+	if !isActivity(it) {
+		return fmt.Errorf("%s is not an activity", ItemType(it))
+	}
+	d.activity = it
+	d.body = body
+	return nil
+}
+
+// signingKey returns the key id and the private key of the activity's actor, from the storage holding the actor.
+func (d *DeliveryModel) signingKey() (string, any, error) {
+	actor := activityActor(d.activity)
+	if actor == "" {
+		return "", nil, fmt.Errorf("activity has no actor")
+	}
+	st, err := d.f.storeFor(actor)
+	if err != nil {
+		return "", nil, err
+	}
+	prv, err := st.s.LoadKey(actor)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to load the private key of %s: %w", actor, err)
+	}
+	keyID := actor.String() + "#main-key"
+	if it, err := d.f.Load(actor); err == nil {
+		_ = vocab.OnActor(it, func(a *vocab.Actor) error {
+			if a.PublicKey.ID != "" {
+				keyID = a.PublicKey.ID.String()
+			}
+			return nil
+		})
+	}
+	return keyID, prv, nil
+}
+
+func dumpHeaders(b *strings.Builder, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "%s: %s\n", name, strings.Join(h.Values(name), ", "))
+	}
+}
+
+func prettyJSON(body []byte) string {
+	buf := bytes.Buffer{}
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return string(body)
+	}
+	return buf.String()
+}
+
+func (d *DeliveryModel) send() tea.Cmd {
+	if d.inbox == "" {
+		return errCmd(fmt.Errorf("no inbox set, press %s to set it", deliveryInboxKey.Help().Key))
+	}
+	keyID, prv, err := d.signingKey()
+	if err != nil {
+		return errCmd(err)
+	}
+	d.sending = true
+	inbox := d.inbox
+	body := d.body
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+		if err != nil {
+			return deliveryResultMsg{d: d, err: err}
+		}
+		req.Header.Set("Content-Type", activityJSONType)
+		req.Header.Set("Accept", activityJSONType)
+		req.Header.Set("User-Agent", "motley")
+		if err := httpsig.Sign(req, body, keyID, prv); err != nil {
+			return deliveryResultMsg{d: d, err: err}
+		}
+
+		reqDump := strings.Builder{}
+		fmt.Fprintf(&reqDump, "%s %s\nHost: %s\n", req.Method, req.URL, req.URL.Host)
+		dumpHeaders(&reqDump, req.Header)
+		reqDump.WriteString("\n" + prettyJSON(body))
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return deliveryResultMsg{d: d, request: reqDump.String(), err: err}
+		}
+		defer res.Body.Close()
+
+		resDump := strings.Builder{}
+		fmt.Fprintf(&resDump, "%s %s\n", res.Proto, res.Status)
+		dumpHeaders(&resDump, res.Header)
+		if resBody, _ := io.ReadAll(io.LimitReader(res.Body, deliveryMaxBodySize)); len(resBody) > 0 {
+			resDump.WriteString("\n" + prettyJSON(resBody))
+		}
+		return deliveryResultMsg{d: d, request: reqDump.String(), response: resDump.String()}
+	}
+}
+
+func (d *DeliveryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case deliveryResultMsg:
+		if mm.d != d {
+			return d, noop
+		}
+		d.sending = false
+		d.request = mm.request
+		d.response = mm.response
+		d.err = mm.err
+	case deliveryInboxMsg:
+		if err := d.setInbox(string(mm)); err != nil {
+			return d, errCmd(err)
+		}
+	case deliveryBodyMsg:
+		if err := d.setBody(mm); err != nil {
+			return d, errCmd(err)
+		}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(mm, deliverySendKey):
+			if !d.sending {
+				return d, d.send()
+			}
+		case key.Matches(mm, deliveryInboxKey):
+			return d, promptCmd("Inbox URL", d.inbox, func(s string) tea.Cmd {
+				return func() tea.Msg { return deliveryInboxMsg(s) }
+			})
+		case key.Matches(mm, deliveryEditKey):
+			return d, editorCmd("motley-activity-*.json", []byte(prettyJSON(d.body)), func(body []byte) tea.Msg {
+				return deliveryBodyMsg(body)
+			})
+		}
+	}
+	return d, noop
+}
+
+func (d *DeliveryModel) View() tea.View {
+	inbox := d.inbox
+	if inbox == "" {
+		inbox = faintStyle.Render("not set")
+	}
+	pieces := []string{
+		viewTitleStyle.Render("Delivery"),
+		fmt.Sprintf("%s %s", fieldStyle.Render("Activity:"), summarizeActivity(d.activity)),
+		fmt.Sprintf("%s %s", fieldStyle.Render("Inbox:"), inbox),
+	}
+	if d.err != nil {
+		pieces = append(pieces, faintRedFg.Render(d.err.Error()))
+	}
+	switch {
+	case d.sending:
+		pieces = append(pieces, "", "Sending…")
+	case d.request != "":
+		pieces = append(pieces, "", fieldStyle.Render("Request"), d.request)
+		if d.response != "" {
+			pieces = append(pieces, "", fieldStyle.Render("Response"), d.response)
+		}
+	default:
+		pieces = append(pieces, "", prettyJSON(d.body))
+	}
+
+	pieces = append(pieces, "", helpLine(deliverySendKey, deliveryInboxKey, deliveryEditKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,115 @@
+package motley
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"git.sr.ht/~mariusor/motley/internal/httpsig"
+	pub "github.com/go-ap/activitypub"
+)
+
+func newTestDelivery(t *testing.T) (*DeliveryModel, *rsa.PrivateKey) {
+	t.Helper()
+	m, st := newTestModel(t)
+	actor := &pub.Actor{ID: "https://example.com/actors/jdoe", Type: pub.PersonType}
+	if _, err := st.s.Save(actor); err != nil {
+		t.Fatalf("unable to save actor: %s", err)
+	}
+	prv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+	saver, ok := st.s.(keySaver)
+	if !ok {
+		t.Skipf("storage can't save keys")
+	}
+	if _, err := saver.SaveKey(actor.ID, prv); err != nil {
+		t.Fatalf("unable to save key: %s", err)
+	}
+	act := &pub.Activity{ID: "https://example.com/activities/1", Type: pub.LikeType, Actor: actor.ID, Object: pub.IRI("https://example.com/objects/1")}
+	return newDeliveryModel(m.commonModel, act), prv
+}
+
+func TestDelivery_setBody(t *testing.T) {
+	d, _ := newTestDelivery(t)
+
+	if err := d.setBody([]byte("{\n  \"id\": \"https://example.com/activities/2\",\n  \"type\": \"Follow\",\n  \"actor\": \"https://example.com/actors/jdoe\"\n}\n")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.activity.GetType() != pub.FollowType {
+		t.Errorf("the activity should be replaced, got %s", d.activity.GetType())
+	}
+	if strings.ContainsAny(string(d.body), "\n ") {
+		t.Errorf("the body should be compacted, got %s", d.body)
+	}
+	for _, body := range []string{`{"type":`, `{"id":"https://example.com/objects/1","type":"Note"}`} {
+		if err := d.setBody([]byte(body)); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+	if d.activity.GetType() != pub.FollowType {
+		t.Errorf("an invalid body should not replace the activity")
+	}
+}
+
+func TestDelivery_send(t *testing.T) {
+	d, prv := newTestDelivery(t)
+
+	var verifyErr error
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		verifyErr = httpsig.Verify(r, received, prv.Public())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := d.setInbox("not an url"); err == nil {
+		t.Errorf("expected error for invalid inbox")
+	}
+	if err := d.setInbox(srv.URL + "/inbox"); err != nil {
+		t.Fatalf("unable to set inbox: %s", err)
+	}
+	cmd := d.send()
+	if !d.sending {
+		t.Fatalf("the model should be sending")
+	}
+	d.Update(cmd())
+	if d.sending || d.err != nil {
+		t.Fatalf("the delivery should be done, got error %v", d.err)
+	}
+	if verifyErr != nil {
+		t.Errorf("invalid signature: %s", verifyErr)
+	}
+	if string(received) != string(d.body) {
+		t.Errorf("the inbox should receive the activity, got %s", received)
+	}
+	if !strings.Contains(d.response, "202") || !strings.Contains(d.request, "Signature") {
+		t.Errorf("the exchange should be shown, got request %q and response %q", d.request, d.response)
+	}
+}
+
+func TestEditedMsg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activity.json")
+	if err := os.WriteFile(path, []byte(`{"type":"Like"}`), 0600); err != nil {
+		t.Fatalf("unable to write file: %s", err)
+	}
+	msg := editedMsg(path, nil, func(body []byte) tea.Msg { return deliveryBodyMsg(body) })
+	if body, ok := msg.(deliveryBodyMsg); !ok || string(body) != `{"type":"Like"}` {
+		t.Errorf("expected the edited content, got %v", msg)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the edited file should be removed")
+	}
+	if _, ok := editedMsg(path, io.ErrUnexpectedEOF, nil).(error); !ok {
+		t.Errorf("an editor failure should return an error")
+	}
+}
@@ -0,0 +1,255 @@
+package motley
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	vocab "github.com/go-ap/activitypub"
+)
+
+const (
+	nodeInfoSchema   = "http://nodeinfo.diaspora.software/ns/schema/2.1"
+	profilePageRel   = "http://webfinger.net/rel/profile-page"
+	activityJSONMIME = "application/activity+json"
+)
+
+var discoveryKey = key.NewBinding(
+	key.WithKeys("F"),
+	key.WithHelp("F", "preview WebFinger and NodeInfo of current actor"),
+)
+
+// validUsername matches the user part of an acct: URI that most servers accept.
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// localPostTypes are the object types counted as posts in NodeInfo.
+var localPostTypes = vocab.ActivityVocabularyTypes{
+	vocab.NoteType, vocab.ArticleType, vocab.PageType, vocab.QuestionType, vocab.ImageType, vocab.VideoType, vocab.AudioType,
+}
+
+type jrdLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+type jrd struct {
+	Subject string    `json:"subject"`
+	Aliases []string  `json:"aliases,omitempty"`
+	Links   []jrdLink `json:"links"`
+}
+
+type nodeInfoUsers struct {
+	Total int `json:"total"`
+}
+
+type nodeInfo struct {
+	Version  string `json:"version"`
+	Software struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"software"`
+	Protocols         []string `json:"protocols"`
+	OpenRegistrations bool     `json:"openRegistrations"`
+	Usage             struct {
+		Users      nodeInfoUsers `json:"users"`
+		LocalPosts int           `json:"localPosts"`
+	} `json:"usage"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+func itemHost(it vocab.Item) string {
+	if vocab.IsNil(it) {
+		return ""
+	}
+	u, err := url.Parse(it.GetLink().String())
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// itemLinks returns the IRIs of it, which can be a single link or a collection of links.
+func itemLinks(it vocab.Item) []string {
+	links := make([]string, 0)
+	for _, iri := range itemIRIs(it) {
+		links = append(links, iri.String())
+	}
+	return links
+}
+
+// webFinger builds the document the server would return for the actor, and the problems that would prevent
+// other servers from discovering it.
+func webFinger(st Store, a *vocab.Actor) (jrd, []string) {
+	problems := make([]string, 0)
+	host := itemHost(st.root)
+
+	username := a.PreferredUsername.First().String()
+	switch {
+	case username == "":
+		problems = append(problems, "actor has no preferredUsername, it can't be found by WebFinger")
+		username = a.Name.First().String()
+	case !validUsername.MatchString(username):
+		problems = append(problems, fmt.Sprintf("preferredUsername %q contains characters not allowed in acct: URIs", username))
+	}
+	if h := itemHost(a); h != host {
+		problems = append(problems, fmt.Sprintf("actor id host %s doesn't match the storage root host %s", h, host))
+	}
+
+	doc := jrd{
+		Subject: fmt.Sprintf("acct:%s@%s", username, host),
+		Links:   []jrdLink{{Rel: "self", Type: activityJSONMIME, Href: a.ID.String()}},
+	}
+	aliases := []string{a.ID.String()}
+	for _, u := range itemLinks(a.URL) {
+		if h := itemHost(vocab.IRI(u)); h != host {
+			problems = append(problems, fmt.Sprintf("url %s host doesn't match the storage root host %s", u, host))
+		}
+		doc.Links = append(doc.Links, jrdLink{Rel: profilePageRel, Type: "text/html", Href: u})
+		if u != a.ID.String() {
+			aliases = append(aliases, u)
+		}
+	}
+	if vocab.IsNil(a.URL) {
+		problems = append(problems, "actor has no url, there will be no profile page link")
+	}
+	doc.Aliases = aliases
+	if vocab.IsNil(a.Inbox) {
+		problems = append(problems, "actor has no inbox, it can't receive activities")
+	}
+	if a.PublicKey.PublicKeyPem == "" {
+		problems = append(problems, "actor has no public key, its activities can't be verified")
+	}
+	return doc, problems
+}
+
+// buildNodeInfo builds the NodeInfo document of the instance from the statistics of the store.
+func buildNodeInfo(stats storeStats) nodeInfo {
+	ni := nodeInfo{Version: "2.1", Protocols: []string{"activitypub"}, Metadata: map[string]string{}}
+	ni.Software.Name = "fedbox"
+	ni.Usage.Users.Total = stats.actors[string(vocab.PersonType)]
+	for _, typ := range localPostTypes {
+		ni.Usage.LocalPosts += stats.objects[string(typ)]
+	}
+	if !vocab.IsNil(stats.root) {
+		_ = vocab.OnActor(stats.root, func(a *vocab.Actor) error {
+			ni.Metadata["nodeName"] = a.Name.First().String()
+			ni.Metadata["nodeDescription"] = a.Summary.First().String()
+			return nil
+		})
+	}
+	return ni
+}
+
+type discoveryLoadedMsg struct {
+	d        *DiscoveryModel
+	finger   jrd
+	nodeInfo nodeInfo
+	problems []string
+	err      error
+}
+
+// DiscoveryModel shows the WebFinger and NodeInfo documents that would be served for an actor,
+// based on what is found in storage.
+type DiscoveryModel struct {
+	*commonModel
+
+	iri      vocab.IRI
+	loading  bool
+	finger   jrd
+	nodeInfo nodeInfo
+	problems []string
+	err      error
+}
+
+func newDiscoveryModel(common *commonModel, iri vocab.IRI) *DiscoveryModel {
+	return &DiscoveryModel{commonModel: common, iri: iri}
+}
+
+func (d *DiscoveryModel) Init() tea.Cmd {
+	d.loading = true
+	f := d.f
+	iri := d.iri
+	return func() tea.Msg {
+		msg := discoveryLoadedMsg{d: d}
+		st, err := f.storeFor(iri)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		it, err := f.Load(iri)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.err = vocab.OnActor(it, func(a *vocab.Actor) error {
+			msg.finger, msg.problems = webFinger(st, a)
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		stats := loadStoreStats(ctx, f, st)
+		msg.nodeInfo = buildNodeInfo(stats)
+		if stats.actors.total() >= dashboardMaxItems || stats.objects.total() >= dashboardMaxItems {
+			msg.problems = append(msg.problems, fmt.Sprintf("NodeInfo counts are limited to the first %d items", dashboardMaxItems))
+		}
+		return msg
+	}
+}
+
+func (d *DiscoveryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if mm, ok := msg.(discoveryLoadedMsg); ok && mm.d == d {
+		d.loading = false
+		d.finger = mm.finger
+		d.nodeInfo = mm.nodeInfo
+		d.problems = mm.problems
+		d.err = mm.err
+	}
+	return d, noop
+}
+
+func renderJSON(v any) string {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return faintRedFg.Render(err.Error())
+	}
+	return string(raw)
+}
+
+func (d *DiscoveryModel) View() tea.View {
+	pieces := []string{viewTitleStyle.Render("Discovery")}
+	if d.err != nil {
+		pieces = append(pieces, faintRedFg.Render(d.err.Error()))
+	}
+	if d.loading {
+		pieces = append(pieces, "Loading"+ellipsis)
+		return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+	}
+	for _, p := range d.problems {
+		pieces = append(pieces, faintRedFg.Render("✗ "+p))
+	}
+	if len(d.problems) == 0 && d.err == nil {
+		pieces = append(pieces, lipgloss.NewStyle().Foreground(Green).Render("✓ no problems found"))
+	}
+
+	resource := url.QueryEscape(d.finger.Subject)
+	host := itemHost(d.iri)
+	pieces = append(pieces,
+		"",
+		fieldStyle.Render("WebFinger"),
+		faintStyle.Render(fmt.Sprintf("GET https://%s/.well-known/webfinger?resource=%s", host, resource)),
+		renderJSON(d.finger),
+		"",
+		fieldStyle.Render("NodeInfo"),
+		faintStyle.Render(fmt.Sprintf("GET https://%s/.well-known/nodeinfo → %s", host, nodeInfoSchema)),
+		renderJSON(d.nodeInfo),
+	)
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,77 @@
+package motley
+
+import (
+	"strings"
+	"testing"
+
+	pub "github.com/go-ap/activitypub"
+)
+
+func containsProblem(problems []string, part string) bool {
+	for _, p := range problems {
+		if strings.Contains(p, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWebFinger(t *testing.T) {
+	st := Store{root: testRoot}
+	a := &pub.Actor{
+		ID:                "https://example.com/actors/jdoe",
+		Type:              pub.PersonType,
+		PreferredUsername: pub.DefaultNaturalLanguage("jdoe"),
+		URL:               pub.IRI("https://example.com/~jdoe"),
+		Inbox:             pub.IRI("https://example.com/actors/jdoe/inbox"),
+		PublicKey:         pub.PublicKey{PublicKeyPem: "-----BEGIN PUBLIC KEY-----"},
+	}
+	doc, problems := webFinger(st, a)
+	if len(problems) != 0 {
+		t.Errorf("expected no problems for a complete actor, got %v", problems)
+	}
+	if doc.Subject != "acct:jdoe@example.com" {
+		t.Errorf("subject = %q, expected acct:jdoe@example.com", doc.Subject)
+	}
+	if len(doc.Links) != 2 || doc.Links[0].Href != a.ID.String() || doc.Links[1].Href != "https://example.com/~jdoe" {
+		t.Errorf("expected the self and profile page links, got %v", doc.Links)
+	}
+
+	broken := &pub.Actor{
+		ID:                "https://example.org/actors/j doe",
+		Type:              pub.PersonType,
+		PreferredUsername: pub.DefaultNaturalLanguage("j doe"),
+		URL:               pub.IRI("https://example.org/~jdoe"),
+	}
+	_, problems = webFinger(st, broken)
+	for _, part := range []string{"not allowed in acct", "id host", "url https://example.org/~jdoe host", "no inbox", "no public key"} {
+		if !containsProblem(problems, part) {
+			t.Errorf("expected a problem about %q, got %v", part, problems)
+		}
+	}
+	_, problems = webFinger(st, &pub.Actor{ID: "https://example.com/actors/anon", Type: pub.PersonType})
+	for _, part := range []string{"no preferredUsername", "no url"} {
+		if !containsProblem(problems, part) {
+			t.Errorf("expected a problem about %q, got %v", part, problems)
+		}
+	}
+}
+
+func TestBuildNodeInfo(t *testing.T) {
+	root := &pub.Actor{ID: testRoot, Type: pub.ServiceType, Name: pub.DefaultNaturalLanguage("Example"), Summary: pub.DefaultNaturalLanguage("An example")}
+	stats := storeStats{
+		root:    root,
+		actors:  typeCounts{string(pub.PersonType): 2, string(pub.ServiceType): 1},
+		objects: typeCounts{string(pub.NoteType): 3, string(pub.ImageType): 1, string(pub.TombstoneType): 5},
+	}
+	ni := buildNodeInfo(stats)
+	if ni.Usage.Users.Total != 2 {
+		t.Errorf("only the Person actors should be counted as users, got %d", ni.Usage.Users.Total)
+	}
+	if ni.Usage.LocalPosts != 4 {
+		t.Errorf("only the post types should be counted as local posts, got %d", ni.Usage.LocalPosts)
+	}
+	if ni.Metadata["nodeName"] != "Example" || ni.Metadata["nodeDescription"] != "An example" {
+		t.Errorf("the metadata should come from the root actor, got %v", ni.Metadata)
+	}
+}
@@ -6,16 +6,20 @@ import (
 	"net/url"
 	"path"
 	"path/filepath"
+	"slices"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"git.sr.ht/~mariusor/lw"
 	"git.sr.ht/~mariusor/motley/internal/config"
 	"git.sr.ht/~mariusor/motley/internal/env"
+	"git.sr.ht/~mariusor/motley/internal/journal"
 	"git.sr.ht/~mariusor/storage-all"
 	pub "github.com/go-ap/activitypub"
 	"github.com/go-ap/errors"
 	"github.com/go-ap/filters"
+	"github.com/google/uuid"
 	tree "github.com/mariusor/bubbles-tree"
 	"github.com/mariusor/qstring"
 	"golang.org/x/sync/errgroup"
@@ -30,12 +34,19 @@ const (
 	Expanded     = "⊟"
 	Unexpandable = "⬚"
 	Attention    = "⊡"
+	Marked       = "●"
 )
 
 const (
 	NodeSyncing = tree.NodeMaxState << (iota + 1)
 	NodeSynced
 	NodeError
+	// NodeChanged marks nodes which were added or modified in storage while watching it.
+	NodeChanged
+	// NodeLocked marks actors whose account is locked.
+	NodeLocked
+	// NodeMarked marks nodes selected for bulk actions.
+	NodeMarked
 )
 
 type loggerFn func(string, ...interface{})
@@ -46,6 +57,12 @@ type Store struct {
 	root pub.Item
 	env  env.Type
 	s    storage.FullStorage
+
+	// conf is the configuration the storage was opened with, used for watching it for changes.
+	conf config.Storage
+
+	guard *writeGuard
+	audit *auditLog
 }
 
 type fedbox struct {
@@ -53,24 +70,35 @@ type fedbox struct {
 	items  pub.IRIs
 	stores []Store
 	logFn  loggerFn
+
+	// journal records the changes made to storage, so they can be undone.
+	journal *journal.Journal
 }
 
+// WithStore builds a Store for an already opened storage.
+// Like the stores opened from the configuration, it is read-only by default for production environments,
+// and its writes are recorded in the audit log.
 func WithStore(st storage.FullStorage, root pub.Item, environment string) Store {
+	e := env.Type(environment)
 	return Store{
-		root: root,
-		env:  env.Type(environment),
-		s:    st,
+		root:  root,
+		env:   e,
+		s:     st,
+		guard: newWriteGuard(root, e, e.IsProd(), 0),
+		audit: openAuditLog(func(format string, args ...interface{}) { logFn(format, args...) }),
 	}
 }
 
-func fedBOX(rootIRIs []string, st []config.Storage, l lw.Logger) (*fedbox, error) {
+func fedBOX(rootIRIs []string, st []config.Storage, readOnly config.ReadOnly, window time.Duration, l lw.Logger) (*fedbox, error) {
 	logFn = l.Infof
 	stores := make([]Store, 0)
-	var appendStore = func(stores *[]Store, db storage.FullStorage, e env.Type, it pub.Item) {
+	audit := openAuditLog(l.Warnf)
+	var appendStore = func(stores *[]Store, db storage.FullStorage, conf config.Storage, it pub.Item) {
 		if pub.IsNil(it) {
 			return
 		}
-		*stores = append(*stores, Store{root: it, s: db, env: e})
+		guard := newWriteGuard(it, conf.Env, readOnly.For(conf.Env), window)
+		*stores = append(*stores, Store{root: it, s: db, env: conf.Env, conf: conf, guard: guard, audit: audit})
 	}
 	errs := make([]error, 0)
 	for _, s := range st {
@@ -94,12 +122,12 @@ func fedBOX(rootIRIs []string, st []config.Storage, l lw.Logger) (*fedbox, error
 			if it.IsCollection() {
 				_ = pub.OnCollectionIntf(it, func(col pub.CollectionInterface) error {
 					for _, it := range col.Collection() {
-						appendStore(&stores, db, s.Env, it)
+						appendStore(&stores, db, s, it)
 					}
 					return nil
 				})
 			} else {
-				appendStore(&stores, db, s.Env, it)
+				appendStore(&stores, db, s, it)
 			}
 			found = true
 		}
@@ -110,7 +138,7 @@ func fedBOX(rootIRIs []string, st []config.Storage, l lw.Logger) (*fedbox, error
 	if len(errs) > 0 {
 		return nil, errors.Join(errs...)
 	}
-	return &fedbox{tree: make(map[pub.IRI]pub.Item), stores: stores, logFn: l.Debugf}, nil
+	return &fedbox{tree: make(map[pub.IRI]pub.Item), stores: stores, logFn: l.Debugf, journal: openJournal(l)}, nil
 }
 
 func (f *fedbox) Load(iri pub.IRI, ff ...filters.Check) (pub.Item, error) {
@@ -128,6 +156,147 @@ func (f *fedbox) Load(iri pub.IRI, ff ...filters.Check) (pub.Item, error) {
 	return nil, errors.NotFoundf("unable to load %s in any storage", iri)
 }
 
+func (f *fedbox) storeFor(iri pub.IRI) (Store, error) {
+	for _, st := range f.stores {
+		if pub.IsNil(st.root) || !iri.Contains(st.root.GetLink(), true) {
+			continue
+		}
+		return st, nil
+	}
+	return Store{}, errors.NotFoundf("unable to find a storage for %s", iri)
+}
+
+// hosts returns the hosts of the roots of the open stores.
+func (f *fedbox) hosts() []string {
+	if f == nil {
+		return nil
+	}
+	hosts := make([]string, 0, len(f.stores))
+	for _, st := range f.stores {
+		if pub.IsNil(st.root) {
+			continue
+		}
+		if u, err := st.root.GetLink().URL(); err == nil && !slices.Contains(hosts, u.Host) {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+// storesFor returns the stores holding the items at iris, each of them once.
+func (f *fedbox) storesFor(iris ...pub.IRI) []Store {
+	stores := make([]Store, 0)
+	for _, iri := range iris {
+		st, err := f.storeFor(iri)
+		if err != nil {
+			continue
+		}
+		if !slices.ContainsFunc(stores, func(s Store) bool { return s.root.GetLink().Equals(st.root.GetLink(), false) }) {
+			stores = append(stores, st)
+		}
+	}
+	return stores
+}
+
+func (f *fedbox) Save(it pub.Item) (pub.Item, error) {
+	st, err := f.storeFor(it.GetLink())
+	if err != nil {
+		return nil, err
+	}
+	var before, saved pub.Item
+	err = st.write(string(journal.OpSave), it.GetLink(), func() error {
+		before, _ = st.s.Load(it.GetLink())
+		saved, err = st.s.Save(it)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.recordItem(journal.OpSave, it.GetLink(), before, saved)
+	return saved, nil
+}
+
+// Delete removes the item from the collections in from, and then from storage.
+// The collections are recorded in the journal, so undoing the delete adds the item back to them.
+func (f *fedbox) Delete(it pub.Item, from ...pub.IRI) error {
+	st, err := f.storeFor(it.GetLink())
+	if err != nil {
+		return err
+	}
+	var before pub.Item
+	err = st.write(string(journal.OpDelete), it.GetLink(), func() error {
+		before, _ = st.s.Load(it.GetLink())
+		for _, col := range from {
+			if err := st.s.RemoveFrom(col, it.GetLink()); err != nil {
+				return errors.Annotatef(err, "unable to remove %s from %s", it.GetLink(), col)
+			}
+		}
+		return st.s.Delete(it)
+	})
+	if err != nil {
+		return err
+	}
+	f.recordDelete(it.GetLink(), before, from...)
+	return nil
+}
+
+func (f *fedbox) AddTo(col pub.IRI, items ...pub.Item) error {
+	st, err := f.storeFor(col)
+	if err != nil {
+		return err
+	}
+	if err := st.write(string(journal.OpAdd), col, func() error { return st.s.AddTo(col, items...) }); err != nil {
+		return err
+	}
+	f.recordCollection(journal.OpAdd, col, items...)
+	return nil
+}
+
+func (f *fedbox) RemoveFrom(col pub.IRI, items ...pub.Item) error {
+	st, err := f.storeFor(col)
+	if err != nil {
+		return err
+	}
+	if err := st.write(string(journal.OpRemove), col, func() error { return st.s.RemoveFrom(col, items...) }); err != nil {
+		return err
+	}
+	f.recordCollection(journal.OpRemove, col, items...)
+	return nil
+}
+
+// emit stores the activity on behalf of its actor, and appends it to the actor's outbox.
+// If the activity has no actor, the root actor of the storage is used.
+func (f *fedbox) emit(st Store, act *pub.Activity) (*pub.Activity, error) {
+	if pub.IsNil(act.Actor) {
+		act.Actor = st.root.GetLink()
+	}
+	if act.ID == "" {
+		act.ID = filters.ActivitiesType.IRI(st.root).AddPath(uuid.NewString())
+	}
+	if act.Published.IsZero() {
+		act.Published = time.Now().UTC()
+	}
+	if _, err := f.Save(act); err != nil {
+		return nil, errors.Annotatef(err, "unable to save %s activity", act.Type)
+	}
+	if err := f.AddTo(pub.Outbox.IRI(act.Actor), act.GetLink()); err != nil {
+		return act, errors.Annotatef(err, "unable to add %s activity to outbox", act.Type)
+	}
+	return act, nil
+}
+
+// tombstone replaces the item in storage with a Tombstone, similarly to how FedBOX handles Delete activities.
+func (f *fedbox) tombstone(it pub.Item) error {
+	t := pub.Tombstone{
+		ID:         it.GetLink(),
+		Type:       pub.TombstoneType,
+		FormerType: it.GetType(),
+		Deleted:    time.Now().UTC(),
+	}
+	_, err := f.Save(&t)
+	return err
+}
+
 func (f *fedbox) getRootNodes() pub.ItemCollection {
 	rootNodes := make(pub.ItemCollection, len(f.stores))
 	for i, st := range f.stores {
@@ -166,7 +335,7 @@ func (n *n) startedSyncing() {
 }
 
 func (n *n) stoppedSyncing() {
-	n.s ^= NodeSyncing
+	n.s &^= NodeSyncing
 }
 
 func (n *n) Parent() tree.Node {
@@ -220,6 +389,9 @@ func (n *n) View() tea.View {
 		st = faintRedFg
 		annotation = Attention
 	}
+	if n.s.Is(NodeChanged) {
+		st = st.Foreground(Green)
+	}
 
 	if n.Item != nil && nodeIsCollapsible(n) {
 		annotation = Expanded
@@ -235,7 +407,15 @@ func (n *n) View() tea.View {
 		}
 	}
 
-	return tea.NewView(fmt.Sprintf("%-1s %s", annotation, st.Render(n.n)))
+	name := n.n
+	if n.s.Is(NodeMarked) {
+		name = Marked + " " + name
+		st = st.Bold(true)
+	}
+	if n.s.Is(NodeLocked) {
+		name += " " + lockIcon
+	}
+	return tea.NewView(fmt.Sprintf("%-1s %s", annotation, st.Render(name)))
 }
 
 func (n *n) Children() tree.Nodes {
@@ -443,35 +623,8 @@ func getItemElements(parent *n) []*n {
 	return result
 }
 
-func (m *model) loadDepsForNode(ctx context.Context, node *n) tea.Cmd {
-	if nodeIsSynced(node) {
-		m.logFn("Node already loaded: %s", node.n)
-		return nil
-	}
-	node.startedSyncing()
-	defer func() {
-		node.s |= NodeSynced
-		node.stoppedSyncing()
-		m.logFn("Node loaded: %s", node.n)
-	}()
-
-	if err := dereferenceItemProperties(ctx, m.f, &node.Item); err != nil {
-		m.logFn("error while loading attributes %s", err)
-		node.s |= NodeError
-	}
-
-	if node.s.Is(tree.NodeCollapsible) && len(node.c) == 0 {
-		count := filters.WithMaxCount(m.height)
-		if err := m.loadNode(ctx, node, count); err != nil {
-			m.logFn("error while loading children %s", err)
-			node.s |= NodeError
-		}
-	}
-
-	return m.tree.stoppedLoading
-}
-
-func (m *model) loadNode(ctx context.Context, nn *n, ff ...filters.Check) error {
+// loadChildren returns the nodes for the items of the collection it, loading them from storage if they are missing.
+func loadChildren(ctx context.Context, f *fedbox, it pub.Item, ff ...filters.Check) ([]*n, error) {
 	accum := func(children *[]*n) func(ctx context.Context, col pub.CollectionInterface) error {
 		return func(ctx context.Context, col pub.CollectionInterface) error {
 			for _, it := range col.Collection() {
@@ -481,16 +634,24 @@ func (m *model) loadNode(ctx context.Context, nn *n, ff ...filters.Check) error
 		}
 	}
 
+	children := make([]*n, 0)
+	_ = pub.OnCollectionIntf(it, func(col pub.CollectionInterface) error {
+		return accum(&children)(ctx, col)
+	})
+	if len(children) == 0 {
+		iri := it.GetLink()
+		if err := accumFn(accum(&children)).LoadFromSearch(ctx, f, iri, ff...); err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
+}
+
+func loadNode(ctx context.Context, f *fedbox, nn *n, ff ...filters.Check) error {
 	if len(nn.c) == 0 {
-		children := make([]*n, 0)
-		_ = pub.OnCollectionIntf(nn.Item, func(col pub.CollectionInterface) error {
-			return accum(&children)(ctx, col)
-		})
-		if len(children) == 0 {
-			iri := nn.Item.GetLink()
-			if err := accumFn(accum(&children)).LoadFromSearch(ctx, m.f, iri, ff...); err != nil {
-				return err
-			}
+		children, err := loadChildren(ctx, f, nn.Item, ff...)
+		if err != nil {
+			return err
 		}
 		nn.setChildren(children...)
 	}
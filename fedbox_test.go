@@ -0,0 +1,41 @@
+package motley
+
+import (
+	"testing"
+
+	"git.sr.ht/~mariusor/lw"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	pub "github.com/go-ap/activitypub"
+)
+
+const testRoot = pub.IRI("https://example.com")
+
+// newTestFedbox returns a fedbox with a single fs storage in a temporary directory,
+// containing the root service actor with its inbox and outbox.
+func newTestFedbox(t *testing.T) (*fedbox, Store) {
+	t.Helper()
+	conf := config.Storage{Type: config.StorageFS, Path: t.TempDir()}
+	db, err := config.Open(conf, "", lw.Dev(lw.SetLevel(lw.ErrorLevel)))
+	if err != nil {
+		t.Fatalf("unable to open storage: %s", err)
+	}
+	if err := db.Open(); err != nil {
+		t.Fatalf("unable to open storage: %s", err)
+	}
+	t.Cleanup(func() {
+		if closer, ok := db.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	})
+	self := &pub.Actor{ID: testRoot, Type: pub.ServiceType, Inbox: pub.Inbox.IRI(testRoot), Outbox: pub.Outbox.IRI(testRoot)}
+	if _, err := db.Save(self); err != nil {
+		t.Fatalf("unable to save root actor: %s", err)
+	}
+	for _, col := range []pub.IRI{self.Inbox.GetLink(), self.Outbox.GetLink()} {
+		if _, err := db.Create(&pub.OrderedCollection{ID: col, Type: pub.OrderedCollectionType}); err != nil {
+			t.Fatalf("unable to create collection %s: %s", col, err)
+		}
+	}
+	st := Store{root: self, s: db, conf: conf}
+	return &fedbox{tree: make(map[pub.IRI]pub.Item), stores: []Store{st}, logFn: t.Logf}, st
+}
@@ -0,0 +1,242 @@
+package motley
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"git.sr.ht/~mariusor/motley/internal/env"
+	pub "github.com/go-ap/activitypub"
+)
+
+var writeToggleKey = key.NewBinding(
+	key.WithKeys("!"),
+	key.WithHelp("!", "toggle read-only mode for current store"),
+)
+
+// writeGuard decides if motley can make changes to a store.
+// It is shared between the copies of the Store, so toggling it applies everywhere.
+//
+// The changes are made by actions, which hold the permission to write from when they begin until they end,
+// so a bulk action doesn't lose it halfway through.
+// For production stores, every action needs the hostname typed as confirmation before it begins,
+// unless the user opted in to a window of time in which the confirmation is not asked again.
+type writeGuard struct {
+	mu sync.Mutex
+
+	host     string
+	prod     bool
+	readOnly bool
+	// window is how long actions on a production store don't need confirming after typing its hostname.
+	// It is zero by default, every action needs its own confirmation.
+	window time.Duration
+	// confirmedUntil is when the window opened by the last confirmation closes.
+	confirmedUntil time.Time
+	// confirmed is set when the hostname was typed, until the next action begins.
+	confirmed bool
+	// actions counts the actions in progress.
+	actions int
+}
+
+type errReadOnly struct {
+	host string
+	prod bool
+	// unconfirmed is set when a production store is writable, but the action wasn't confirmed.
+	unconfirmed bool
+}
+
+func (e errReadOnly) Error() string {
+	if e.unconfirmed {
+		return fmt.Sprintf("writes to the production instance %s need its hostname typed as confirmation", e.host)
+	}
+	if e.prod {
+		return fmt.Sprintf("read-only mode: press %s and type %s to allow writes to this production instance", writeToggleKey.Help().Key, e.host)
+	}
+	return fmt.Sprintf("read-only mode: writes to %s are disabled, press %s to enable them", e.host, writeToggleKey.Help().Key)
+}
+
+func newWriteGuard(root pub.Item, e env.Type, readOnly bool, window time.Duration) *writeGuard {
+	g := writeGuard{prod: e.IsProd(), readOnly: readOnly, window: window, host: root.GetLink().String()}
+	if u, err := url.Parse(g.host); err == nil && u.Host != "" {
+		g.host = u.Host
+	}
+	return &g
+}
+
+// locked returns whether the store is in read-only mode.
+func (g *writeGuard) locked() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.readOnly
+}
+
+// allow returns an error if writes are not allowed, which happens outside an action for production stores.
+// NOTE(marius): a nil guard, which only the stores built in tests have, allows everything.
+func (g *writeGuard) allow() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.readOnly {
+		return errReadOnly{host: g.host, prod: g.prod}
+	}
+	if g.prod && g.actions == 0 {
+		return errReadOnly{host: g.host, prod: true, unconfirmed: true}
+	}
+	return nil
+}
+
+// needsConfirmation returns whether the hostname has to be typed before the next action can begin.
+func (g *writeGuard) needsConfirmation() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.prod && !g.readOnly && !g.confirmed && !time.Now().Before(g.confirmedUntil)
+}
+
+// confirm records that the hostname was typed, which allows the next action, or all of them until the window closes.
+func (g *writeGuard) confirm() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.confirmed = true
+	if g.window > 0 {
+		g.confirmedUntil = time.Now().Add(g.window)
+	}
+}
+
+// begin starts an action, which can write until the returned function is called.
+func (g *writeGuard) begin() (func(), error) {
+	if g == nil {
+		return func() {}, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.readOnly {
+		return nil, errReadOnly{host: g.host, prod: g.prod}
+	}
+	if g.prod {
+		switch {
+		case g.confirmed:
+			g.confirmed = false
+		case time.Now().Before(g.confirmedUntil):
+		default:
+			return nil, errReadOnly{host: g.host, prod: true, unconfirmed: true}
+		}
+	}
+	g.actions++
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.actions--
+		})
+	}, nil
+}
+
+func (g *writeGuard) setReadOnly(readOnly bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.readOnly = readOnly
+	g.confirmed = false
+	g.confirmedUntil = time.Time{}
+}
+
+// write runs fn, which changes the storage at iri, only if writes are allowed, and records it in the audit log.
+func (s Store) write(op string, iri pub.IRI, fn func() error) error {
+	if err := s.guard.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	s.audit.record(s.env, op, iri, err)
+	return err
+}
+
+// action runs fn, which makes the changes of a single user action, holding the permission to write to the store until it ends.
+func (s Store) action(fn func() error) error {
+	release, err := s.guard.begin()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+// beginActions starts an action on each of the stores, and returns the function which ends all of them.
+func beginActions(stores ...Store) (func(), error) {
+	releases := make([]func(), 0, len(stores))
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	for _, st := range stores {
+		release, err := st.guard.begin()
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+	return releaseAll, nil
+}
+
+// confirmWrites returns the command built by fn, which starts an action writing to the stores,
+// after asking the user to type the hostname of the production stores which need it.
+func confirmWrites(fn func() tea.Cmd, stores ...Store) tea.Cmd {
+	for i, st := range stores {
+		g := st.guard
+		if !g.needsConfirmation() {
+			continue
+		}
+		rest := stores[i+1:]
+		label := fmt.Sprintf("Type %s to confirm writing to this production instance", g.host)
+		return promptCmd(label, "", func(typed string) tea.Cmd {
+			if !strings.EqualFold(strings.TrimSpace(typed), g.host) {
+				return errCmd(fmt.Errorf("hostname doesn't match, no changes were made to %s", g.host))
+			}
+			g.confirm()
+			return confirmWrites(fn, rest...)
+		})
+	}
+	return fn()
+}
+
+// toggleWrites switches the store between read-only and writable.
+// Making a production store writable needs its hostname typed as confirmation, which also confirms the next action.
+func toggleWrites(st Store) tea.Cmd {
+	g := st.guard
+	if g == nil {
+		return errCmd(fmt.Errorf("read-only mode is not available for this store"))
+	}
+	if !g.locked() {
+		g.setReadOnly(true)
+		return statusMessageCmd("read-only mode enabled for %s", g.host)
+	}
+	if !g.prod {
+		g.setReadOnly(false)
+		return statusMessageCmd("writes enabled for %s", g.host)
+	}
+	label := fmt.Sprintf("Type %s to allow writes to this production instance", g.host)
+	return promptCmd(label, "", func(typed string) tea.Cmd {
+		if !strings.EqualFold(strings.TrimSpace(typed), g.host) {
+			return errCmd(fmt.Errorf("hostname doesn't match, %s stays read-only", g.host))
+		}
+		g.setReadOnly(false)
+		g.confirm()
+		if g.window > 0 {
+			return statusMessageCmd("writes enabled for %s, confirmed for %s", g.host, g.window)
+		}
+		return statusMessageCmd("writes enabled for %s, each change needs the hostname confirmed", g.host)
+	})
+}
@@ -0,0 +1,178 @@
+package motley
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"git.sr.ht/~mariusor/motley/internal/env"
+	pub "github.com/go-ap/activitypub"
+)
+
+func newTestGuardedFedbox(t *testing.T, e env.Type, readOnly bool, window time.Duration) (*fedbox, Store) {
+	t.Helper()
+	f, st := newTestFedbox(t)
+	st.guard = newWriteGuard(st.root, e, readOnly, window)
+	f.stores[0] = st
+	return f, st
+}
+
+func testNote(iri pub.IRI) *pub.Object {
+	return &pub.Object{ID: iri, Type: pub.NoteType}
+}
+
+func isUnconfirmed(err error) bool {
+	ro := errReadOnly{}
+	return errors.As(err, &ro) && ro.unconfirmed
+}
+
+func TestWriteGuard_dev(t *testing.T) {
+	f, st := newTestGuardedFedbox(t, env.DEV, false, 0)
+	if st.guard.needsConfirmation() {
+		t.Errorf("writes to a dev store should not need confirmation")
+	}
+	if _, err := f.Save(testNote("https://example.com/objects/1")); err != nil {
+		t.Errorf("unable to save to a dev store: %s", err)
+	}
+}
+
+func TestWriteGuard_readOnly(t *testing.T) {
+	f, st := newTestGuardedFedbox(t, env.DEV, true, 0)
+	_, err := f.Save(testNote("https://example.com/objects/1"))
+	if !errors.As(err, &errReadOnly{}) {
+		t.Errorf("saving to a read-only store should fail with errReadOnly, got %v", err)
+	}
+	if err := st.action(func() error { return nil }); !errors.As(err, &errReadOnly{}) {
+		t.Errorf("an action on a read-only store should fail with errReadOnly, got %v", err)
+	}
+}
+
+func TestWriteGuard_prodConfirmsEachAction(t *testing.T) {
+	f, st := newTestGuardedFedbox(t, env.PROD, false, 0)
+	save := func() error {
+		_, err := f.Save(testNote("https://example.com/objects/1"))
+		return err
+	}
+
+	if err := save(); !isUnconfirmed(err) {
+		t.Errorf("saving outside an action should need confirmation, got %v", err)
+	}
+	if err := st.action(save); !isUnconfirmed(err) {
+		t.Errorf("an unconfirmed action should fail, got %v", err)
+	}
+	if !st.guard.needsConfirmation() {
+		t.Fatalf("a production store should need confirmation")
+	}
+
+	st.guard.confirm()
+	if st.guard.needsConfirmation() {
+		t.Errorf("a confirmed store should not need confirmation")
+	}
+	if err := st.action(save); err != nil {
+		t.Errorf("the confirmed action should be allowed, got %s", err)
+	}
+	if err := st.action(save); !isUnconfirmed(err) {
+		t.Errorf("the action after the confirmed one should need confirmation again, got %v", err)
+	}
+}
+
+func TestWriteGuard_actionKeepsPermission(t *testing.T) {
+	f, st := newTestGuardedFedbox(t, env.PROD, false, 0)
+	st.guard.confirm()
+
+	release, err := beginActions(st)
+	if err != nil {
+		t.Fatalf("unable to begin the confirmed action: %s", err)
+	}
+	for _, iri := range []pub.IRI{"https://example.com/objects/1", "https://example.com/objects/2", "https://example.com/objects/3"} {
+		if _, err := f.Save(testNote(iri)); err != nil {
+			t.Errorf("unable to save %s during the action: %s", iri, err)
+		}
+	}
+	release()
+	release()
+	if _, err := f.Save(testNote("https://example.com/objects/4")); !isUnconfirmed(err) {
+		t.Errorf("saving after the action ended should need confirmation, got %v", err)
+	}
+}
+
+func TestWriteGuard_window(t *testing.T) {
+	_, st := newTestGuardedFedbox(t, env.PROD, false, time.Hour)
+	st.guard.confirm()
+	for i := 0; i < 3; i++ {
+		if err := st.action(func() error { return nil }); err != nil {
+			t.Errorf("action %d should be allowed inside the window, got %s", i, err)
+		}
+	}
+	if st.guard.needsConfirmation() {
+		t.Errorf("the store should not need confirmation inside the window")
+	}
+	st.guard.setReadOnly(true)
+	st.guard.setReadOnly(false)
+	if !st.guard.needsConfirmation() {
+		t.Errorf("toggling read-only mode should close the window")
+	}
+}
+
+func TestConfirmWrites(t *testing.T) {
+	_, st := newTestGuardedFedbox(t, env.PROD, false, 0)
+	ran := false
+	cmd := confirmWrites(func() tea.Cmd {
+		ran = true
+		return noop
+	}, st)
+
+	prompt, ok := findMsg[promptMsg](cmd)
+	if !ok {
+		t.Fatalf("confirmWrites should prompt for the hostname of a production store")
+	}
+	if _, ok := findMsg[error](prompt.submitFn("example.org")); !ok {
+		t.Errorf("a wrong hostname should return an error")
+	}
+	if ran || !st.guard.needsConfirmation() {
+		t.Errorf("a wrong hostname should not confirm the writes")
+	}
+
+	prompt.submitFn("example.com")
+	if !ran {
+		t.Errorf("the command should be built after typing the hostname")
+	}
+	if err := st.action(func() error { return nil }); err != nil {
+		t.Errorf("the action should be allowed after typing the hostname, got %s", err)
+	}
+
+	_, dev := newTestGuardedFedbox(t, env.DEV, false, 0)
+	ran = false
+	confirmWrites(func() tea.Cmd {
+		ran = true
+		return noop
+	}, dev)
+	if !ran {
+		t.Errorf("writes to a dev store should not need confirmation")
+	}
+}
+
+func TestWithStore(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	_, test := newTestFedbox(t)
+
+	st := WithStore(test.s, test.root, string(env.PROD))
+	if !st.guard.locked() {
+		t.Errorf("a production store should be read-only by default")
+	}
+	if st.audit == nil {
+		t.Errorf("the writes to the store should be recorded in the audit log")
+	}
+	err := st.write("save", testRoot, func() error { return nil })
+	if !errors.As(err, &errReadOnly{}) {
+		t.Errorf("writing to a production store should fail with errReadOnly, got %v", err)
+	}
+
+	if st = WithStore(test.s, test.root, string(env.DEV)); st.guard.locked() {
+		t.Errorf("a dev store should be writable by default")
+	}
+	if err = st.write("save", testRoot, func() error { return nil }); err != nil {
+		t.Errorf("writing to a dev store should be allowed, got %s", err)
+	}
+}
@@ -0,0 +1,355 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	"github.com/charmbracelet/x/ansi"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+	tree "github.com/mariusor/bubbles-tree"
+)
+
+const historyStateName = "history"
+
+var (
+	forwardKey = key.NewBinding(
+		key.WithKeys(">", "alt+right"),
+		key.WithHelp(">", "move forward to the next element in history"),
+	)
+	crumbKey = key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "jump to breadcrumb"),
+	)
+)
+
+// history is the navigation history of a tab.
+type history struct {
+	// back holds the trees we advanced from, and forward the ones we went back from, most recent last.
+	back    []*tree.Model
+	forward []*tree.Model
+
+	// bar and spans are the rendered breadcrumbs bar and the position of each crumb in it, used for mouse clicks.
+	bar   string
+	spans []crumbSpan
+}
+
+// savedHistory holds the IRIs of the items which were advanced to in a tab, so they can be restored in the next session.
+type savedHistory struct {
+	Back    []vocab.IRI `json:"back"`
+	Forward []vocab.IRI `json:"forward"`
+}
+
+// savedTabs holds the histories of the open tabs.
+type savedTabs struct {
+	Tabs   []savedHistory `json:"tabs"`
+	Active int            `json:"active"`
+}
+
+// savedSessions holds the tabs saved for each set of open instances, keyed by the IRIs of their root actors.
+type savedSessions map[string]savedTabs
+
+// sessionKey returns the IRIs of the root actors of the open stores, sorted, which the saved tabs are keyed by.
+func (f *fedbox) sessionKey() string {
+	if f == nil {
+		return ""
+	}
+	roots := make([]string, 0, len(f.stores))
+	for _, st := range f.stores {
+		roots = append(roots, st.root.GetLink().String())
+	}
+	slices.Sort(roots)
+	return strings.Join(slices.Compact(roots), " ")
+}
+
+// crumbSpan is the position of a breadcrumb in the breadcrumb bar, used for mouse clicks.
+type crumbSpan struct {
+	start, end int
+	index      int
+}
+
+func selectedNode(nodes tree.Nodes) *n {
+	for _, nn := range nodes {
+		node, ok := nn.(*n)
+		if !ok {
+			continue
+		}
+		if node.s.Is(tree.NodeSelected) {
+			return node
+		}
+		if sel := selectedNode(node.Children()); sel != nil {
+			return sel
+		}
+	}
+	return nil
+}
+
+func treeRoot(t *tree.Model) *n {
+	if t == nil || len(t.Children()) == 0 {
+		return nil
+	}
+	root, _ := t.Children()[0].(*n)
+	return root
+}
+
+// nodePath returns the names of the node and its ancestors, starting with the root.
+func nodePath(node *n) []string {
+	path := make([]string, 0)
+	for ; node != nil; node = node.p {
+		path = append([]string{node.n}, path...)
+	}
+	return path
+}
+
+// trees returns all the trees we navigated through, with the current one last.
+func (h history) trees(current *tree.Model) []*tree.Model {
+	return append(append([]*tree.Model{}, h.back...), current)
+}
+
+func (m *model) trees() []*tree.Model {
+	return m.history.trees(m.tree.list)
+}
+
+// crumbs returns for every tree the path to the node that was selected in it.
+// As every advanced tree starts from the node selected in the previous one, we skip that node.
+func (m *model) crumbs() [][]string {
+	trees := m.trees()
+	crumbs := make([][]string, 0, len(trees))
+	for i, t := range trees {
+		sel := selectedNode(t.Children())
+		if i == len(trees)-1 {
+			sel = m.currentNode
+		}
+		path := nodePath(sel)
+		if i < len(trees)-1 && len(path) > 0 {
+			path = path[:len(path)-1]
+		}
+		crumbs = append(crumbs, path)
+	}
+	return crumbs
+}
+
+// updateCrumbs renders the trail of the navigation history, truncated at the beginning to fit in width.
+// It also records the position of each crumb, so they can be clicked.
+func (m *model) updateCrumbs(width int) {
+	numStyle := lipgloss.NewStyle().Faint(true)
+	sepStyle := lipgloss.NewStyle().Foreground(SubtleIndigo)
+
+	spans := make([]crumbSpan, 0)
+	pieces := make([]string, 0)
+	pos := 0
+	for i, path := range m.crumbs() {
+		if len(path) == 0 {
+			continue
+		}
+		if len(pieces) > 0 {
+			pieces = append(pieces, sepStyle.Render(" » "))
+			pos += 3
+		}
+		label := strings.Join(path, sepStyle.Render(" › "))
+		if i < 9 {
+			label = numStyle.Render(fmt.Sprintf("%d ", i+1)) + label
+		}
+		w := lipgloss.Width(label)
+		spans = append(spans, crumbSpan{start: pos, end: pos + w, index: i})
+		pieces = append(pieces, label)
+		pos += w
+	}
+	bar := strings.Join(pieces, "")
+	if over := lipgloss.Width(bar) - width; over > 0 && width > 1 {
+		bar = ansi.TruncateLeft(bar, over+lipgloss.Width(ellipsis), ellipsis)
+		for i := range spans {
+			spans[i].start -= over
+			spans[i].end -= over
+		}
+	}
+	m.history.bar = lipgloss.NewStyle().Width(width).MaxWidth(width).Render(bar)
+	m.history.spans = spans
+}
+
+// crumbAt returns the index of the tree corresponding to the breadcrumb at position x in the bar.
+func (m *model) crumbAt(x int) (int, bool) {
+	for _, s := range m.history.spans {
+		if x >= s.start && x < s.end {
+			return s.index, true
+		}
+	}
+	return -1, false
+}
+
+func (m *model) switchTree(t *tree.Model) {
+	t.SetWidth(m.tree.list.Width())
+	t.SetHeight(m.tree.list.Height())
+	m.tree.Back(t)
+}
+
+// JumpTo moves to the tree at position index in the history, keeping the trees after it for moving forward.
+func (m *model) JumpTo(index int) tea.Cmd {
+	if index < 0 || index >= len(m.history.back) {
+		return noop
+	}
+	trees := m.trees()
+	for i := len(trees) - 1; i > index; i-- {
+		m.history.forward = append(m.history.forward, trees[i])
+	}
+	m.switchTree(m.history.back[index])
+	m.history.back = m.history.back[:index]
+	m.saveHistory()
+	return m.focusTree()
+}
+
+func (m *model) Forward() tea.Cmd {
+	if len(m.history.forward) == 0 {
+		m.logFn("No next tree to move forward to.")
+		return noop
+	}
+	next := m.history.forward[len(m.history.forward)-1]
+	m.history.forward = m.history.forward[:len(m.history.forward)-1]
+	m.history.back = append(m.history.back, m.tree.list)
+	m.switchTree(next)
+	m.saveHistory()
+	if root := treeRoot(next); root != nil {
+		return tea.Batch(m.focusTree(), nodeCmd(root))
+	}
+	return m.focusTree()
+}
+
+func (h history) saved(current *tree.Model) savedHistory {
+	s := savedHistory{}
+	if len(h.back) > 0 {
+		for _, t := range h.trees(current)[1:] {
+			if root := treeRoot(t); root != nil {
+				s.Back = append(s.Back, root.GetLink())
+			}
+		}
+	}
+	for _, t := range h.forward {
+		if root := treeRoot(t); root != nil {
+			s.Forward = append(s.Forward, root.GetLink())
+		}
+	}
+	return s
+}
+
+// saveHistory saves the history of every tab, the active one from the model, the others from where they were stashed.
+func (m *model) saveHistory() {
+	s := savedTabs{Active: m.activeTab}
+	for i, t := range m.tabs {
+		if i == m.activeTab {
+			s.Tabs = append(s.Tabs, m.history.saved(m.tree.list))
+		} else {
+			s.Tabs = append(s.Tabs, t.history.saved(t.tree.list))
+		}
+	}
+	// NOTE(marius): the tabs of other instances are kept, and a state that can't be loaded is overwritten.
+	sessions := make(savedSessions)
+	_ = config.LoadState(historyStateName, &sessions)
+	sessions[m.f.sessionKey()] = s
+	if err := config.SaveState(historyStateName, sessions); err != nil {
+		m.logFn("unable to save history: %s", err)
+	}
+}
+
+func historyNode(ctx context.Context, f *fedbox, iri vocab.IRI, count int) (*n, error) {
+	it, err := f.Load(iri)
+	if err != nil {
+		return nil, err
+	}
+	nn := node(it)
+	if it.IsCollection() {
+		if err := loadNode(ctx, f, nn, filters.WithMaxCount(count)); err != nil {
+			return nil, err
+		}
+	}
+	return nn, nil
+}
+
+// restoredHistory holds the nodes loaded for the history of a tab saved in the previous session.
+type restoredHistory struct {
+	back    []*n
+	forward []*n
+}
+
+type historyLoadedMsg struct {
+	gen    uint64
+	tabs   []restoredHistory
+	active int
+}
+
+// restoreHistory loads in the background the tabs saved in the previous session,
+// which are opened when the results arrive, in historyLoaded.
+func (m *model) restoreHistory() tea.Cmd {
+	sessions := make(savedSessions)
+	if err := config.LoadState(historyStateName, &sessions); err != nil {
+		m.logFn("unable to load history: %s", err)
+		return noop
+	}
+	s := sessions[m.f.sessionKey()]
+	if len(s.Tabs) == 0 {
+		return noop
+	}
+	ctx, gen := m.startLoading(&m.loaders.advance)
+	f := m.f
+	l := m.logFn
+	count := max(m.height, 50)
+	return tea.Batch(loadingCmd(true), func() tea.Msg {
+		msg := historyLoadedMsg{gen: gen, active: s.Active}
+		for _, h := range s.Tabs {
+			msg.tabs = append(msg.tabs, restoredHistory{
+				back:    historyNodes(ctx, f, l, h.Back, count),
+				forward: historyNodes(ctx, f, l, h.Forward, count),
+			})
+		}
+		return msg
+	})
+}
+
+func historyNodes(ctx context.Context, f *fedbox, l loggerFn, iris []vocab.IRI, count int) []*n {
+	nodes := make([]*n, 0, len(iris))
+	for _, iri := range iris {
+		nn, err := historyNode(ctx, f, iri, count)
+		if err != nil {
+			l("unable to restore %s from history: %s", iri, err)
+			continue
+		}
+		nodes = append(nodes, nn)
+	}
+	return nodes
+}
+
+// historyLoaded opens a tab for each restored history, and advances their trees through the loaded nodes.
+func (m *model) historyLoaded(msg historyLoadedMsg) tea.Cmd {
+	if !m.finishLoading(&m.loaders.advance, msg.gen) {
+		m.logFn("Dropping stale history")
+		return noop
+	}
+	cmds := []tea.Cmd{loadingCmd(false)}
+	for i, h := range msg.tabs {
+		if i > 0 {
+			cmds = append(cmds, m.openTab())
+		}
+		m.restoreTab(h)
+	}
+	if msg.active != m.activeTab {
+		cmds = append(cmds, m.switchTab(msg.active))
+	}
+	return tea.Batch(append(cmds, nodeCmd(m.currentNode))...)
+}
+
+// restoreTab advances the tree of the active tab through the nodes of h.
+// NOTE(marius): the current node is set directly, as the tab might not be the active one by the time a command would run.
+func (m *model) restoreTab(h restoredHistory) {
+	for _, nn := range h.back {
+		m.history.back = append(m.history.back, m.tree.Advance(nn))
+		m.currentNode = nn
+	}
+	for _, nn := range h.forward {
+		m.history.forward = append(m.history.forward, m.tree.newTree(nn))
+	}
+}
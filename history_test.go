@@ -0,0 +1,112 @@
+package motley
+
+import (
+	"testing"
+
+	"git.sr.ht/~mariusor/lw"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	pub "github.com/go-ap/activitypub"
+)
+
+func newTestModel(t *testing.T) (*model, Store) {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	f, st := newTestFedbox(t)
+	m := Model(lw.Dev(lw.SetLevel(lw.ErrorLevel)), st)
+	m.f = f
+	m.setSize(120, 40)
+	return m, st
+}
+
+func TestHistory_perTab(t *testing.T) {
+	m, st := newTestModel(t)
+	inbox := pub.Inbox.IRI(st.root)
+
+	m.history.back = append(m.history.back, m.tree.Advance(node(inbox)))
+	m.openTab()
+	if len(m.history.back) != 0 {
+		t.Fatalf("the new tab should start without history, got %d trees", len(m.history.back))
+	}
+	if len(m.tabs[0].history.back) != 1 {
+		t.Fatalf("the first tab should keep its history, got %d trees", len(m.tabs[0].history.back))
+	}
+
+	m.saveHistory()
+	sessions := savedSessions{}
+	if err := config.LoadState(historyStateName, &sessions); err != nil {
+		t.Fatalf("unable to load history: %s", err)
+	}
+	saved := sessions[m.f.sessionKey()]
+	if len(saved.Tabs) != 2 || saved.Active != 1 {
+		t.Fatalf("expected 2 tabs with the second active, got %d, active %d", len(saved.Tabs), saved.Active)
+	}
+	if back := saved.Tabs[0].Back; len(back) != 1 || back[0] != inbox {
+		t.Errorf("the first tab should have %s in its history, got %v", inbox, back)
+	}
+	if back := saved.Tabs[1].Back; len(back) != 0 {
+		t.Errorf("the second tab should have no history, got %v", back)
+	}
+
+	restored := Model(lw.Dev(lw.SetLevel(lw.ErrorLevel)), st)
+	restored.f = m.f
+	restored.setSize(120, 40)
+	msg, ok := findMsg[historyLoadedMsg](restored.restoreHistory())
+	if !ok {
+		t.Fatalf("the history should be loaded by a command")
+	}
+	if len(restored.tabs) != 1 {
+		t.Fatalf("the tabs should be opened only when the history is loaded, got %d", len(restored.tabs))
+	}
+	restored.update(msg)
+	if len(restored.tabs) != 2 || restored.activeTab != 1 {
+		t.Fatalf("expected 2 restored tabs with the second active, got %d, active %d", len(restored.tabs), restored.activeTab)
+	}
+	if len(restored.history.back) != 0 {
+		t.Errorf("the active tab should have no history, got %d trees", len(restored.history.back))
+	}
+	if root := treeRoot(restored.tabs[0].tree.list); root == nil || root.GetLink() != inbox {
+		t.Errorf("the first tab should be advanced to %s", inbox)
+	}
+}
+
+func TestHistory_crumbs(t *testing.T) {
+	m, st := newTestModel(t)
+	inbox := node(pub.Inbox.IRI(st.root))
+	m.history.back = append(m.history.back, m.tree.Advance(inbox))
+	m.currentNode = inbox
+
+	m.updateCrumbs(m.tree.width())
+	if len(m.history.spans) == 0 || m.history.bar == "" {
+		t.Fatalf("the breadcrumbs should be computed on update")
+	}
+	last := m.history.spans[len(m.history.spans)-1]
+	if idx, ok := m.crumbAt(last.start); !ok || idx != last.index {
+		t.Errorf("crumbAt(%d) = %d, %t, expected %d", last.start, idx, ok, last.index)
+	}
+	if _, ok := m.crumbAt(-1); ok {
+		t.Errorf("crumbAt(-1) should not match any crumb")
+	}
+}
+
+func TestHistory_perInstance(t *testing.T) {
+	m, st := newTestModel(t)
+	inbox := pub.Inbox.IRI(st.root)
+	m.history.back = append(m.history.back, m.tree.Advance(node(inbox)))
+	m.saveHistory()
+
+	other := &fedbox{tree: make(map[pub.IRI]pub.Item), stores: []Store{{root: pub.IRI("https://example.org"), s: st.s}}, logFn: t.Logf}
+	restored := Model(lw.Dev(lw.SetLevel(lw.ErrorLevel)), st)
+	restored.f = other
+	if cmd := restored.restoreHistory(); cmd != nil {
+		t.Errorf("the history of another instance should not be restored")
+	}
+	restored.saveHistory()
+
+	sessions := savedSessions{}
+	if err := config.LoadState(historyStateName, &sessions); err != nil {
+		t.Fatalf("unable to load history: %s", err)
+	}
+	if back := sessions[m.f.sessionKey()].Tabs[0].Back; len(back) != 1 || back[0] != inbox {
+		t.Errorf("saving the history of another instance should keep %s, got %v", inbox, back)
+	}
+}
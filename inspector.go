@@ -0,0 +1,387 @@
+package motley
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	vocab "github.com/go-ap/activitypub"
+)
+
+var inspectorFollowKey = key.NewBinding(
+	key.WithKeys("enter"),
+	key.WithHelp("enter", "follow property to its node"),
+)
+
+// property is a single value of an ActivityPub property, which can be followed to its node when iri is set.
+type property struct {
+	name  string
+	value string
+	iri   vocab.IRI
+	depth int
+}
+
+func (p property) followable() bool {
+	return p.iri != "" && !vocab.PublicNS.Equals(p.iri, false)
+}
+
+type followMsg vocab.IRI
+
+func followCmd(iri vocab.IRI) tea.Cmd {
+	return func() tea.Msg {
+		return followMsg(iri)
+	}
+}
+
+// InspectorModel shows all the properties of an item below the summary rendered by the content model,
+// allowing to follow the ones that reference other items.
+type InspectorModel struct {
+	content tea.Model
+
+	props  []property
+	cursor int
+	depth  int
+}
+
+func newInspectorModel(content tea.Model) *InspectorModel {
+	return &InspectorModel{content: content, cursor: -1}
+}
+
+func (i *InspectorModel) Init() tea.Cmd {
+	return i.content.Init()
+}
+
+func (i *InspectorModel) place(x, y, w, h int) {
+	if pl, ok := i.content.(placer); ok {
+		pl.place(x, y, w, h)
+	}
+}
+
+func (i *InspectorModel) add(name, value string, iri vocab.IRI) {
+	i.props = append(i.props, property{name: name, value: value, iri: iri, depth: i.depth})
+}
+
+func (i *InspectorModel) addText(name, value string) {
+	if value != "" {
+		i.add(name, value, "")
+	}
+}
+
+func (i *InspectorModel) addTime(name string, t time.Time) {
+	if !t.IsZero() {
+		i.add(name, t.Local().Format(time.RFC1123), "")
+	}
+}
+
+func (i *InspectorModel) addItem(name string, it vocab.Item) {
+	if vocab.IsNil(it) {
+		return
+	}
+	if vocab.IsItemCollection(it) {
+		_ = vocab.OnItemCollection(it, func(col *vocab.ItemCollection) error {
+			for idx, ob := range col.Collection() {
+				if idx > 0 {
+					name = ""
+				}
+				i.addItem(name, ob)
+			}
+			return nil
+		})
+		return
+	}
+	iri := it.GetLink()
+	if vocab.IsIRI(it) {
+		i.add(name, iri.String(), iri)
+		return
+	}
+	if vocab.LinkTypes.Match(it.GetType()) {
+		_ = vocab.OnLink(it, func(l *vocab.Link) error {
+			i.add(name, fmt.Sprintf("%s %s", ItemType(l), l.Href), l.Href)
+			return nil
+		})
+		return
+	}
+	value := fmt.Sprintf("%s %s", ItemType(it), iri)
+	if label := getNameFromItem(it); label != "" && label != iri.String() {
+		value = fmt.Sprintf("%s %q %s", ItemType(it), label, iri)
+	}
+	i.add(name, value, iri)
+}
+
+func (i *InspectorModel) nested(name string, fn func()) {
+	i.add(name, "", "")
+	i.depth++
+	fn()
+	i.depth--
+}
+
+func (i *InspectorModel) updateObject(ob *vocab.Object) error {
+	i.addText("id", ob.ID.String())
+	i.addText("type", ItemType(ob))
+	i.addText("mediaType", string(ob.MediaType))
+	i.addTime("published", ob.Published)
+	i.addTime("updated", ob.Updated)
+	i.addTime("startTime", ob.StartTime)
+	i.addTime("endTime", ob.EndTime)
+	if ob.Duration > 0 {
+		i.addText("duration", ob.Duration.String())
+	}
+	i.addItem("attributedTo", ob.AttributedTo)
+	i.addItem("inReplyTo", ob.InReplyTo)
+	i.addItem("context", ob.Context)
+	i.addItem("generator", ob.Generator)
+	i.addItem("icon", ob.Icon)
+	i.addItem("image", ob.Image)
+	i.addItem("location", ob.Location)
+	i.addItem("preview", ob.Preview)
+	i.addItem("tag", ob.Tag)
+	i.addItem("attachment", ob.Attachment)
+	i.addItem("url", ob.URL)
+	i.addItem("to", ob.To)
+	i.addItem("cc", ob.CC)
+	i.addItem("bto", ob.Bto)
+	i.addItem("bcc", ob.BCC)
+	i.addItem("audience", ob.Audience)
+	i.addItem("replies", ob.Replies)
+	i.addItem("likes", ob.Likes)
+	i.addItem("shares", ob.Shares)
+	if ob.Source.MediaType != "" {
+		i.addText("source", string(ob.Source.MediaType))
+	}
+	i.addContentLinks(ob)
+	return nil
+}
+
+// addContentLinks adds the links found in the HTML content of the object, like mentions and hashtags.
+func (i *InspectorModel) addContentLinks(ob *vocab.Object) {
+	if mimeIsBinary(ob.MediaType) {
+		return
+	}
+	if mt := baseMimeType(ob.MediaType); mt != "" && mt != mimeTypeHTML {
+		return
+	}
+	name := "links"
+	for _, l := range htmlLinks(ob.Content.First().String()) {
+		value := l.href.String()
+		if l.text != "" && l.text != value {
+			value = fmt.Sprintf("%s %s", l.text, l.href)
+		}
+		i.add(name, value, l.href)
+		name = ""
+	}
+}
+
+func (i *InspectorModel) updateIntransitiveActivity(a *vocab.IntransitiveActivity) error {
+	if err := vocab.OnObject(a, i.updateObject); err != nil {
+		return err
+	}
+	i.addItem("actor", a.Actor)
+	i.addItem("target", a.Target)
+	i.addItem("result", a.Result)
+	i.addItem("instrument", a.Instrument)
+	i.addItem("origin", a.Origin)
+	return nil
+}
+
+func (i *InspectorModel) updateActivity(a *vocab.Activity) error {
+	if err := vocab.OnIntransitiveActivity(a, i.updateIntransitiveActivity); err != nil {
+		return err
+	}
+	i.addItem("object", a.Object)
+	return nil
+}
+
+func (i *InspectorModel) updateActor(a *vocab.Actor) error {
+	if err := vocab.OnObject(a, i.updateObject); err != nil {
+		return err
+	}
+	i.addText("preferredUsername", a.PreferredUsername.First().String())
+	i.addItem("inbox", a.Inbox)
+	i.addItem("outbox", a.Outbox)
+	i.addItem("following", a.Following)
+	i.addItem("followers", a.Followers)
+	i.addItem("liked", a.Liked)
+	i.addItem("streams", a.Streams)
+	if e := a.Endpoints; e != nil {
+		i.nested("endpoints", func() {
+			i.addItem("sharedInbox", e.SharedInbox)
+			i.addItem("uploadMedia", e.UploadMedia)
+			i.addItem("oauthAuthorizationEndpoint", e.OauthAuthorizationEndpoint)
+			i.addItem("oauthTokenEndpoint", e.OauthTokenEndpoint)
+			i.addItem("provideClientKey", e.ProvideClientKey)
+			i.addItem("signClientKey", e.SignClientKey)
+			if e.ProxyURL != "" {
+				i.addItem("proxyUrl", e.ProxyURL)
+			}
+		})
+	}
+	if k := a.PublicKey; k.ID != "" || k.PublicKeyPem != "" {
+		i.nested("publicKey", func() {
+			i.addText("id", k.ID.String())
+			i.addItem("owner", k.Owner)
+			if pem := strings.TrimSpace(k.PublicKeyPem); pem != "" {
+				i.addText("publicKeyPem", strings.SplitN(pem, "\n", 2)[0]+ellipsis)
+			}
+		})
+	}
+	return nil
+}
+
+func (i *InspectorModel) updateTombstone(t *vocab.Tombstone) error {
+	if err := vocab.OnObject(t, i.updateObject); err != nil {
+		return err
+	}
+	if t.FormerType != nil {
+		i.addText("formerType", t.FormerType.AsTypes().String())
+	}
+	i.addTime("deleted", t.Deleted)
+	return nil
+}
+
+func (i *InspectorModel) updateCollection(col vocab.CollectionInterface) error {
+	if err := vocab.OnObject(col, i.updateObject); err != nil {
+		return err
+	}
+	i.addText("totalItems", fmt.Sprintf("%d", col.Count()))
+	switch c := col.(type) {
+	case *vocab.OrderedCollection:
+		i.addItem("first", c.First)
+		i.addItem("last", c.Last)
+		i.addItem("current", c.Current)
+	case *vocab.Collection:
+		i.addItem("first", c.First)
+		i.addItem("last", c.Last)
+		i.addItem("current", c.Current)
+	case *vocab.OrderedCollectionPage:
+		i.addItem("partOf", c.PartOf)
+		i.addItem("prev", c.Prev)
+		i.addItem("next", c.Next)
+	case *vocab.CollectionPage:
+		i.addItem("partOf", c.PartOf)
+		i.addItem("prev", c.Prev)
+		i.addItem("next", c.Next)
+	}
+	return nil
+}
+
+func (i *InspectorModel) updateItems(items *vocab.ItemCollection) error {
+	i.addText("totalItems", fmt.Sprintf("%d", items.Count()))
+	i.addItem("items", *items)
+	return nil
+}
+
+func (i *InspectorModel) updateLink(l *vocab.Link) error {
+	i.addText("id", l.ID.String())
+	i.addText("type", ItemType(l))
+	i.addItem("href", l.Href)
+	i.addText("mediaType", string(l.MediaType))
+	i.addText("hrefLang", l.HrefLang.String())
+	if l.Width > 0 || l.Height > 0 {
+		i.addText("size", fmt.Sprintf("%dx%d", l.Width, l.Height))
+	}
+	i.addItem("preview", l.Preview)
+	return nil
+}
+
+func (i *InspectorModel) updateModel(it vocab.Item) error {
+	if vocab.IsNil(it) {
+		return nil
+	}
+	if vocab.IsIRI(it) {
+		i.addItem("id", it)
+		return nil
+	}
+	if vocab.IsItemCollection(it) {
+		return vocab.OnItemCollection(it, i.updateItems)
+	}
+	typ := it.GetType()
+	switch {
+	case vocab.CollectionTypes.Match(typ):
+		return vocab.OnCollectionIntf(it, i.updateCollection)
+	case vocab.IntransitiveActivityTypes.Match(typ):
+		return vocab.OnIntransitiveActivity(it, i.updateIntransitiveActivity)
+	case vocab.ActivityTypes.Match(typ):
+		return vocab.OnActivity(it, i.updateActivity)
+	case vocab.ActorTypes.Match(typ):
+		return vocab.OnActor(it, i.updateActor)
+	case typ == vocab.TombstoneType:
+		return vocab.OnTombstone(it, i.updateTombstone)
+	case vocab.LinkTypes.Match(typ):
+		return vocab.OnLink(it, i.updateLink)
+	case vocab.ObjectTypes.Match(typ) || vocab.NilType.Match(typ):
+		return vocab.OnObject(it, i.updateObject)
+	}
+	return fmt.Errorf("unknown activitypub object of type %T", it)
+}
+
+// moveCursor moves the cursor to the next followable property in the dir direction.
+func (i *InspectorModel) moveCursor(dir int) {
+	for idx := i.cursor + dir; idx >= 0 && idx < len(i.props); idx += dir {
+		if i.props[idx].followable() {
+			i.cursor = idx
+			return
+		}
+	}
+}
+
+func (i *InspectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if mm, ok := msg.(tea.KeyPressMsg); ok {
+		switch {
+		case key.Matches(mm, listUpKey):
+			i.moveCursor(-1)
+			return i, noop
+		case key.Matches(mm, listDownKey):
+			i.moveCursor(1)
+			return i, noop
+		case key.Matches(mm, inspectorFollowKey):
+			if i.cursor >= 0 && i.cursor < len(i.props) {
+				return i, followCmd(i.props[i.cursor].iri)
+			}
+			return i, noop
+		}
+	}
+	var cmd tea.Cmd
+	i.content, cmd = i.content.Update(msg)
+	return i, cmd
+}
+
+func (i *InspectorModel) header() []string {
+	return []string{i.content.View().Content, viewTitleStyle.Render("Properties")}
+}
+
+// cursorLine returns the line of the selected property, which the pager keeps in view.
+func (i *InspectorModel) cursorLine() int {
+	if i.cursor < 0 {
+		return -1
+	}
+	return lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, i.header()...)) + i.cursor
+}
+
+func (i *InspectorModel) View() tea.View {
+	nameStyle := lipgloss.NewStyle().Bold(true)
+	linkStyle := lipgloss.NewStyle().Foreground(Indigo)
+
+	width := 0
+	for _, p := range i.props {
+		width = max(width, lipgloss.Width(p.name)+2*p.depth)
+	}
+
+	lines := i.header()
+	for idx, p := range i.props {
+		label := strings.Repeat("  ", p.depth) + p.name
+		value := p.value
+		if p.followable() {
+			value = linkStyle.Render(value)
+		}
+		line := nameStyle.Render(fmt.Sprintf("%-*s", width, label)) + "  " + value
+		if idx == i.cursor {
+			line = hintFg.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", helpLine(listUpKey, listDownKey, inspectorFollowKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
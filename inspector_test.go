@@ -0,0 +1,51 @@
+package motley
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestInspector_follow(t *testing.T) {
+	author := pub.IRI("https://example.com/actors/jdoe")
+	note := &pub.Object{ID: "https://example.com/objects/1", Type: pub.NoteType, AttributedTo: author, To: pub.ItemCollection{pub.PublicNS}}
+	i := newInspectorModel(newObjectModel(nil))
+	if err := i.updateModel(note); err != nil {
+		t.Fatalf("unable to inspect the note: %s", err)
+	}
+
+	i.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	_, cmd := i.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if msg, ok := findMsg[followMsg](cmd); !ok || pub.IRI(msg) != author {
+		t.Errorf("following the first property should advance to %s, got %v", author, msg)
+	}
+	i.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if p := i.props[i.cursor]; p.iri != author {
+		t.Errorf("the public namespace should not be followable, the cursor is on %s", p.iri)
+	}
+}
+
+func TestModel_follow(t *testing.T) {
+	m, st := newTestModel(t)
+	author := &pub.Actor{ID: "https://example.com/actors/jdoe", Type: pub.PersonType}
+	if _, err := st.s.Save(author); err != nil {
+		t.Fatalf("unable to save actor: %s", err)
+	}
+
+	adv, ok := findMsg[advanceMsg](m.update(followMsg(author.ID)))
+	if !ok {
+		t.Fatalf("following a property should load its item")
+	}
+	loaded, ok := findMsg[advanceLoadedMsg](m.update(adv))
+	if !ok {
+		t.Fatalf("advancing should load the children of the followed item")
+	}
+	m.update(loaded)
+	if len(m.history.back) != 1 {
+		t.Errorf("following should advance the tree, got %d trees in history", len(m.history.back))
+	}
+	if root := treeRoot(m.tree.list); root == nil || root.GetLink() != author.ID {
+		t.Errorf("the tree should be advanced to %s", author.ID)
+	}
+}
@@ -38,6 +38,38 @@ type Options struct {
 	LogLevel lw.Level
 	URLs     []string
 	Storage  []Storage
+	// Languages holds the preferred languages for showing natural language values, in order of preference.
+	Languages []string
+	// ReadOnly controls if the storage can be modified.
+	ReadOnly ReadOnly
+	// ProdWriteWindow is how long changes to production storage don't need confirming again after typing its hostname.
+	// When it's zero, every change needs its own confirmation.
+	ProdWriteWindow time.Duration
+	// Layout is the arrangement of the panes, as it was last saved.
+	Layout Layout
+}
+
+// ReadOnly is the mode for preventing changes to storage.
+type ReadOnly string
+
+const (
+	// ReadOnlyAuto disables changes only for production environments.
+	ReadOnlyAuto = ReadOnly("auto")
+	// ReadOnlyOn disables changes for all environments.
+	ReadOnlyOn = ReadOnly("on")
+	// ReadOnlyOff allows changes for all environments.
+	ReadOnlyOff = ReadOnly("off")
+)
+
+// For returns if storage for environment e starts as read-only.
+func (r ReadOnly) For(e env.Type) bool {
+	switch ReadOnly(strings.ToLower(string(r))) {
+	case ReadOnlyOn:
+		return true
+	case ReadOnlyOff:
+		return false
+	}
+	return e.IsProd()
 }
 
 type StorageType string
@@ -53,6 +85,8 @@ const (
 	KeyDBPw        = "DB_PASSWORD"
 	KeyStorage     = "STORAGE"
 	KeyStoragePath = "STORAGE_PATH"
+	KeyLanguages   = "LANGUAGES"
+	KeyReadOnly    = "READ_ONLY"
 	StorageFS      = StorageType("fs")
 	StorageSqlite  = StorageType("sqlite")
 	StorageBoltDB  = StorageType("boltdb")
@@ -228,10 +262,26 @@ func LoadFromEnv(base string, e env.Type, timeOut time.Duration) (Options, error
 	}
 	st.Path = filepath.Clean(st.Path)
 	conf.Storage = append(conf.Storage, st)
+	conf.Languages = ParseLanguages(loadKeyFromEnv(KeyLanguages, ""))
+	conf.ReadOnly = ReadOnly(loadKeyFromEnv(KeyReadOnly, string(ReadOnlyAuto)))
+	if l, err := LoadLayout(); err == nil {
+		conf.Layout = l
+	}
 
 	return conf, nil
 }
 
+// ParseLanguages splits a comma or space separated list of language codes.
+func ParseLanguages(s string) []string {
+	langs := make([]string, 0)
+	for _, l := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if l = strings.TrimSpace(l); l != "" {
+			langs = append(langs, l)
+		}
+	}
+	return langs
+}
+
 func ParseStorageDSN(s string) (StorageType, string) {
 	r := regexp.MustCompile(fmt.Sprintf(`(%s):\/(.+)`, strings.Join(allStorageTypes, "|")))
 	found := r.FindAllSubmatch([]byte(s), -1)
@@ -90,3 +90,47 @@ func TestLoadFromEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestParseLanguages(t *testing.T) {
+	tests := map[string][]string{
+		"":             {},
+		"en":           {"en"},
+		"en,fr":        {"en", "fr"},
+		" ro, en  fr ": {"ro", "en", "fr"},
+		",,de,":        {"de"},
+	}
+	for in, want := range tests {
+		got := ParseLanguages(in)
+		if len(got) != len(want) {
+			t.Errorf("ParseLanguages(%q) = %v, expected %v", in, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("ParseLanguages(%q) = %v, expected %v", in, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestReadOnly_For(t *testing.T) {
+	tests := []struct {
+		mode ReadOnly
+		env  env.Type
+		want bool
+	}{
+		{ReadOnlyAuto, env.PROD, true},
+		{ReadOnlyAuto, env.DEV, false},
+		{ReadOnlyAuto, "", false},
+		{"", env.PROD, true},
+		{ReadOnlyOn, env.DEV, true},
+		{"ON", env.TEST, true},
+		{ReadOnlyOff, env.PROD, false},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.For(tt.env); got != tt.want {
+			t.Errorf("ReadOnly(%q).For(%q) = %t, expected %t", tt.mode, tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/go-ap/errors"
+)
+
+const layoutFileName = "layout.json"
+
+// Layout holds how the tree and pager panes are arranged on the screen.
+type Layout struct {
+	// Split is the fraction of the available space used by the tree pane.
+	Split       float64 `json:"split"`
+	Orientation string  `json:"orientation"`
+	Zoom        bool    `json:"zoom"`
+}
+
+// ConfigDir returns the directory for the application configuration, following the XDG base directory specification.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, StateDirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Annotatef(err, "unable to find the configuration directory")
+	}
+	return filepath.Join(home, ".config", StateDirName), nil
+}
+
+// LoadLayout reads the layout saved in the configuration directory.
+// A missing layout file is not an error, and the zero value is returned.
+func LoadLayout() (Layout, error) {
+	l := Layout{}
+	dir, err := ConfigDir()
+	if err != nil {
+		return l, err
+	}
+	err = loadJSON(filepath.Join(dir, layoutFileName), &l)
+	return l, err
+}
+
+// SaveLayout saves the layout in the configuration directory, replacing the previous one.
+func SaveLayout(l Layout) error {
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	return saveJSON(filepath.Join(dir, layoutFileName), l)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoadLayout(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	loaded, err := LoadLayout()
+	if err != nil {
+		t.Errorf("LoadLayout() without a saved layout errored: %s", err)
+	}
+	if loaded != (Layout{}) {
+		t.Errorf("LoadLayout() without a saved layout returned %+v, expected the zero value", loaded)
+	}
+
+	saved := Layout{Split: 0.4, Orientation: "vertical", Zoom: true}
+	if err := SaveLayout(saved); err != nil {
+		t.Fatalf("SaveLayout() errored: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, StateDirName, layoutFileName)); err != nil {
+		t.Errorf("the layout should be saved in the configuration directory: %s", err)
+	}
+	if loaded, err = LoadLayout(); err != nil {
+		t.Fatalf("LoadLayout() errored: %s", err)
+	}
+	if loaded != saved {
+		t.Errorf("LoadLayout() = %+v, expected %+v", loaded, saved)
+	}
+}
+
+func TestLoadLayout_invalid(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, StateDirName, layoutFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLayout(); err == nil {
+		t.Errorf("LoadLayout() should fail for an invalid layout file")
+	}
+}
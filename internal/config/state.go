@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/go-ap/errors"
+)
+
+// StateDirName is the name of the directory where the application keeps its state between sessions.
+var StateDirName = "motley"
+
+// StateDir returns the directory for the application state, following the XDG base directory specification.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, StateDirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Annotatef(err, "unable to find the state directory")
+	}
+	return filepath.Join(home, ".local", "state", StateDirName), nil
+}
+
+func statePath(name string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// LoadState decodes the state saved under name into v.
+// A missing state file is not an error, and v is left untouched.
+func LoadState(name string, v any) error {
+	path, err := statePath(name)
+	if err != nil {
+		return err
+	}
+	return loadJSON(path, v)
+}
+
+// SaveState encodes v as JSON and saves it under name, replacing the previous state.
+func SaveState(name string, v any) error {
+	path, err := statePath(name)
+	if err != nil {
+		return err
+	}
+	return saveJSON(path, v)
+}
+
+func loadJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Annotatef(err, "unable to read %s", path)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Annotatef(err, "invalid contents in %s", path)
+	}
+	return nil
+}
+
+func saveJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Annotatef(err, "unable to create directory for %s", path)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Annotatef(err, "unable to encode %s", path)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Annotatef(err, "unable to write %s", path)
+	}
+	return os.Rename(tmp, path)
+}
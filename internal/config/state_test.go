@@ -0,0 +1,47 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", dir)
+
+	type state struct {
+		IRIs []string `json:"iris"`
+	}
+
+	loaded := state{}
+	if err := LoadState("missing", &loaded); err != nil {
+		t.Errorf("LoadState() for missing state errored: %s", err)
+	}
+	if len(loaded.IRIs) != 0 {
+		t.Errorf("LoadState() for missing state returned %v, expected empty", loaded.IRIs)
+	}
+
+	saved := state{IRIs: []string{"https://example.com", "https://example.com/outbox"}}
+	if err := SaveState("test", saved); err != nil {
+		t.Fatalf("SaveState() errored: %s", err)
+	}
+	if err := LoadState("test", &loaded); err != nil {
+		t.Fatalf("LoadState() errored: %s", err)
+	}
+	if len(loaded.IRIs) != len(saved.IRIs) {
+		t.Fatalf("LoadState() returned %v, expected %v", loaded.IRIs, saved.IRIs)
+	}
+	for i := range saved.IRIs {
+		if loaded.IRIs[i] != saved.IRIs[i] {
+			t.Errorf("LoadState() returned %v, expected %v", loaded.IRIs, saved.IRIs)
+		}
+	}
+
+	stateDir, err := StateDir()
+	if err != nil {
+		t.Fatalf("StateDir() errored: %s", err)
+	}
+	if expected := filepath.Join(dir, StateDirName); stateDir != expected {
+		t.Errorf("StateDir() = %s, expected %s", stateDir, expected)
+	}
+}
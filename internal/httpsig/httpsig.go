@@ -0,0 +1,184 @@
+// Package httpsig signs and verifies HTTP requests following the draft-cavage-http-signatures specification,
+// in the form used by most ActivityPub servers.
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-ap/errors"
+)
+
+const (
+	AlgorithmRSASHA256 = "rsa-sha256"
+	AlgorithmHS2019    = "hs2019"
+
+	requestTarget = "(request-target)"
+)
+
+// SignedHeaders are the headers covered by the signature.
+var SignedHeaders = []string{requestTarget, "host", "date", "digest"}
+
+// Digest returns the value of the Digest header for body.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Algorithm returns the name of the signature algorithm for the key.
+func Algorithm(key crypto.PublicKey) (string, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRSASHA256, nil
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return AlgorithmHS2019, nil
+	}
+	return "", errors.NotSupportedf("unsupported key type %T", key)
+}
+
+func signingString(r *http.Request, headers []string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		h = strings.ToLower(h)
+		switch h {
+		case requestTarget:
+			lines = append(lines, fmt.Sprintf("%s: %s %s", requestTarget, strings.ToLower(r.Method), r.URL.RequestURI()))
+		case "host":
+			host := r.Host
+			if host == "" {
+				host = r.URL.Host
+			}
+			lines = append(lines, "host: "+host)
+		default:
+			v := r.Header.Values(h)
+			if len(v) == 0 {
+				return "", errors.NotFoundf("missing header %s", h)
+			}
+			lines = append(lines, h+": "+strings.Join(v, ", "))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Sign sets the Date, Digest and Signature headers on the request.
+func Sign(r *http.Request, body []byte, keyID string, key crypto.PrivateKey) error {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return errors.NotSupportedf("unsupported key type %T", key)
+	}
+	algorithm, err := Algorithm(signer.Public())
+	if err != nil {
+		return err
+	}
+	if r.Header.Get("Date") == "" {
+		r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	r.Header.Set("Digest", Digest(body))
+
+	toSign, err := signingString(r, SignedHeaders)
+	if err != nil {
+		return err
+	}
+	var sig []byte
+	switch signer.(type) {
+	case ed25519.PrivateKey, *ed25519.PrivateKey:
+		sig, err = signer.Sign(rand.Reader, []byte(toSign), crypto.Hash(0))
+	default:
+		sum := sha256.Sum256([]byte(toSign))
+		sig, err = signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "unable to sign request")
+	}
+	r.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		keyID, algorithm, strings.Join(SignedHeaders, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// Signature holds the parameters of a Signature header.
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+}
+
+// Parse parses the Signature header of the request.
+func Parse(r *http.Request) (Signature, error) {
+	s := Signature{}
+	header := r.Header.Get("Signature")
+	if header == "" {
+		return s, errors.NotFoundf("missing Signature header")
+	}
+	for _, param := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		v = strings.Trim(v, `"`)
+		switch k {
+		case "keyId":
+			s.KeyID = v
+		case "algorithm":
+			s.Algorithm = v
+		case "headers":
+			s.Headers = strings.Fields(v)
+		case "signature":
+			sig, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return s, errors.Annotatef(err, "invalid signature encoding")
+			}
+			s.Signature = sig
+		}
+	}
+	if len(s.Headers) == 0 {
+		s.Headers = []string{"date"}
+	}
+	if s.KeyID == "" || len(s.Signature) == 0 {
+		return s, errors.BadRequestf("incomplete Signature header")
+	}
+	return s, nil
+}
+
+// Verify checks the Signature header of the request against the public key, and the Digest header against body.
+func Verify(r *http.Request, body []byte, key crypto.PublicKey) error {
+	s, err := Parse(r)
+	if err != nil {
+		return err
+	}
+	if digest := r.Header.Get("Digest"); digest != "" && digest != Digest(body) {
+		return errors.BadRequestf("digest mismatch")
+	}
+	toVerify, err := signingString(r, s.Headers)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(toVerify))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], s.Signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, []byte(toVerify), s.Signature) {
+			err = errors.Errorf("invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, sum[:], s.Signature) {
+			err = errors.Errorf("invalid signature")
+		}
+	default:
+		return errors.NotSupportedf("unsupported key type %T", key)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "signature verification failed")
+	}
+	return nil
+}
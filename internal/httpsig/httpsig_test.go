@@ -0,0 +1,89 @@
+package httpsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func generateKeys(t *testing.T) map[string]crypto.Signer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %s", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ED25519 key: %s", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ECDSA key: %s", err)
+	}
+	return map[string]crypto.Signer{"rsa": rsaKey, "ed25519": edKey, "ecdsa": ecKey}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"Follow"}`)
+
+	for name, key := range generateKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ := io.ReadAll(r.Body)
+				if err := Verify(r, received, key.Public()); err != nil {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/inbox", bytes.NewReader(body))
+			if err := Sign(req, body, "https://example.com/actor#main-key", key); err != nil {
+				t.Fatalf("Sign() errored: %s", err)
+			}
+			sig, err := Parse(req)
+			if err != nil {
+				t.Fatalf("Parse() errored: %s", err)
+			}
+			if sig.KeyID != "https://example.com/actor#main-key" {
+				t.Errorf("Parse() keyId = %s, expected %s", sig.KeyID, "https://example.com/actor#main-key")
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request errored: %s", err)
+			}
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusAccepted {
+				t.Errorf("server responded with %s, expected %d", res.Status, http.StatusAccepted)
+			}
+		})
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %s", err)
+	}
+	body := []byte(`{"type":"Follow"}`)
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/inbox", bytes.NewReader(body))
+	if err := Sign(req, body, "https://example.com/actor#main-key", key); err != nil {
+		t.Fatalf("Sign() errored: %s", err)
+	}
+	if err := Verify(req, []byte(`{"type":"Block"}`), key.Public()); err == nil {
+		t.Errorf("Verify() should have failed for a different body")
+	}
+	req.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	if err := Verify(req, body, key.Public()); err == nil {
+		t.Errorf("Verify() should have failed for a different date")
+	}
+}
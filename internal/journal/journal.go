@@ -0,0 +1,226 @@
+// Package journal keeps an append only log of the changes made to storage, so they can be undone later,
+// even in a different session.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-ap/errors"
+)
+
+type Op string
+
+const (
+	// OpSave is an object being created or replaced.
+	OpSave Op = "save"
+	// OpDelete is an object being removed from storage.
+	OpDelete Op = "delete"
+	// OpAdd is items being added to a collection.
+	OpAdd Op = "add"
+	// OpRemove is items being removed from a collection.
+	OpRemove Op = "remove"
+	// OpMetadata is the metadata of an actor being changed, like its password or its private key.
+	OpMetadata Op = "metadata"
+	// OpClient is an OAuth2 client being created or deleted.
+	OpClient Op = "client"
+	// OpRevoke is an OAuth2 authorization or access token being removed, which can't be undone.
+	OpRevoke Op = "revoke"
+)
+
+// Entry is a single change to storage.
+// For OpSave and OpDelete, IRI is the object and Before and After hold its JSON-LD representation,
+// for OpDelete, Items are also the IRIs of the collections the object was removed from,
+// for OpAdd and OpRemove, IRI is the collection and Items the IRIs that were added or removed,
+// for OpMetadata, IRI is the actor and Before and After hold its metadata,
+// for OpClient, IRI is the instance, Items the client ID and Before and After hold the client,
+// for OpRevoke, IRI is the actor the authorization or token was issued for, or the instance, and Items is its kind.
+type Entry struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Instance is the IRI of the root actor of the store that was changed.
+	Instance string          `json:"instance,omitempty"`
+	Op       Op              `json:"op"`
+	IRI      string          `json:"iri"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+	Items    []string        `json:"items,omitempty"`
+	Undone   bool            `json:"undone,omitempty"`
+}
+
+// Journal is a file with one JSON entry per line.
+// Changing the state of an entry appends it again, the last line with an ID wins.
+type Journal struct {
+	path string
+	max  int
+
+	mu    sync.Mutex
+	lines int
+	last  int64
+	// undone is set when the journal might have undone entries, which are dropped by the next Append.
+	undone bool
+}
+
+// Open opens the journal at path, which keeps the last max entries.
+func Open(path string, max int) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Annotatef(err, "unable to create journal directory")
+	}
+	j := &Journal{path: path, max: max}
+	entries, lines, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+	j.lines = lines
+	if len(entries) > 0 {
+		j.last, _ = strconv.ParseInt(entries[len(entries)-1].ID, 10, 64)
+	}
+	for _, e := range entries {
+		j.undone = j.undone || e.Undone
+	}
+	return j, nil
+}
+
+func (j *Journal) read() ([]Entry, int, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, errors.Annotatef(err, "unable to open journal")
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	index := make(map[string]int)
+	lines := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		lines++
+		e := Entry{}
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// NOTE(marius): a partially written last line, we skip it.
+			continue
+		}
+		if i, ok := index[e.ID]; ok {
+			entries[i] = e
+			continue
+		}
+		index[e.ID] = len(entries)
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, lines, errors.Annotatef(err, "unable to read journal")
+	}
+	if over := len(entries) - j.max; j.max > 0 && over > 0 {
+		entries = entries[over:]
+	}
+	return entries, lines, nil
+}
+
+// Entries returns the entries in the journal, oldest first.
+func (j *Journal) Entries() ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries, _, err := j.read()
+	return entries, err
+}
+
+func (j *Journal) write(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Annotatef(err, "unable to encode journal entry")
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Annotatef(err, "unable to open journal")
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return errors.Annotatef(err, "unable to write journal")
+	}
+	j.lines++
+	return nil
+}
+
+// Append adds a new entry to the journal, setting its ID and time.
+// The undone entries of the same instance are dropped, as they can't be redone on top of a new change.
+func (j *Journal) Append(e Entry) (Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.undone {
+		keep := func(old Entry) bool { return !old.Undone || old.Instance != e.Instance }
+		if err := j.rewrite(keep); err != nil {
+			return e, err
+		}
+	}
+	j.last++
+	e.ID = strconv.FormatInt(j.last, 10)
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if err := j.write(e); err != nil {
+		return e, err
+	}
+	if j.max > 0 && j.lines > 2*j.max {
+		return e, j.rewrite(nil)
+	}
+	return e, nil
+}
+
+// SetUndone records that the entry has been undone or redone.
+func (j *Journal) SetUndone(e Entry, undone bool) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Undone = undone
+	j.undone = j.undone || undone
+	return j.write(e)
+}
+
+// rewrite compacts the journal keeping only the last state of the last max entries, and only those
+// for which keep returns true, if it's set.
+func (j *Journal) rewrite(keep func(Entry) bool) error {
+	entries, _, err := j.read()
+	if err != nil {
+		return err
+	}
+	if keep != nil {
+		count := len(entries)
+		entries = slices.DeleteFunc(entries, func(e Entry) bool { return !keep(e) })
+		if len(entries) == count {
+			j.undone = slices.ContainsFunc(entries, func(e Entry) bool { return e.Undone })
+			return nil
+		}
+	}
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Annotatef(err, "unable to rewrite journal")
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			_ = f.Close()
+			return errors.Annotatef(err, "unable to rewrite journal")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return errors.Annotatef(err, "unable to rewrite journal")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Annotatef(err, "unable to rewrite journal")
+	}
+	j.lines = len(entries)
+	j.undone = slices.ContainsFunc(entries, func(e Entry) bool { return e.Undone })
+	return os.Rename(tmp, j.path)
+}
@@ -0,0 +1,151 @@
+package journal
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("Open() errored: %s", err)
+	}
+	saved, err := j.Append(Entry{Op: OpSave, IRI: "https://example.com/1", After: json.RawMessage(`{"type":"Note"}`)})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	if _, err = j.Append(Entry{Op: OpAdd, IRI: "https://example.com/outbox", Items: []string{"https://example.com/1"}}); err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	if err := j.SetUndone(saved, true); err != nil {
+		t.Fatalf("SetUndone() errored: %s", err)
+	}
+
+	reopened, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("Open() errored: %s", err)
+	}
+	entries, err := reopened.Entries()
+	if err != nil {
+		t.Fatalf("Entries() errored: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Entries() returned %d entries, expected 2", len(entries))
+	}
+	if entries[0].ID != saved.ID || !entries[0].Undone {
+		t.Errorf("first entry %+v should have been undone", entries[0])
+	}
+	if string(entries[0].After) != `{"type":"Note"}` {
+		t.Errorf("first entry After = %s, expected %s", entries[0].After, `{"type":"Note"}`)
+	}
+	if entries[1].Op != OpAdd || len(entries[1].Items) != 1 {
+		t.Errorf("second entry %+v is not the add operation", entries[1])
+	}
+
+	next, err := reopened.Append(Entry{Op: OpDelete, IRI: "https://example.com/1"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	if next.ID == saved.ID || next.ID == entries[1].ID {
+		t.Errorf("Append() after reopening reused ID %s", next.ID)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := Open(path, 3)
+	if err != nil {
+		t.Fatalf("Open() errored: %s", err)
+	}
+	var last Entry
+	for i := 0; i < 10; i++ {
+		if last, err = j.Append(Entry{Op: OpSave, IRI: "https://example.com/1"}); err != nil {
+			t.Fatalf("Append() errored: %s", err)
+		}
+	}
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatalf("Entries() errored: %s", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Entries() returned %d entries, expected 3", len(entries))
+	}
+	if entries[2].ID != last.ID {
+		t.Errorf("last entry is %s, expected %s", entries[2].ID, last.ID)
+	}
+	if j.lines > 2*j.max {
+		t.Errorf("journal has %d lines, it should have been compacted", j.lines)
+	}
+}
+
+func TestAppendDropsUndone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("Open() errored: %s", err)
+	}
+	kept, err := j.Append(Entry{Op: OpSave, IRI: "https://example.com/1"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	undone, err := j.Append(Entry{Op: OpSave, IRI: "https://example.com/2"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	if err := j.SetUndone(undone, true); err != nil {
+		t.Fatalf("SetUndone() errored: %s", err)
+	}
+
+	reopened, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("Open() errored: %s", err)
+	}
+	last, err := reopened.Append(Entry{Op: OpSave, IRI: "https://example.com/3"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	entries, err := reopened.Entries()
+	if err != nil {
+		t.Fatalf("Entries() errored: %s", err)
+	}
+	if len(entries) != 2 || entries[0].ID != kept.ID || entries[1].ID != last.ID {
+		t.Fatalf("Entries() returned %+v, expected the undone entry to be dropped", entries)
+	}
+	if last.ID == undone.ID {
+		t.Errorf("Append() reused the ID %s of the dropped entry", last.ID)
+	}
+}
+
+func TestAppendKeepsUndoneOfOtherInstances(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal.jsonl"), 10)
+	if err != nil {
+		t.Fatalf("Open() errored: %s", err)
+	}
+	other, err := j.Append(Entry{Instance: "https://example.org", Op: OpSave, IRI: "https://example.org/1"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	undone, err := j.Append(Entry{Instance: "https://example.com", Op: OpSave, IRI: "https://example.com/1"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	for _, e := range []Entry{other, undone} {
+		if err := j.SetUndone(e, true); err != nil {
+			t.Fatalf("SetUndone() errored: %s", err)
+		}
+	}
+
+	last, err := j.Append(Entry{Instance: "https://example.com", Op: OpSave, IRI: "https://example.com/2"})
+	if err != nil {
+		t.Fatalf("Append() errored: %s", err)
+	}
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatalf("Entries() errored: %s", err)
+	}
+	if len(entries) != 2 || entries[0].ID != other.ID || !entries[0].Undone || entries[1].ID != last.ID {
+		t.Fatalf("Entries() returned %+v, expected only the undone entry of the same instance to be dropped", entries)
+	}
+}
@@ -1,8 +1,6 @@
 package motley
 
 import (
-	"fmt"
-
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -24,67 +22,99 @@ type pagerModel struct {
 
 	viewport viewport.Model
 	model    tea.Model
+	focus    bool
+
+	// x, y hold the position of the pager content on the screen.
+	x, y int
 }
 
-func (p *pagerModel) setSize(w, h int) {
-	p.viewport.SetHeight(h)
-	p.viewport.SetWidth(w)
+func (p *pagerModel) Focus() {
+	p.focus = true
 }
 
-func (p pagerModel) View() tea.View {
-	h := p.viewport.Height()
-	w := p.viewport.Width()
-	s := lipgloss.NewStyle().Height(h).MaxHeight(h).MaxWidth(w).Width(w)
-	p.viewport.SetContent(s.Render(p.model.View().Content))
-	return tea.NewView(p.viewport.View())
+func (p *pagerModel) Blur() {
+	p.focus = false
 }
 
-func (p *pagerModel) updateIntransitiveActivity(a *vocab.IntransitiveActivity) error {
-	// TODO(marius): IntransitiveActivity stuff
-	return nil
+func (p *pagerModel) Focused() bool {
+	return p.focus
 }
 
-func (p *pagerModel) updateActivity(a *vocab.Activity) error {
-	if err := vocab.OnIntransitiveActivity(a, p.updateIntransitiveActivity); err != nil {
-		return err
-	}
-	// TODO(marius): Activity stuff
-	return nil
+// placer is implemented by the content models which depend on the position and the size of the pager.
+type placer interface {
+	place(x, y, w, h int)
 }
 
-func (p *pagerModel) updateActor(a *vocab.Actor) error {
-	return nil
+// show replaces the current content of the pager with the received model.
+// showsNode returns whether the pager shows the current node, and not one of the other views.
+func (p *pagerModel) showsNode() bool {
+	switch p.model.(type) {
+	case *InspectorModel, motelyPager:
+		return true
+	}
+	return false
 }
 
-func (p *pagerModel) updateObject(o *vocab.Object) error {
-	return nil
+func (p *pagerModel) show(content tea.Model) tea.Cmd {
+	p.model = content
+	p.viewport.GotoTop()
+	p.placeContent()
+	return content.Init()
 }
-func (p *pagerModel) updateItems(items *vocab.ItemCollection) error {
-	return nil
+
+func (p *pagerModel) setSize(w, h int) {
+	p.viewport.SetHeight(h)
+	p.viewport.SetWidth(w)
 }
 
-func (p *pagerModel) updateModel(it vocab.Item) error {
-	if it == nil {
-		return nil
+// placeContent lets the content model know the current position and size of the pager.
+func (p *pagerModel) placeContent() {
+	if pl, ok := p.model.(placer); ok {
+		pl.place(p.x, p.y, p.viewport.Width(), p.viewport.Height())
 	}
+}
 
-	if vocab.IsItemCollection(it) {
-		return vocab.OnItemCollection(it, p.updateItems)
-	}
-	typ := it.GetType()
-	if vocab.IntransitiveActivityTypes.Match(typ) {
-		return vocab.OnIntransitiveActivity(it, p.updateIntransitiveActivity)
-	}
-	if vocab.ActivityTypes.Match(typ) {
-		return vocab.OnActivity(it, p.updateActivity)
+func (p pagerModel) View() tea.View {
+	p.render()
+	return tea.NewView(p.viewport.View())
+}
+
+// render sets the content of the viewport, which it needs for knowing how far it can scroll.
+func (p *pagerModel) render() {
+	w := p.viewport.Width()
+	s := lipgloss.NewStyle().MaxWidth(w).Width(w)
+	p.viewport.SetContent(s.Render(p.model.View().Content))
+}
+
+// scroll moves the content of the pager for the mouse wheel.
+func (p *pagerModel) scroll(msg tea.MouseWheelMsg) tea.Cmd {
+	p.render()
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return cmd
+}
+
+// cursorer is implemented by the content models with a cursor, which the pager keeps in view.
+type cursorer interface {
+	cursorLine() int
+}
+
+func (p *pagerModel) followCursor() {
+	c, ok := p.model.(cursorer)
+	if !ok {
+		return
 	}
-	if vocab.ActorTypes.Match(typ) {
-		return vocab.OnActor(it, p.updateActor)
+	line := c.cursorLine()
+	if line < 0 {
+		return
 	}
-	if vocab.ObjectTypes.Match(typ) || vocab.NilType.Match(typ) {
-		return vocab.OnObject(it, p.updateObject)
+	p.render()
+	switch top, h := p.viewport.YOffset(), p.viewport.Height(); {
+	case line < top:
+		p.viewport.SetYOffset(line)
+	case line >= top+h:
+		p.viewport.SetYOffset(line - h + 1)
 	}
-	return fmt.Errorf("unknown activitypub object of type %T", it)
 }
 
 func newItemModel(common *commonModel) pagerModel {
@@ -106,11 +136,20 @@ func (p pagerModel) Init() tea.Cmd {
 
 func (p *pagerModel) updateAsModel(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, 0)
+	if mm, ok := msg.(nodeRefreshMsg); ok {
+		if !p.showsNode() {
+			return noop
+		}
+		msg = nodeUpdateMsg(mm)
+	}
 	switch mm := msg.(type) {
 	case tea.WindowSizeMsg:
 		p.logFn("item resize: %+v", msg)
 	case nodeUpdateMsg:
 		var content tea.Model = M
+		if vocab.IsNil(p.item) || vocab.IsNil(mm.Item) || p.item.GetLink() != mm.Item.GetLink() {
+			p.viewport.GotoTop()
+		}
 		p.item = mm.Item
 		if vocab.IsIRI(p.item) {
 		}
@@ -124,19 +163,26 @@ func (p *pagerModel) updateAsModel(msg tea.Msg) tea.Cmd {
 				vocab.InviteType, vocab.JoinType, vocab.LeaveType, vocab.LikeType, vocab.ListenType,
 				vocab.MoveType, vocab.OfferType, vocab.RejectType, vocab.ReadType, vocab.RemoveType,
 				vocab.TentativeRejectType, vocab.TentativeAcceptType, vocab.UndoType, vocab.UpdateType, vocab.ViewType}.Match(t):
-				ob := newActivityModel()
+				ob := newActivityModel(p.langs)
 				if err := vocab.OnActivity(p.item, ob.updateActivity); err != nil {
 					cmds = append(cmds, errCmd(err))
 				}
 				content = ob
 			case vocab.ActivityVocabularyTypes{vocab.OrderedCollectionPageType, vocab.CollectionPageType, vocab.OrderedCollectionType, vocab.CollectionType}.Match(t):
-				ob := newCollectionModel()
+				ob := newCollectionModel(p.langs)
 				if err := vocab.OnCollectionIntf(p.item, ob.updateCollection); err != nil {
 					cmds = append(cmds, errCmd(err))
 				}
 				content = ob
+			case isMediaItem(p.item):
+				ob := newMediaModel(p.x, p.y, p.viewport.Width(), p.viewport.Height(), p.langs, p.f.hosts())
+				if err := vocab.OnObject(p.item, ob.updateObject); err != nil {
+					cmds = append(cmds, errCmd(err))
+				}
+				ob.reuse(mediaContent(p.model))
+				content = ob
 			default:
-				ob := newObjectModel()
+				ob := newObjectModel(p.langs)
 				if err := vocab.OnObject(p.item, ob.updateObject); err != nil {
 					cmds = append(cmds, errCmd(err))
 				}
@@ -144,22 +190,42 @@ func (p *pagerModel) updateAsModel(msg tea.Msg) tea.Cmd {
 			}
 		}
 		if vocab.IsLink(p.item) {
-			ob := newLinkModel()
+			ob := newLinkModel(p.langs)
 			if err := vocab.OnLink(p.item, ob.updateLink); err != nil {
 				cmds = append(cmds, errCmd(err))
 			}
 			content = ob
 		}
+		if !vocab.IsNil(p.item) {
+			inspector := newInspectorModel(content)
+			if err := inspector.updateModel(p.item); err != nil {
+				cmds = append(cmds, errCmd(err))
+			}
+			content = inspector
+		}
 		p.model = content
+		p.placeContent()
+		cmds = append(cmds, content.Init())
 	case tea.KeyMsg:
 		switch mm.String() {
 		case "home", "g":
 			p.viewport.GotoTop()
 		case "end", "G":
+			p.render()
 			p.viewport.GotoBottom()
 		}
 	}
 
+	// NOTE(marius): key presses reach the content model only when the pager has focus,
+	// otherwise they would be interpreted by both the tree and the pager.
+	if _, isKey := msg.(tea.KeyMsg); !isKey || p.focus {
+		var cmd tea.Cmd
+		p.model, cmd = p.model.Update(msg)
+		cmds = append(cmds, cmd)
+		if isKey {
+			p.followCursor()
+		}
+	}
 	return tea.Batch(cmds...)
 }
 
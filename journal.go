@@ -0,0 +1,474 @@
+package motley
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/lw"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	"git.sr.ht/~mariusor/motley/internal/journal"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+	"github.com/go-ap/jsonld"
+	"github.com/openshift/osin"
+)
+
+const (
+	journalFileName   = "journal.jsonl"
+	journalMaxEntries = 1000
+	journalTimeFmt    = "2006-01-02 15:04:05"
+)
+
+var (
+	journalKey = key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "show journal of changes"),
+	)
+	journalUndoKey = key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo up to selected change"),
+	)
+	journalRedoKey = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "redo up to selected change"),
+	)
+)
+
+func openJournal(l lw.Logger) *journal.Journal {
+	dir, err := config.StateDir()
+	if err != nil {
+		l.Warnf("unable to open journal, changes can't be undone: %s", err)
+		return nil
+	}
+	j, err := journal.Open(filepath.Join(dir, journalFileName), journalMaxEntries)
+	if err != nil {
+		l.Warnf("unable to open journal, changes can't be undone: %s", err)
+		return nil
+	}
+	return j
+}
+
+func marshalItem(it pub.Item) json.RawMessage {
+	if pub.IsNil(it) {
+		return nil
+	}
+	raw, err := jsonld.WithContext(jsonld.IRI(pub.ActivityBaseURI)).Marshal(it)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// record appends the entry to the journal, under the instance of the store it changed.
+func (f *fedbox) record(e journal.Entry) {
+	if f.journal == nil {
+		return
+	}
+	if instance, err := f.instanceFor(pub.IRI(e.IRI)); err == nil {
+		e.Instance = instance.String()
+	}
+	if _, err := f.journal.Append(e); err != nil {
+		f.logFn("unable to record %s of %s: %s", e.Op, e.IRI, err)
+	}
+}
+
+func (f *fedbox) recordItem(op journal.Op, iri pub.IRI, before, after pub.Item) {
+	f.record(journal.Entry{Op: op, IRI: iri.String(), Before: marshalItem(before), After: marshalItem(after)})
+}
+
+func (f *fedbox) recordDelete(iri pub.IRI, before pub.Item, from ...pub.IRI) {
+	e := journal.Entry{Op: journal.OpDelete, IRI: iri.String(), Before: marshalItem(before)}
+	for _, col := range from {
+		e.Items = append(e.Items, col.String())
+	}
+	f.record(e)
+}
+
+func (f *fedbox) recordCollection(op journal.Op, col pub.IRI, items ...pub.Item) {
+	e := journal.Entry{Op: op, IRI: col.String()}
+	for _, it := range items {
+		e.Items = append(e.Items, it.GetLink().String())
+	}
+	f.record(e)
+}
+
+// changeMetadata runs fn, which changes the metadata of the actor at iri, like its password, its private key
+// or its lock, and records the metadata from before and after the change.
+// NOTE(marius): the change is recorded even when fn fails, as it might have saved the metadata before failing.
+func (f *fedbox) changeMetadata(st Store, op string, iri pub.IRI, fn func() error) error {
+	before, err := loadAccountMetadata(st, iri)
+	if err != nil {
+		return err
+	}
+	err = st.write(op, iri, fn)
+	after, _ := loadAccountMetadata(st, iri)
+	if b, a := marshalMetadata(before), marshalMetadata(after); !bytes.Equal(b, a) {
+		f.record(journal.Entry{Op: journal.OpMetadata, IRI: iri.String(), Before: b, After: a})
+	}
+	return err
+}
+
+func marshalMetadata(m accountMetadata) json.RawMessage {
+	if len(m) == 0 {
+		return nil
+	}
+	raw, _ := json.Marshal(m)
+	return raw
+}
+
+func marshalClient(c osin.Client) json.RawMessage {
+	raw, _ := json.Marshal(osin.DefaultClient{Id: c.GetId(), Secret: c.GetSecret(), RedirectUri: c.GetRedirectUri(), UserData: c.GetUserData()})
+	return raw
+}
+
+// recordClient records the creation of the client when created is set, or its deletion otherwise.
+func (f *fedbox) recordClient(st Store, c osin.Client, created bool) {
+	e := journal.Entry{Op: journal.OpClient, IRI: st.root.GetLink().String(), Items: []string{c.GetId()}}
+	if created {
+		e.After = marshalClient(c)
+	} else {
+		e.Before = marshalClient(c)
+	}
+	f.record(e)
+}
+
+// recordRevoke records the removal of an authorization or of an access token, of kind "authorize" or "access",
+// issued for the actor at iri, or for an unknown actor of the store when it's empty.
+// NOTE(marius): the values of the authorizations and tokens are not recorded, as they can't be restored.
+func (f *fedbox) recordRevoke(st Store, kind string, iri pub.IRI) {
+	if iri == "" {
+		iri = st.root.GetLink()
+	}
+	f.record(journal.Entry{Op: journal.OpRevoke, IRI: iri.String(), Items: []string{kind}})
+}
+
+func unmarshalItem(raw json.RawMessage) (pub.Item, error) {
+	it, err := pub.UnmarshalJSON(raw)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid journal entry")
+	}
+	return it, nil
+}
+
+// applyEntry reverts the change when undo is set, or makes it again otherwise.
+func (f *fedbox) applyEntry(e journal.Entry, undo bool) error {
+	iri := pub.IRI(e.IRI)
+	st, err := f.storeFor(iri)
+	if err != nil {
+		return err
+	}
+	op := "redo " + string(e.Op)
+	if undo {
+		op = "undo " + string(e.Op)
+	}
+	return st.write(op, iri, func() error {
+		return applyEntry(st, e, undo)
+	})
+}
+
+// NOTE(marius): this calls the storage directly, as undoing and redoing must not be recorded as new changes.
+func applyEntry(st Store, e journal.Entry, undo bool) error {
+	iri := pub.IRI(e.IRI)
+	items := make(pub.ItemCollection, 0, len(e.Items))
+	for _, i := range e.Items {
+		items = append(items, pub.IRI(i))
+	}
+
+	// NOTE(marius): a save is undone by saving the previous state, and adding items by removing them,
+	// and the other way around.
+	op := e.Op
+	state := e.After
+	if undo {
+		state = e.Before
+		switch e.Op {
+		case journal.OpDelete:
+			return undoDelete(st, iri, state, items)
+		case journal.OpRevoke:
+			return fmt.Errorf("revoked OAuth2 authorizations and tokens can't be restored")
+		case journal.OpAdd:
+			op = journal.OpRemove
+		case journal.OpRemove:
+			op = journal.OpAdd
+		}
+	}
+	switch op {
+	case journal.OpSave:
+		if len(state) == 0 {
+			// NOTE(marius): undoing the creation of an object.
+			return st.s.Delete(iri)
+		}
+		it, err := unmarshalItem(state)
+		if err != nil {
+			return err
+		}
+		_, err = st.s.Save(it)
+		return err
+	case journal.OpDelete:
+		for _, col := range items {
+			if err := st.s.RemoveFrom(col.GetLink(), iri); err != nil {
+				return err
+			}
+		}
+		return st.s.Delete(iri)
+	case journal.OpAdd:
+		return st.s.AddTo(iri, items...)
+	case journal.OpRemove:
+		return st.s.RemoveFrom(iri, items...)
+	case journal.OpMetadata:
+		m := make(accountMetadata)
+		if len(state) > 0 {
+			if err := json.Unmarshal(state, &m); err != nil {
+				return errors.Annotatef(err, "invalid journal entry")
+			}
+		}
+		return st.s.SaveMetadata(iri, m)
+	case journal.OpClient:
+		if len(state) == 0 {
+			if len(e.Items) == 0 {
+				return fmt.Errorf("invalid journal entry, missing client ID")
+			}
+			return st.s.RemoveClient(e.Items[0])
+		}
+		c := osin.DefaultClient{}
+		if err := json.Unmarshal(state, &c); err != nil {
+			return errors.Annotatef(err, "invalid journal entry")
+		}
+		return st.s.CreateClient(&c)
+	}
+	return fmt.Errorf("unknown journal operation %q", e.Op)
+}
+
+// undoDelete saves the deleted object back, and adds it to the collections it was removed from.
+func undoDelete(st Store, iri pub.IRI, state json.RawMessage, from pub.ItemCollection) error {
+	if len(state) > 0 {
+		it, err := unmarshalItem(state)
+		if err != nil {
+			return err
+		}
+		if _, err = st.s.Save(it); err != nil {
+			return err
+		}
+	}
+	for _, col := range from {
+		if err := st.s.AddTo(col.GetLink(), iri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type journalLoadedMsg struct {
+	j       *JournalModel
+	entries []journal.Entry
+	err     error
+}
+
+type journalAppliedMsg struct {
+	j    *JournalModel
+	undo bool
+	done int
+	err  error
+}
+
+// JournalModel lists the changes made to the open stores, newest first, and allows undoing and redoing them in order.
+type JournalModel struct {
+	*commonModel
+
+	loading bool
+	running bool
+	cursor  int
+	// entries are the entries of the journal, newest first.
+	entries []journal.Entry
+	err     error
+}
+
+func newJournalModel(common *commonModel) *JournalModel {
+	return &JournalModel{commonModel: common}
+}
+
+func (j *JournalModel) Init() tea.Cmd {
+	if j.f == nil || j.f.journal == nil {
+		j.err = fmt.Errorf("the journal is not available")
+		return noop
+	}
+	j.loading = true
+	f := j.f
+	return func() tea.Msg {
+		entries, err := f.journal.Entries()
+		entries = slices.DeleteFunc(entries, func(e journal.Entry) bool { return !f.isOpen(e) })
+		slices.Reverse(entries)
+		return journalLoadedMsg{j: j, entries: entries, err: err}
+	}
+}
+
+// isOpen returns whether the entry changed one of the open stores.
+// NOTE(marius): the entries recorded before keeping the instance are matched by their IRI.
+func (f *fedbox) isOpen(e journal.Entry) bool {
+	if e.Instance == "" {
+		_, err := f.storeFor(pub.IRI(e.IRI))
+		return err == nil
+	}
+	for _, st := range f.stores {
+		if !pub.IsNil(st.root) && st.root.GetLink().Equals(pub.IRI(e.Instance), false) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply undoes the changes newer than the selected one, including it, newest first,
+// or redoes the undone changes older than the selected one, including it, oldest first.
+func (j *JournalModel) apply(undo bool) tea.Cmd {
+	if j.cursor < 0 || j.cursor >= len(j.entries) {
+		return noop
+	}
+	todo := make([]journal.Entry, 0)
+	if undo {
+		for _, e := range j.entries[:j.cursor+1] {
+			if !e.Undone {
+				todo = append(todo, e)
+			}
+		}
+	} else {
+		for i := len(j.entries) - 1; i >= j.cursor; i-- {
+			if e := j.entries[i]; e.Undone {
+				todo = append(todo, e)
+			}
+		}
+	}
+	if len(todo) == 0 {
+		return noop
+	}
+	iris := make([]pub.IRI, 0, len(todo))
+	for _, e := range todo {
+		iris = append(iris, pub.IRI(e.IRI))
+	}
+	f := j.f
+	stores := f.storesFor(iris...)
+	return confirmWrites(func() tea.Cmd {
+		j.running = true
+		return j.applyCmd(f, todo, stores, undo)
+	}, stores...)
+}
+
+func (j *JournalModel) applyCmd(f *fedbox, todo []journal.Entry, stores []Store, undo bool) tea.Cmd {
+	return func() tea.Msg {
+		msg := journalAppliedMsg{j: j, undo: undo}
+		release, err := beginActions(stores...)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer release()
+		for _, e := range todo {
+			if err := f.applyEntry(e, undo); err != nil {
+				msg.err = errors.Annotatef(err, "unable to apply %s of %s", e.Op, e.IRI)
+				break
+			}
+			if err := f.journal.SetUndone(e, undo); err != nil {
+				msg.err = err
+				break
+			}
+			msg.done++
+		}
+		return msg
+	}
+}
+
+func (j *JournalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case journalLoadedMsg:
+		if mm.j != j {
+			return j, noop
+		}
+		j.loading = false
+		j.entries = mm.entries
+		j.err = mm.err
+		j.cursor = clamp(j.cursor, 0, max(0, len(j.entries)-1))
+	case journalAppliedMsg:
+		if mm.j != j {
+			return j, noop
+		}
+		j.running = false
+		action := "redone"
+		if mm.undo {
+			action = "undone"
+		}
+		cmds := []tea.Cmd{j.Init(), statusMessageCmd("%d changes %s", mm.done, action)}
+		if mm.err != nil {
+			cmds = append(cmds, errCmd(mm.err))
+		}
+		return j, tea.Batch(cmds...)
+	case tea.KeyPressMsg:
+		if j.running {
+			return j, noop
+		}
+		switch {
+		case key.Matches(mm, listUpKey):
+			j.cursor = clamp(j.cursor-1, 0, max(0, len(j.entries)-1))
+		case key.Matches(mm, listDownKey):
+			j.cursor = clamp(j.cursor+1, 0, max(0, len(j.entries)-1))
+		case key.Matches(mm, journalUndoKey):
+			return j, j.apply(true)
+		case key.Matches(mm, journalRedoKey):
+			return j, j.apply(false)
+		}
+	}
+	return j, noop
+}
+
+func entryLine(e journal.Entry) string {
+	line := fmt.Sprintf("%s %-6s %s", e.Time.Local().Format(journalTimeFmt), e.Op, e.IRI)
+	switch len(e.Items) {
+	case 0:
+	case 1:
+		line += " " + e.Items[0]
+	default:
+		line += fmt.Sprintf(" %d items", len(e.Items))
+	}
+	return line
+}
+
+func (j *JournalModel) View() tea.View {
+	undoneStyle := lipgloss.NewStyle().Faint(true).Strikethrough(true)
+
+	pieces := []string{viewTitleStyle.Render("Journal")}
+	if j.err != nil {
+		pieces = append(pieces, faintRedFg.Render(j.err.Error()))
+	}
+	switch {
+	case j.loading:
+		pieces = append(pieces, "Loading journal"+ellipsis)
+	case j.running:
+		pieces = append(pieces, "Applying changes"+ellipsis)
+	case len(j.entries) == 0:
+		pieces = append(pieces, "No changes recorded")
+	default:
+		for i, e := range j.entries {
+			line := entryLine(e)
+			if e.Undone {
+				line = undoneStyle.Render(line)
+			}
+			if i == j.cursor {
+				line = hintFg.Render(line)
+			}
+			pieces = append(pieces, line)
+		}
+		e := j.entries[j.cursor]
+		if len(e.Before) > 0 {
+			pieces = append(pieces, "", fieldStyle.Render("Before"), prettyJSON(e.Before))
+		}
+		if len(e.After) > 0 {
+			pieces = append(pieces, "", fieldStyle.Render("After"), prettyJSON(e.After))
+		}
+	}
+
+	pieces = append(pieces, "", helpLine(journalUndoKey, journalRedoKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
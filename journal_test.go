@@ -0,0 +1,319 @@
+package motley
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~mariusor/motley/internal/journal"
+	pub "github.com/go-ap/activitypub"
+)
+
+func newTestJournal(t *testing.T) (*fedbox, Store) {
+	t.Helper()
+	f, st := newTestFedbox(t)
+	j, err := journal.Open(filepath.Join(t.TempDir(), journalFileName), journalMaxEntries)
+	if err != nil {
+		t.Fatalf("unable to open journal: %s", err)
+	}
+	f.journal = j
+	return f, st
+}
+
+func inCollection(t *testing.T, st Store, col, iri pub.IRI) bool {
+	t.Helper()
+	it, err := st.s.Load(col)
+	if err != nil {
+		t.Fatalf("unable to load %s: %s", col, err)
+	}
+	found := false
+	_ = pub.OnCollectionIntf(it, func(c pub.CollectionInterface) error {
+		found = c.Contains(iri)
+		return nil
+	})
+	return found
+}
+
+func lastEntry(t *testing.T, f *fedbox) journal.Entry {
+	t.Helper()
+	entries, err := f.journal.Entries()
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("unable to load journal entries: %v", err)
+	}
+	return entries[len(entries)-1]
+}
+
+func TestApplyEntry_delete(t *testing.T) {
+	f, st := newTestJournal(t)
+	outbox := pub.Outbox.IRI(st.root)
+	note := &pub.Object{ID: "https://example.com/objects/1", Type: pub.NoteType, Content: pub.DefaultNaturalLanguage("hello")}
+	if _, err := st.s.Save(note); err != nil {
+		t.Fatalf("unable to save note: %s", err)
+	}
+	if err := st.s.AddTo(outbox, note.ID); err != nil {
+		t.Fatalf("unable to add note to outbox: %s", err)
+	}
+
+	if err := f.Delete(note, outbox); err != nil {
+		t.Fatalf("unable to delete note: %s", err)
+	}
+	e := lastEntry(t, f)
+	if e.Op != journal.OpDelete || len(e.Items) != 1 || e.Items[0] != outbox.String() {
+		t.Fatalf("the delete should record the collections, got %+v", e)
+	}
+	if inCollection(t, st, outbox, note.ID) {
+		t.Fatalf("the note should be removed from the outbox")
+	}
+
+	if err := f.applyEntry(e, true); err != nil {
+		t.Fatalf("unable to undo delete: %s", err)
+	}
+	if _, err := st.s.Load(note.ID); err != nil {
+		t.Errorf("undoing the delete should restore the note: %s", err)
+	}
+	if !inCollection(t, st, outbox, note.ID) {
+		t.Errorf("undoing the delete should add the note back to the outbox")
+	}
+
+	if err := f.applyEntry(e, false); err != nil {
+		t.Fatalf("unable to redo delete: %s", err)
+	}
+	if inCollection(t, st, outbox, note.ID) {
+		t.Errorf("redoing the delete should remove the note from the outbox")
+	}
+}
+
+func TestApplyEntry_save(t *testing.T) {
+	f, st := newTestJournal(t)
+	note := &pub.Object{ID: "https://example.com/objects/1", Type: pub.NoteType, Content: pub.DefaultNaturalLanguage("hello")}
+	if _, err := f.Save(note); err != nil {
+		t.Fatalf("unable to save note: %s", err)
+	}
+	created := lastEntry(t, f)
+	edited := *note
+	edited.Content = pub.DefaultNaturalLanguage("edited")
+	if _, err := f.Save(&edited); err != nil {
+		t.Fatalf("unable to save note: %s", err)
+	}
+	e := lastEntry(t, f)
+
+	content := func() string {
+		it, err := st.s.Load(note.ID)
+		if err != nil {
+			return ""
+		}
+		var s string
+		_ = pub.OnObject(it, func(ob *pub.Object) error {
+			s = ob.Content.String()
+			return nil
+		})
+		return s
+	}
+	if err := f.applyEntry(e, true); err != nil {
+		t.Fatalf("unable to undo save: %s", err)
+	}
+	if c := content(); c != "hello" {
+		t.Errorf("undoing the edit should restore the content, got %q", c)
+	}
+	if err := f.applyEntry(e, false); err != nil {
+		t.Fatalf("unable to redo save: %s", err)
+	}
+	if c := content(); c != "edited" {
+		t.Errorf("redoing the edit should change the content, got %q", c)
+	}
+	if err := f.applyEntry(created, true); err != nil {
+		t.Fatalf("unable to undo creation: %s", err)
+	}
+	if _, err := st.s.Load(note.ID); err == nil {
+		t.Errorf("undoing the creation should remove the note")
+	}
+}
+
+func TestApplyEntry_collection(t *testing.T) {
+	f, st := newTestJournal(t)
+	inbox := pub.Inbox.IRI(st.root)
+	note := &pub.Object{ID: "https://example.com/objects/1", Type: pub.NoteType}
+	if _, err := st.s.Save(note); err != nil {
+		t.Fatalf("unable to save note: %s", err)
+	}
+	if err := f.AddTo(inbox, note.ID); err != nil {
+		t.Fatalf("unable to add note: %s", err)
+	}
+	e := lastEntry(t, f)
+	if err := f.applyEntry(e, true); err != nil {
+		t.Fatalf("unable to undo add: %s", err)
+	}
+	if inCollection(t, st, inbox, note.ID) {
+		t.Errorf("undoing the add should remove the note")
+	}
+	if err := f.applyEntry(e, false); err != nil {
+		t.Fatalf("unable to redo add: %s", err)
+	}
+	if !inCollection(t, st, inbox, note.ID) {
+		t.Errorf("redoing the add should add the note back")
+	}
+}
+
+func undoAll(t *testing.T, f *fedbox) {
+	t.Helper()
+	entries, err := f.journal.Entries()
+	if err != nil {
+		t.Fatalf("unable to load journal entries: %s", err)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := f.applyEntry(entries[i], true); err != nil {
+			t.Fatalf("unable to undo %s of %s: %s", entries[i].Op, entries[i].IRI, err)
+		}
+	}
+}
+
+func TestApplyEntry_keyRotation(t *testing.T) {
+	f, st := newTestJournal(t)
+	saver, ok := st.s.(keySaver)
+	if !ok {
+		t.Skipf("storage can't save keys")
+	}
+	actor := &pub.Actor{ID: testActor, Type: pub.PersonType, Outbox: pub.Outbox.IRI(testActor)}
+	if _, err := st.s.Create(&pub.OrderedCollection{ID: actor.Outbox.GetLink(), Type: pub.OrderedCollectionType}); err != nil {
+		t.Fatalf("unable to create outbox: %s", err)
+	}
+	prv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+	key, err := saver.SaveKey(actor.ID, prv)
+	if err != nil {
+		t.Fatalf("unable to save key: %s", err)
+	}
+	actor.PublicKey = *key
+	if _, err := st.s.Save(actor); err != nil {
+		t.Fatalf("unable to save actor: %s", err)
+	}
+
+	if err := rotateKey(f, st, actor); err != nil {
+		t.Fatalf("unable to rotate key: %s", err)
+	}
+	undoAll(t, f)
+
+	loaded, err := st.s.Load(actor.ID)
+	if err != nil {
+		t.Fatalf("unable to load actor: %s", err)
+	}
+	err = pub.OnActor(loaded, func(a *pub.Actor) error {
+		if a.PublicKey.PublicKeyPem != key.PublicKeyPem {
+			t.Errorf("undoing the rotation should restore the public key")
+		}
+		k := inspectPublicKey(a)
+		k.checkPair(st, actor.ID)
+		if !k.paired {
+			t.Errorf("undoing the rotation should restore the private key paired with the public key, got %v", k.pairError)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to read actor: %s", err)
+	}
+}
+
+func TestApplyEntry_accountLock(t *testing.T) {
+	f, st := newTestJournal(t)
+	actor := pub.IRI("https://example.com/actors/jdoe")
+	if _, err := st.s.Save(&pub.Actor{ID: actor, Type: pub.PersonType}); err != nil {
+		t.Fatalf("unable to save actor: %s", err)
+	}
+	if err := setAccountPassword(st, actor, "secret"); err != nil {
+		t.Fatalf("unable to set password: %s", err)
+	}
+	saveTestToken(t, st, "actor-token", actor)
+
+	err := f.changeMetadata(st, "account", actor, func() error {
+		_, err := lockAccount(f, st, actor)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to lock account: %s", err)
+	}
+	entries, err := f.journal.Entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("locking should record the revoked token and the metadata, got %d entries: %v", len(entries), err)
+	}
+	if entries[0].Op != journal.OpRevoke || entries[1].Op != journal.OpMetadata {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if entries[1].Instance != testRoot.String() {
+		t.Errorf("the entries should be recorded for the instance %s, got %q", testRoot, entries[1].Instance)
+	}
+
+	if err := f.applyEntry(entries[1], true); err != nil {
+		t.Fatalf("unable to undo the lock: %s", err)
+	}
+	if err := st.s.PasswordCheck(actor, []byte("secret")); err != nil {
+		t.Errorf("undoing the lock should restore the password: %s", err)
+	}
+	if actorIsLocked(f, actor) {
+		t.Errorf("undoing the lock should unlock the account")
+	}
+	if err := f.applyEntry(entries[0], true); err == nil {
+		t.Errorf("undoing the revoked token should fail")
+	}
+}
+
+func TestJournalModel_onlyOpenInstances(t *testing.T) {
+	f, _ := newTestJournal(t)
+	for _, e := range []journal.Entry{
+		{Instance: "https://example.org", Op: journal.OpSave, IRI: "https://example.org/1"},
+		{Instance: testRoot.String(), Op: journal.OpSave, IRI: "https://example.com/1"},
+		{Op: journal.OpSave, IRI: "https://example.com/2"},
+	} {
+		if _, err := f.journal.Append(e); err != nil {
+			t.Fatalf("unable to append entry: %s", err)
+		}
+	}
+	j := newJournalModel(&commonModel{f: f})
+	msg, ok := j.Init()().(journalLoadedMsg)
+	if !ok {
+		t.Fatalf("Init() should load the journal")
+	}
+	if len(msg.entries) != 2 || msg.entries[0].IRI != "https://example.com/2" || msg.entries[1].IRI != "https://example.com/1" {
+		t.Errorf("only the entries of the open stores should be listed, newest first, got %+v", msg.entries)
+	}
+}
+
+func TestApplyEntry_client(t *testing.T) {
+	f, st := newTestJournal(t)
+	c, err := createClient(f, st, "https://app.example.org/callback")
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	entries, err := f.journal.Entries()
+	if err != nil {
+		t.Fatalf("unable to load journal entries: %s", err)
+	}
+	var e journal.Entry
+	for _, entry := range entries {
+		if entry.Op == journal.OpClient {
+			e = entry
+		}
+	}
+	if len(e.Items) != 1 || e.Items[0] != c.GetId() {
+		t.Fatalf("creating the client should be recorded, got %+v", entries)
+	}
+
+	if err := f.applyEntry(e, true); err != nil {
+		t.Fatalf("unable to undo the client creation: %s", err)
+	}
+	if _, err := st.s.GetClient(c.GetId()); err == nil {
+		t.Errorf("undoing the creation should remove the client")
+	}
+	if err := f.applyEntry(e, false); err != nil {
+		t.Fatalf("unable to redo the client creation: %s", err)
+	}
+	restored, err := st.s.GetClient(c.GetId())
+	if err != nil {
+		t.Fatalf("redoing the creation should restore the client: %s", err)
+	}
+	if restored.GetSecret() != c.GetSecret() || restored.GetRedirectUri() != c.GetRedirectUri() {
+		t.Errorf("the restored client %+v doesn't match the created one %+v", restored, c)
+	}
+}
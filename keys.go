@@ -0,0 +1,336 @@
+package motley
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+)
+
+const rotatedKeySize = 2048
+
+var (
+	keysKey = key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "inspect keys of current actor"),
+	)
+	keysRotateKey = key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "rotate key"),
+	)
+)
+
+// keySaver is implemented by all the storage backends, but it's not part of storage.FullStorage.
+type keySaver interface {
+	SaveKey(vocab.IRI, crypto.PrivateKey) (*vocab.PublicKey, error)
+}
+
+// keyInfo holds the result of validating the public key of an actor,
+// and of checking it against the private key found in storage.
+type keyInfo struct {
+	id          vocab.IRI
+	owner       vocab.IRI
+	typ         string
+	size        int
+	fingerprint string
+	public      crypto.PublicKey
+
+	problems []string
+	// paired is set when the stored private key matches the public key.
+	paired    bool
+	pairError error
+}
+
+func (k keyInfo) valid() bool {
+	return len(k.problems) == 0 && k.paired
+}
+
+func keyType(pub crypto.PublicKey) (string, int) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name, k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return fmt.Sprintf("%T", pub), 0
+}
+
+// fingerprint returns the SHA256 of the DER encoding of the key, in the format used by ssh-keygen.
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// inspectPublicKey validates the publicKey property of the actor.
+func inspectPublicKey(actor *vocab.Actor) keyInfo {
+	k := keyInfo{id: actor.PublicKey.ID, owner: actor.PublicKey.Owner}
+	if actor.PublicKey.PublicKeyPem == "" {
+		k.problems = append(k.problems, "actor has no public key")
+		return k
+	}
+	if k.id == "" {
+		k.problems = append(k.problems, "public key has no id")
+	} else if !k.id.Contains(actor.ID, false) {
+		k.problems = append(k.problems, fmt.Sprintf("key id %s is not under the actor id", k.id))
+	}
+	if !k.owner.Equals(actor.ID, false) {
+		k.problems = append(k.problems, fmt.Sprintf("key owner %q doesn't match the actor id", k.owner))
+	}
+
+	block, _ := pem.Decode([]byte(actor.PublicKey.PublicKeyPem))
+	if block == nil {
+		k.problems = append(k.problems, "public key is not a valid PEM block")
+		return k
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		// NOTE(marius): some older servers publish PKCS1 encoded RSA keys.
+		rsaPub, rsaErr := x509.ParsePKCS1PublicKey(block.Bytes)
+		if rsaErr != nil {
+			k.problems = append(k.problems, fmt.Sprintf("unable to parse %s: %s", block.Type, err))
+			return k
+		}
+		pub = rsaPub
+		k.problems = append(k.problems, "public key is PKCS1 encoded, some servers expect PKIX")
+	}
+	k.public = pub
+	k.typ, k.size = keyType(pub)
+	k.fingerprint = fingerprint(block.Bytes)
+	if rsaPub, ok := pub.(*rsa.PublicKey); ok && rsaPub.N.BitLen() < 2048 {
+		k.problems = append(k.problems, fmt.Sprintf("RSA key of %d bits is too weak", rsaPub.N.BitLen()))
+	}
+	return k
+}
+
+// checkPair verifies that the private key stored for the actor corresponds to its public key.
+func (k *keyInfo) checkPair(st Store, actor vocab.IRI) {
+	prv, err := st.s.LoadKey(actor)
+	if err != nil {
+		k.pairError = errors.Annotatef(err, "unable to load private key")
+		return
+	}
+	signer, ok := prv.(crypto.Signer)
+	if !ok {
+		k.pairError = fmt.Errorf("unsupported private key type %T", prv)
+		return
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || k.public == nil {
+		k.pairError = fmt.Errorf("unable to compare the keys")
+		return
+	}
+	k.paired = pub.Equal(k.public)
+	if !k.paired {
+		typ, size := keyType(signer.Public())
+		k.pairError = fmt.Errorf("stored %s %d private key doesn't match the public key", typ, size)
+	}
+}
+
+// newKeyLike generates a key of the same type as pub, defaulting to RSA.
+func newKeyLike(pub crypto.PublicKey) (crypto.PrivateKey, error) {
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		_, prv, err := ed25519.GenerateKey(rand.Reader)
+		return prv, err
+	case *ecdsa.PublicKey:
+		return ecdsa.GenerateKey(k.Curve, rand.Reader)
+	}
+	return rsa.GenerateKey(rand.Reader, rotatedKeySize)
+}
+
+type keysLoadedMsg struct {
+	k     *KeysModel
+	actor *vocab.Actor
+	info  keyInfo
+	err   error
+}
+
+// KeysModel shows the public key of an actor, validates it and checks that it pairs with the stored private key.
+// Rotating the key generates a new key pair, saves the actor and emits an Update activity for it.
+type KeysModel struct {
+	*commonModel
+
+	iri     vocab.IRI
+	actor   *vocab.Actor
+	info    keyInfo
+	loading bool
+	err     error
+}
+
+func newKeysModel(common *commonModel, iri vocab.IRI) *KeysModel {
+	return &KeysModel{commonModel: common, iri: iri}
+}
+
+func (k *KeysModel) Init() tea.Cmd {
+	k.loading = true
+	return k.load(nil)
+}
+
+func (k *KeysModel) load(rotate func(Store, *vocab.Actor) error) tea.Cmd {
+	f := k.f
+	iri := k.iri
+	return func() tea.Msg {
+		msg := keysLoadedMsg{k: k}
+		st, err := f.storeFor(iri)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		it, err := st.s.Load(iri)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.err = vocab.OnActor(it, func(a *vocab.Actor) error {
+			if rotate != nil {
+				if err := rotate(st, a); err != nil {
+					return err
+				}
+			}
+			msg.actor = a
+			return nil
+		})
+		if msg.actor != nil {
+			msg.info = inspectPublicKey(msg.actor)
+			msg.info.checkPair(st, iri)
+		}
+		return msg
+	}
+}
+
+func (k *KeysModel) rotate() tea.Cmd {
+	f := k.f
+	return k.load(func(st Store, a *vocab.Actor) error {
+		return st.action(func() error {
+			return rotateKey(f, st, a)
+		})
+	})
+}
+
+func rotateKey(f *fedbox, st Store, a *vocab.Actor) error {
+	saver, ok := st.s.(keySaver)
+	if !ok {
+		return fmt.Errorf("storage doesn't support saving keys")
+	}
+	prv, err := newKeyLike(inspectPublicKey(a).public)
+	if err != nil {
+		return errors.Annotatef(err, "unable to generate key")
+	}
+	// NOTE(marius): the private key is saved first, as the storage builds the public key from it.
+	// If saving the actor fails, the previous private key is restored, to keep it paired with the public key.
+	previous, _ := st.s.LoadKey(a.ID)
+	var pub *vocab.PublicKey
+	err = f.changeMetadata(st, "save key", a.ID, func() error {
+		pub, err = saver.SaveKey(a.ID, prv)
+		return err
+	})
+	if err != nil {
+		return errors.Annotatef(err, "unable to save private key")
+	}
+	if pub == nil {
+		return fmt.Errorf("unable to build public key for %T", prv)
+	}
+	previousPub := a.PublicKey
+	a.PublicKey = *pub
+	if _, err = f.Save(a); err != nil {
+		a.PublicKey = previousPub
+		if rerr := restoreKey(f, st, saver, a.ID, previous); rerr != nil {
+			return errors.Annotatef(err, "unable to save actor, and the previous private key could not be restored: %s", rerr)
+		}
+		return errors.Annotatef(err, "unable to save actor")
+	}
+	update := vocab.UpdateNew("", a)
+	update.Actor = a.GetLink()
+	_, err = f.emit(st, update)
+	return err
+}
+
+func restoreKey(f *fedbox, st Store, saver keySaver, actor vocab.IRI, prv crypto.PrivateKey) error {
+	if prv == nil {
+		return fmt.Errorf("no previous private key")
+	}
+	return f.changeMetadata(st, "restore key", actor, func() error {
+		_, err := saver.SaveKey(actor, prv)
+		return err
+	})
+}
+
+func (k *KeysModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case keysLoadedMsg:
+		if mm.k != k {
+			return k, noop
+		}
+		k.loading = false
+		k.err = mm.err
+		if mm.actor != nil {
+			k.actor = mm.actor
+			k.info = mm.info
+		}
+	case tea.KeyPressMsg:
+		if key.Matches(mm, keysRotateKey) && !k.loading {
+			return k, confirmCmd("Rotate key of "+k.iri.String()+"?", func() tea.Cmd {
+				return confirmWrites(func() tea.Cmd {
+					k.loading = true
+					return tea.Batch(k.rotate(), statusMessageCmd("rotating key of %s", k.iri))
+				}, k.f.storesFor(k.iri)...)
+			})
+		}
+	}
+	return k, noop
+}
+
+func (k *KeysModel) View() tea.View {
+	okStyle := lipgloss.NewStyle().Foreground(Green)
+
+	field := func(name, value string) string {
+		return fmt.Sprintf("%s %s", fieldStyle.Render(name+":"), value)
+	}
+	pieces := []string{viewTitleStyle.Render("Keys"), field("Actor", k.iri.String())}
+	if k.err != nil {
+		pieces = append(pieces, faintRedFg.Render(k.err.Error()))
+	}
+	switch {
+	case k.loading:
+		pieces = append(pieces, "", "Loading keys"+ellipsis)
+	case k.actor != nil:
+		i := k.info
+		if i.public != nil {
+			pieces = append(pieces,
+				field("Key ID", i.id.String()),
+				field("Owner", i.owner.String()),
+				field("Type", fmt.Sprintf("%s, %d bits", i.typ, i.size)),
+				field("Fingerprint", i.fingerprint),
+			)
+		}
+		pieces = append(pieces, "")
+		for _, p := range i.problems {
+			pieces = append(pieces, faintRedFg.Render("✗ "+p))
+		}
+		switch {
+		case i.paired:
+			pieces = append(pieces, okStyle.Render("✓ stored private key matches the public key"))
+		case i.pairError != nil:
+			pieces = append(pieces, faintRedFg.Render("✗ "+i.pairError.Error()))
+		}
+		if i.valid() {
+			pieces = append(pieces, okStyle.Render("✓ key is valid"))
+		}
+	}
+	pieces = append(pieces, "", helpLine(keysRotateKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,125 @@
+package motley
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	pub "github.com/go-ap/activitypub"
+)
+
+const testActor = pub.IRI("https://example.com/actors/jdoe")
+
+func pemKey(t *testing.T, typ string, der []byte) pub.PublicKey {
+	t.Helper()
+	return pub.PublicKey{
+		ID:           testActor + "#main-key",
+		Owner:        testActor,
+		PublicKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})),
+	}
+}
+
+func hasProblem(k keyInfo, s string) bool {
+	for _, p := range k.problems {
+		if strings.Contains(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInspectPublicKey(t *testing.T) {
+	prv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(prv.Public())
+	if err != nil {
+		t.Fatalf("unable to marshal key: %s", err)
+	}
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+	weakPkix, _ := x509.MarshalPKIXPublicKey(weak.Public())
+
+	tests := []struct {
+		name    string
+		key     func() pub.PublicKey
+		size    int
+		problem string
+	}{
+		{name: "valid", key: func() pub.PublicKey { return pemKey(t, "PUBLIC KEY", pkix) }, size: 2048},
+		{name: "PKCS1", key: func() pub.PublicKey { return pemKey(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&prv.PublicKey)) }, size: 2048, problem: "PKCS1"},
+		{name: "not PEM", key: func() pub.PublicKey {
+			k := pemKey(t, "PUBLIC KEY", pkix)
+			k.PublicKeyPem = "not a key"
+			return k
+		}, problem: "not a valid PEM"},
+		{name: "owner mismatch", key: func() pub.PublicKey {
+			k := pemKey(t, "PUBLIC KEY", pkix)
+			k.Owner = "https://example.com/actors/other"
+			return k
+		}, size: 2048, problem: "doesn't match the actor id"},
+		{name: "weak", key: func() pub.PublicKey { return pemKey(t, "PUBLIC KEY", weakPkix) }, size: 1024, problem: "too weak"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := inspectPublicKey(&pub.Actor{ID: testActor, Type: pub.PersonType, PublicKey: tt.key()})
+			if k.size != tt.size {
+				t.Errorf("expected size %d, got %d", tt.size, k.size)
+			}
+			if tt.problem == "" && len(k.problems) > 0 {
+				t.Errorf("expected no problems, got %v", k.problems)
+			}
+			if tt.problem != "" && !hasProblem(k, tt.problem) {
+				t.Errorf("expected problem %q, got %v", tt.problem, k.problems)
+			}
+		})
+	}
+}
+
+func TestCheckPair(t *testing.T) {
+	f, st := newTestFedbox(t)
+	saver, ok := st.s.(keySaver)
+	if !ok {
+		t.Skipf("storage can't save keys")
+	}
+	stored, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err)
+	}
+	if _, err := saver.SaveKey(testActor, stored); err != nil {
+		t.Fatalf("unable to save key: %s", err)
+	}
+
+	k := keyInfo{public: stored.Public()}
+	k.checkPair(st, testActor)
+	if !k.paired || k.pairError != nil {
+		t.Errorf("the stored key should pair, got %v", k.pairError)
+	}
+
+	k = keyInfo{public: other.Public()}
+	k.checkPair(st, testActor)
+	if k.paired || k.pairError == nil {
+		t.Errorf("a different key should not pair")
+	}
+
+	if err := restoreKey(f, st, saver, testActor, other); err != nil {
+		t.Fatalf("unable to restore key: %s", err)
+	}
+	k.checkPair(st, testActor)
+	if !k.paired {
+		t.Errorf("the restored key should pair, got %v", k.pairError)
+	}
+	if err := restoreKey(f, st, saver, testActor, nil); err == nil {
+		t.Errorf("restoring a missing key should fail")
+	}
+}
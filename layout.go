@@ -0,0 +1,206 @@
+package motley
+
+import (
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/config"
+)
+
+const (
+	defaultSplit = 0.28
+	splitStep    = 0.04
+
+	minPagerWidth  = 32
+	minPaneHeight  = 5
+	narrowMaxWidth = 2*minTreeWidth + minPagerWidth
+)
+
+var (
+	growTreeKey = key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "grow the tree pane"),
+	)
+	shrinkTreeKey = key.NewBinding(
+		key.WithKeys("-", "_"),
+		key.WithHelp("-", "shrink the tree pane"),
+	)
+	orientationKey = key.NewBinding(
+		key.WithKeys("|"),
+		key.WithHelp("|", "switch between automatic, horizontal and vertical layouts"),
+	)
+	zoomKey = key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "maximize the focused pane"),
+	)
+)
+
+type orientation string
+
+const (
+	orientationAuto       orientation = "auto"
+	orientationHorizontal orientation = "horizontal"
+	orientationVertical   orientation = "vertical"
+)
+
+func (o orientation) next() orientation {
+	switch o {
+	case orientationAuto:
+		return orientationHorizontal
+	case orientationHorizontal:
+		return orientationVertical
+	default:
+		return orientationAuto
+	}
+}
+
+// layout holds how the tree and pager panes are arranged on the screen.
+type layout struct {
+	// Split is the fraction of the available space used by the tree pane.
+	Split       float64
+	Orientation orientation
+	Zoom        bool
+
+	// dragging is set while the divider between the panes is being dragged with the mouse.
+	dragging bool
+}
+
+// layoutFromConfig returns the layout from the configuration, falling back to the defaults for invalid values.
+func layoutFromConfig(c config.Layout) layout {
+	l := layout{Split: c.Split, Orientation: orientation(c.Orientation), Zoom: c.Zoom}
+	if l.Split <= 0 || l.Split >= 1 {
+		l.Split = defaultSplit
+	}
+	switch l.Orientation {
+	case orientationAuto, orientationHorizontal, orientationVertical:
+	default:
+		l.Orientation = orientationAuto
+	}
+	return l
+}
+
+func (l layout) save() error {
+	return config.SaveLayout(config.Layout{Split: l.Split, Orientation: string(l.Orientation), Zoom: l.Zoom})
+}
+
+// vertical returns whether the pager is placed under the tree for a screen of width w.
+func (l layout) vertical(w int) bool {
+	if l.Orientation == orientationAuto {
+		return w < narrowMaxWidth
+	}
+	return l.Orientation == orientationVertical
+}
+
+func clampSplit(s float64) float64 {
+	if s < splitStep {
+		return splitStep
+	}
+	if s > 1-splitStep {
+		return 1 - splitStep
+	}
+	return s
+}
+
+func (m *model) setLayout(l layout) tea.Cmd {
+	m.layout = l
+	m.setSize(m.width, m.height)
+	if err := m.layout.save(); err != nil {
+		return errCmd(err)
+	}
+	return noop
+}
+
+func (m *model) resizeSplit(delta float64) tea.Cmd {
+	l := m.layout
+	l.Split = clampSplit(l.Split + delta)
+	return m.setLayout(l)
+}
+
+func (m *model) switchOrientation() tea.Cmd {
+	l := m.layout
+	l.Orientation = l.Orientation.next()
+	return tea.Batch(m.setLayout(l), statusMessageCmd("%s layout", l.Orientation))
+}
+
+func (m *model) toggleZoom() tea.Cmd {
+	l := m.layout
+	l.Zoom = !l.Zoom
+	return m.setLayout(l)
+}
+
+// setPaneSizes splits the w x h space, which excludes the status bar, between the tree and the pager.
+// Every pane is rendered with a border and one column of padding on each side.
+func (m *model) setPaneSizes(w, h int) {
+	switch {
+	case m.layout.Zoom:
+		m.tree.setSize(w-4, h-2-1) // 1 for the breadcrumbs bar
+		m.pager.setSize(w-4, h-2)
+		m.pager.x, m.pager.y = 2, 1
+	case m.layout.vertical(w):
+		h = h - 2 - 2 // 2 for each pane's border
+		th := clamp(int(m.layout.Split*float64(h)), minPaneHeight, max(minPaneHeight, h-minPaneHeight))
+		m.tree.setSize(w-4, th-1)
+		m.pager.setSize(w-4, h-th)
+		// NOTE(marius): the pager content starts under the tree, its border and the pager's border.
+		m.pager.x, m.pager.y = 2, th+3
+	default:
+		w = w - 2 - 2 // 1 for padding, 1 for border
+		tw := clamp(int(m.layout.Split*float64(w)), minTreeWidth, max(minTreeWidth, w-minPagerWidth))
+		m.tree.setSize(tw-1-1, h-2-1)
+		m.pager.setSize(w-tw-1-1, h-2)
+		// NOTE(marius): the pager content starts after the tree, its border and padding, and the pager's border and padding.
+		m.pager.x, m.pager.y = tw+4, 1
+	}
+}
+
+// panesView arranges the rendered tree and pager according to the layout.
+func (m *model) panesView(tree, pager string) string {
+	switch {
+	case m.layout.Zoom && m.tree.list.Focused():
+		return tree
+	case m.layout.Zoom:
+		return pager
+	case m.layout.vertical(m.width):
+		return lipgloss.JoinVertical(lipgloss.Left, tree, pager)
+	default:
+		return lipgloss.JoinHorizontal(lipgloss.Top, tree, pager)
+	}
+}
+
+// inTree returns whether the screen position x, y is inside the tree pane.
+func (m *model) inTree(x, y int) bool {
+	switch {
+	case m.layout.Zoom:
+		return m.tree.list.Focused()
+	case m.layout.vertical(m.width):
+		return y < m.tree.height()+1+2
+	default:
+		return x < m.tree.width()+2*paneInset
+	}
+}
+
+// onDivider returns whether the screen position x, y is on the borders between the tree and the pager.
+func (m *model) onDivider(x, y int) bool {
+	switch {
+	case m.layout.Zoom:
+		return false
+	case m.layout.vertical(m.width):
+		edge := m.tree.height() + 1 + 2
+		return y == edge-1 || y == edge
+	default:
+		edge := m.tree.width() + 2*paneInset
+		return x == edge-1 || x == edge
+	}
+}
+
+// dragDivider moves the divider between the panes to the screen position x, y.
+func (m *model) dragDivider(x, y int) {
+	if m.layout.vertical(m.width) {
+		if h := m.height - m.status.Height() - m.tabsHeight() - 4; h > 0 {
+			m.layout.Split = clampSplit(float64(y-1) / float64(h))
+		}
+	} else if w := m.width - 4; w > 0 {
+		m.layout.Split = clampSplit(float64(x-2) / float64(w))
+	}
+	m.setSize(m.width, m.height)
+}
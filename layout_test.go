@@ -0,0 +1,44 @@
+package motley
+
+import (
+	"testing"
+
+	"git.sr.ht/~mariusor/motley/internal/config"
+)
+
+func TestLayoutFromConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		in   config.Layout
+		want layout
+	}{
+		{name: "defaults", in: config.Layout{}, want: layout{Split: defaultSplit, Orientation: orientationAuto}},
+		{name: "saved", in: config.Layout{Split: 0.5, Orientation: "vertical", Zoom: true}, want: layout{Split: 0.5, Orientation: orientationVertical, Zoom: true}},
+		{name: "invalid split", in: config.Layout{Split: 1.5, Orientation: "horizontal"}, want: layout{Split: defaultSplit, Orientation: orientationHorizontal}},
+		{name: "invalid orientation", in: config.Layout{Split: 0.3, Orientation: "diagonal"}, want: layout{Split: 0.3, Orientation: orientationAuto}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := layoutFromConfig(tt.in); got != tt.want {
+				t.Errorf("layoutFromConfig(%+v) = %+v, expected %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLayout_saves(t *testing.T) {
+	m, _ := newTestModel(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m.switchOrientation()
+	m.resizeSplit(splitStep)
+
+	saved, err := config.LoadLayout()
+	if err != nil {
+		t.Fatalf("unable to load layout: %s", err)
+	}
+	want := config.Layout{Split: defaultSplit + splitStep, Orientation: string(orientationHorizontal)}
+	if saved != want {
+		t.Errorf("saved layout %+v, expected %+v", saved, want)
+	}
+}
@@ -10,10 +10,12 @@ type LinkModel struct {
 	vocab.Link
 
 	Name NaturalLanguageValues
+
+	langs []vocab.LangRef
 }
 
-func newLinkModel() LinkModel {
-	return LinkModel{}
+func newLinkModel(langs []vocab.LangRef) LinkModel {
+	return LinkModel{langs: langs}
 }
 
 func (l LinkModel) Init() tea.Cmd {
@@ -33,6 +35,7 @@ func (l LinkModel) View() tea.View {
 	// TODO(marius): move this to initialization, and move the setting of the nat values into the Update()
 	if len(l.Link.Name) > 0 {
 		l.Name = nameModel(l.Link.Name)
+		l.Name.preferred = l.langs
 	}
 
 	typeStyle := lipgloss.NewStyle().Bold(true).BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
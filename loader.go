@@ -0,0 +1,285 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+	"github.com/go-ap/jsonld"
+	tree "github.com/mariusor/bubbles-tree"
+)
+
+const loadTimeout = time.Minute
+
+var cancelLoadingKey = key.NewBinding(
+	key.WithKeys("ctrl+g"),
+	key.WithHelp("ctrl+g", "cancel loading"),
+)
+
+// loader keeps track of a loading operation in flight, so it can be cancelled.
+// Every operation gets a new generation, unique between the tabs, and the results of older generations
+// are dropped as stale.
+type loader struct {
+	gen    uint64
+	cancel context.CancelFunc
+}
+
+func (l loader) busy() bool {
+	return l.cancel != nil
+}
+
+// loaders holds the loading operations in flight of a tab: the preview of the current node, and advancing the tree.
+// They are separate, so moving the cursor doesn't cancel advancing to a node.
+type loaders struct {
+	preview loader
+	advance loader
+}
+
+func (l loaders) busy() bool {
+	return l.preview.busy() || l.advance.busy()
+}
+
+func (l loaders) owns(gen uint64) bool {
+	return gen != 0 && (l.preview.gen == gen || l.advance.gen == gen)
+}
+
+// loadResultMsg is implemented by the results of the loading operations.
+type loadResultMsg interface {
+	generation() uint64
+}
+
+func (m depsLoadedMsg) generation() uint64     { return m.gen }
+func (m childrenLoadedMsg) generation() uint64 { return m.gen }
+func (m advanceLoadedMsg) generation() uint64  { return m.gen }
+func (m historyLoadedMsg) generation() uint64  { return m.gen }
+
+// nextLoadGen returns a new loading generation, unique between the tabs.
+func (m *model) nextLoadGen() uint64 {
+	m.loadGen++
+	return m.loadGen
+}
+
+type loadingMsg bool
+
+func loadingCmd(busy bool) tea.Cmd {
+	return func() tea.Msg {
+		return loadingMsg(busy)
+	}
+}
+
+// startLoading supersedes the loading operation in flight of l, and returns the context and generation for a new one.
+func (m *model) startLoading(l *loader) (context.Context, uint64) {
+	m.abort(l)
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	l.gen = m.nextLoadGen()
+	l.cancel = cancel
+	m.tree.startedLoading()
+	return ctx, l.gen
+}
+
+// finishLoading returns whether gen is the loading operation in flight of l, and marks it as done if it is.
+func (m *model) finishLoading(l *loader, gen uint64) bool {
+	if gen != l.gen || !l.busy() {
+		return false
+	}
+	l.cancel()
+	l.cancel = nil
+	if !m.loaders.busy() {
+		m.tree.stoppedLoading()
+	}
+	return true
+}
+
+func (m *model) abort(l *loader) bool {
+	if !l.busy() {
+		return false
+	}
+	l.cancel()
+	l.cancel = nil
+	l.gen = m.nextLoadGen()
+	if !m.loaders.busy() {
+		m.tree.stoppedLoading()
+	}
+	return true
+}
+
+// abortLoading cancels all the loading operations in flight of the active tab.
+func (m *model) abortLoading() bool {
+	preview := m.abort(&m.loaders.preview)
+	advance := m.abort(&m.loaders.advance)
+	return preview || advance
+}
+
+func (m *model) cancelLoading() tea.Cmd {
+	if !m.abortLoading() {
+		return noop
+	}
+	return tea.Batch(loadingCmd(false), statusMessageCmd("loading cancelled"))
+}
+
+type depsLoadedMsg struct {
+	gen      uint64
+	node     *n
+	item     vocab.Item
+	children []*n
+	// locked is set for actors with a locked account.
+	locked bool
+	err    error
+}
+
+// loadDepsCmd dereferences the properties of the node's item, and loads its children if it's a collection.
+// The loading works on a copy of the item, and the node is updated only when the results arrive, in depsLoaded.
+func (m *model) loadDepsCmd(node *n) tea.Cmd {
+	if nodeIsSynced(node) {
+		m.logFn("Node already loaded: %s", node.n)
+		return noop
+	}
+	ctx, gen := m.startLoading(&m.loaders.preview)
+	node.startedSyncing()
+
+	f := m.f
+	it := copyItem(node.Item)
+	withChildren := node.s.Is(tree.NodeCollapsible) && len(node.c) == 0
+	count := filters.WithMaxCount(m.height)
+	return tea.Batch(loadingCmd(true), func() tea.Msg {
+		msg := depsLoadedMsg{gen: gen, node: node}
+		if err := dereferenceItemProperties(ctx, f, &it); err != nil {
+			msg.err = fmt.Errorf("error while loading attributes: %w", err)
+		}
+		msg.item = it
+		// NOTE(marius): the lock is checked only when loading the actor, to avoid reading the metadata of every child.
+		if !vocab.IsNil(it) && vocab.ActorTypes.Match(it.GetType()) {
+			msg.locked = actorIsLocked(f, it.GetLink())
+		}
+		if withChildren {
+			children, err := loadChildren(ctx, f, it, count)
+			if err != nil {
+				msg.err = fmt.Errorf("error while loading children: %w", err)
+			}
+			msg.children = children
+		}
+		return msg
+	})
+}
+
+func (m *model) depsLoaded(msg depsLoadedMsg) tea.Cmd {
+	node := msg.node
+	node.stoppedSyncing()
+	if !m.finishLoading(&m.loaders.preview, msg.gen) {
+		m.logFn("Dropping stale results for node: %s", node.n)
+		return noop
+	}
+
+	node.s |= NodeSynced
+	node.Item = msg.item
+	if msg.locked {
+		node.s |= NodeLocked
+	} else {
+		node.s &^= NodeLocked
+	}
+	if msg.err != nil {
+		m.logFn("%s", msg.err)
+		node.s |= NodeError
+	}
+	if len(node.c) == 0 && len(msg.children) > 0 {
+		node.setChildren(msg.children...)
+	}
+	m.logFn("Node loaded: %s", node.n)
+
+	cmds := []tea.Cmd{loadingCmd(false)}
+	if node == m.currentNode {
+		cmds = append(cmds, nodeRefreshCmd(*node))
+	}
+	return tea.Batch(cmds...)
+}
+
+type childrenLoadedMsg struct {
+	gen      uint64
+	node     *n
+	children []*n
+	err      error
+}
+
+func (m *model) loadChildrenCmd(node *n, ff ...filters.Check) tea.Cmd {
+	if len(node.c) > 0 {
+		return noop
+	}
+	ctx, gen := m.startLoading(&m.loaders.preview)
+	f := m.f
+	it := copyItem(node.Item)
+	return tea.Batch(loadingCmd(true), func() tea.Msg {
+		children, err := loadChildren(ctx, f, it, ff...)
+		return childrenLoadedMsg{gen: gen, node: node, children: children, err: err}
+	})
+}
+
+func (m *model) childrenLoaded(msg childrenLoadedMsg) tea.Cmd {
+	if !m.finishLoading(&m.loaders.preview, msg.gen) {
+		m.logFn("Dropping stale children for node: %s", msg.node.n)
+		return noop
+	}
+	if msg.err != nil {
+		return tea.Batch(loadingCmd(false), errCmd(msg.err))
+	}
+	if len(msg.node.c) == 0 {
+		msg.node.setChildren(msg.children...)
+	}
+	return loadingCmd(false)
+}
+
+// copyItem returns a copy of the object behind it, which can be changed in the background
+// while the UI keeps reading the original.
+// NOTE(marius): dereferencing replaces the properties of the object, it never changes them in place,
+// so copying the struct is enough for the types we know. The others get a full copy through JSON.
+func copyItem(it vocab.Item) vocab.Item {
+	switch ob := it.(type) {
+	case *vocab.Object:
+		cp := *ob
+		return &cp
+	case *vocab.Actor:
+		cp := *ob
+		return &cp
+	case *vocab.Activity:
+		cp := *ob
+		return &cp
+	case *vocab.IntransitiveActivity:
+		cp := *ob
+		return &cp
+	case *vocab.Question:
+		cp := *ob
+		return &cp
+	case *vocab.Collection:
+		cp := *ob
+		return &cp
+	case *vocab.CollectionPage:
+		cp := *ob
+		return &cp
+	case *vocab.OrderedCollection:
+		cp := *ob
+		return &cp
+	case *vocab.OrderedCollectionPage:
+		cp := *ob
+		return &cp
+	case vocab.ItemCollection:
+		return slices.Clone(ob)
+	case vocab.IRI, *vocab.Link, nil:
+		return it
+	}
+	if vocab.IsNil(it) {
+		return it
+	}
+	raw, err := jsonld.WithContext(jsonld.IRI(vocab.ActivityBaseURI)).Marshal(it)
+	if err != nil {
+		return it
+	}
+	cp, err := vocab.UnmarshalJSON(raw)
+	if err != nil {
+		return it
+	}
+	return cp
+}
@@ -0,0 +1,90 @@
+package motley
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+// findMsg runs cmd, and the commands it batches, and returns the first message of type T.
+func findMsg[T tea.Msg](cmd tea.Cmd) (T, bool) {
+	var zero T
+	if cmd == nil {
+		return zero, false
+	}
+	switch msg := cmd().(type) {
+	case T:
+		return msg, true
+	case tea.BatchMsg:
+		for _, c := range msg {
+			if found, ok := findMsg[T](c); ok {
+				return found, true
+			}
+		}
+	}
+	return zero, false
+}
+
+func TestCopyItem(t *testing.T) {
+	ob := &pub.Object{ID: "https://example.com/1", Type: pub.NoteType, AttributedTo: pub.IRI("https://example.com/actor")}
+	cp, ok := copyItem(ob).(*pub.Object)
+	if !ok || cp == ob {
+		t.Fatalf("expected a copy of the object, got %T", cp)
+	}
+	cp.AttributedTo = &pub.Actor{ID: "https://example.com/actor", Type: pub.PersonType}
+	if _, ok := ob.AttributedTo.(pub.IRI); !ok {
+		t.Errorf("changing the copy should not change the original, got %T", ob.AttributedTo)
+	}
+
+	col := pub.ItemCollection{pub.IRI("https://example.com/1")}
+	cpCol := copyItem(col).(pub.ItemCollection)
+	cpCol[0] = pub.IRI("https://example.com/2")
+	if col[0] != pub.IRI("https://example.com/1") {
+		t.Errorf("changing the copy should not change the original collection, got %s", col[0])
+	}
+}
+
+func TestLoaders_previewDoesntCancelAdvance(t *testing.T) {
+	m, st := newTestModel(t)
+	_, advanceGen := m.startLoading(&m.loaders.advance)
+	_, previewGen := m.startLoading(&m.loaders.preview)
+	m.startLoading(&m.loaders.preview)
+
+	if !m.loaders.advance.busy() || m.loaders.advance.gen != advanceGen {
+		t.Fatalf("moving the cursor should not cancel advancing")
+	}
+	if m.finishLoading(&m.loaders.preview, previewGen) {
+		t.Errorf("the superseded preview should be stale")
+	}
+	newNode := node(pub.Outbox.IRI(st.root))
+	m.advanceLoaded(advanceLoadedMsg{gen: advanceGen, node: newNode, children: []*n{node(pub.IRI("https://example.com/1"))}})
+	if len(m.history.back) != 1 {
+		t.Errorf("the advance should be applied, got %d trees in history", len(m.history.back))
+	}
+	if !m.loaders.busy() {
+		t.Errorf("the preview should still be loading")
+	}
+}
+
+func TestPager_refreshKeepsOpenView(t *testing.T) {
+	m, st := newTestModel(t)
+	it := &pub.Object{ID: "https://example.com/objects/1", Type: pub.NoteType}
+	nn := node(it)
+
+	dashboard := newDashboardModel(m.commonModel)
+	m.pager.show(dashboard)
+	m.pager.updateAsModel(nodeRefreshMsg(*nn))
+	if m.pager.model != dashboard {
+		t.Errorf("refreshing the current node should not replace the open view, got %T", m.pager.model)
+	}
+
+	m.pager.updateAsModel(nodeUpdateMsg(*node(st.root)))
+	if _, ok := m.pager.model.(*InspectorModel); !ok {
+		t.Fatalf("moving to a node should show it, got %T", m.pager.model)
+	}
+	m.pager.updateAsModel(nodeRefreshMsg(*nn))
+	if !m.pager.item.GetLink().Equals(it.ID, false) {
+		t.Errorf("refreshing the current node should update its preview, got %s", m.pager.item.GetLink())
+	}
+}
@@ -0,0 +1,597 @@
+package motley
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/ansi/sixel"
+	vocab "github.com/go-ap/activitypub"
+)
+
+var (
+	mediaSaveKey = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "save media to disk"),
+	)
+	mediaGraphicsKey = key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "show image using terminal graphics"),
+	)
+	mediaFetchKey = key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "fetch remote media"),
+	)
+)
+
+const (
+	// mediaMaxSize is the largest media file we download for saving.
+	mediaMaxSize = 256 << 20
+	// mediaPreviewMaxSize is the largest remote image we download for showing a preview.
+	mediaPreviewMaxSize = 16 << 20
+	// mediaMaxProbes is how many of the media links we check for their size.
+	// Only the links served by the open stores are checked automatically, the others need the fetch key pressed.
+	mediaMaxProbes = 8
+)
+
+type graphicsProtocol string
+
+const (
+	graphicsHalfBlock graphicsProtocol = "halfblock"
+	graphicsKitty     graphicsProtocol = "kitty"
+	graphicsSixel     graphicsProtocol = "sixel"
+)
+
+// detectGraphicsProtocol tries to guess the best graphics protocol the terminal supports.
+// The guess can be overridden using the MOTLEY_GRAPHICS environment variable.
+func detectGraphicsProtocol() graphicsProtocol {
+	switch graphicsProtocol(strings.ToLower(os.Getenv("MOTLEY_GRAPHICS"))) {
+	case graphicsKitty:
+		return graphicsKitty
+	case graphicsSixel:
+		return graphicsSixel
+	case graphicsHalfBlock:
+		return graphicsHalfBlock
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", strings.Contains(term, "kitty"), os.Getenv("TERM_PROGRAM") == "WezTerm", os.Getenv("TERM_PROGRAM") == "ghostty":
+		return graphicsKitty
+	case strings.Contains(term, "foot"), strings.Contains(term, "mlterm"), strings.Contains(term, "sixel"):
+		return graphicsSixel
+	}
+	return graphicsHalfBlock
+}
+
+// mediaLink is a reference to a media file, found in the url or attachment properties of an object.
+type mediaLink struct {
+	href      vocab.IRI
+	mediaType vocab.MimeType
+	name      string
+	width     uint
+	height    uint
+	// size is the length of the remote file, when the server reports it.
+	size int64
+}
+
+func (l mediaLink) String() string {
+	s := strings.Builder{}
+	s.WriteString(l.href.String())
+	if l.mediaType != "" {
+		fmt.Fprintf(&s, " (%s)", l.mediaType)
+	}
+	if l.width > 0 && l.height > 0 {
+		fmt.Fprintf(&s, " %dx%d", l.width, l.height)
+	}
+	if l.size > 0 {
+		fmt.Fprintf(&s, " %s", humanSize(int(l.size)))
+	}
+	if l.name != "" {
+		fmt.Fprintf(&s, " %q", l.name)
+	}
+	return s.String()
+}
+
+func appendMediaLinks(links []mediaLink, it vocab.Item) []mediaLink {
+	if vocab.IsNil(it) {
+		return links
+	}
+	if vocab.IsItemCollection(it) {
+		_ = vocab.OnItemCollection(it, func(col *vocab.ItemCollection) error {
+			for _, ob := range col.Collection() {
+				links = appendMediaLinks(links, ob)
+			}
+			return nil
+		})
+		return links
+	}
+	if vocab.IsIRI(it) {
+		return append(links, mediaLink{href: it.GetLink()})
+	}
+	if vocab.LinkTypes.Match(it.GetType()) {
+		_ = vocab.OnLink(it, func(l *vocab.Link) error {
+			links = append(links, mediaLink{
+				href:      l.Href,
+				mediaType: l.MediaType,
+				name:      l.Name.First().String(),
+				width:     l.Width,
+				height:    l.Height,
+			})
+			return nil
+		})
+		return links
+	}
+	_ = vocab.OnObject(it, func(ob *vocab.Object) error {
+		l := mediaLink{href: ob.GetLink(), mediaType: ob.MediaType, name: ob.Name.First().String()}
+		if !vocab.IsNil(ob.URL) {
+			l.href = ob.URL.GetLink()
+		}
+		links = append(links, l)
+		return nil
+	})
+	return links
+}
+
+func mediaLinks(ob vocab.Object) []mediaLink {
+	links := make([]mediaLink, 0)
+	links = appendMediaLinks(links, ob.URL)
+	links = appendMediaLinks(links, ob.Attachment)
+	return links
+}
+
+// localMediaContent returns the binary content of an object stored locally,
+// either as a data URI, or as base64 encoded content with a binary media type.
+func localMediaContent(ob vocab.Object) ([]byte, vocab.MimeType, bool) {
+	raw := strings.TrimSpace(ob.Content.First().String())
+	if raw == "" {
+		return nil, "", false
+	}
+	mimeType := ob.MediaType
+	if strings.HasPrefix(raw, "data:") {
+		header, data, ok := strings.Cut(strings.TrimPrefix(raw, "data:"), ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, "", false
+		}
+		mimeType = vocab.MimeType(strings.TrimSuffix(header, ";base64"))
+		raw = data
+	}
+	if !mimeIsBinary(mimeType) {
+		return nil, "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, "", false
+	}
+	return decoded, mimeType, true
+}
+
+func humanSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// renderHalfBlocks renders the image scaled to fit in a w x h cells area, using the upper half block character,
+// with the foreground color for the upper pixel and the background color for the lower one.
+func renderHalfBlocks(img image.Image, w, h int) string {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || w <= 0 || h <= 0 {
+		return ""
+	}
+	scale := max(1, max((b.Dx()+w-1)/w, (b.Dy()+2*h-1)/(2*h)))
+	cols := b.Dx() / scale
+	rows := b.Dy() / (2 * scale)
+
+	lines := make([]string, 0, rows)
+	for y := 0; y < rows; y++ {
+		line := strings.Builder{}
+		for x := 0; x < cols; x++ {
+			top := img.At(b.Min.X+x*scale, b.Min.Y+2*y*scale)
+			bottom := img.At(b.Min.X+x*scale, b.Min.Y+(2*y+1)*scale)
+			line.WriteString(lipgloss.NewStyle().Foreground(top).Background(bottom).Render("▀"))
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isMediaItem checks if the item is one of the media types, or if it has a binary media type.
+func isMediaItem(it vocab.Item) bool {
+	if binContentTypes.Match(it.GetType()) {
+		return true
+	}
+	binary := false
+	_ = vocab.OnObject(it, func(ob *vocab.Object) error {
+		binary = mimeIsBinary(ob.MediaType)
+		return nil
+	})
+	return binary
+}
+
+// MediaModel shows the Image, Audio and Video objects, with their attached media files,
+// a preview of locally stored images, and allows saving the media to disk.
+//
+// NOTE(marius): the media on other servers is fetched only when the user asks for it,
+// as requesting the media of a reported object reveals the address of the moderator and can be abused
+// to make requests to internal services.
+type MediaModel struct {
+	ObjectModel
+
+	links    []mediaLink
+	data     []byte
+	dataType vocab.MimeType
+	img      image.Image
+	// hosts are the hosts of the open stores, the media served by them is fetched without asking.
+	hosts []string
+	// probed is set when the media links served by the open stores were checked.
+	probed bool
+
+	protocol graphicsProtocol
+	// x, y represent the position of the pager on the screen, which we need for placing graphics.
+	x, y          int
+	width, height int
+	previewRow    int
+}
+
+func newMediaModel(x, y, w, h int, langs []vocab.LangRef, hosts []string) *MediaModel {
+	return &MediaModel{ObjectModel: newObjectModel(langs), protocol: detectGraphicsProtocol(), x: x, y: y, width: w, height: h, hosts: hosts}
+}
+
+func (m *MediaModel) Init() tea.Cmd {
+	if m.probed {
+		return noop
+	}
+	links := make([]mediaLink, 0, len(m.links))
+	for _, l := range m.links {
+		if m.isLocal(l) {
+			links = append(links, l)
+		}
+	}
+	if len(links) == 0 {
+		return noop
+	}
+	return m.probe(links)
+}
+
+// mediaContent returns the media model shown by the pager content, if any.
+func mediaContent(content tea.Model) *MediaModel {
+	if i, ok := content.(*InspectorModel); ok {
+		content = i.content
+	}
+	m, _ := content.(*MediaModel)
+	return m
+}
+
+// isLocal checks if the media link is served by one of the open stores.
+func (m *MediaModel) isLocal(l mediaLink) bool {
+	u, err := l.href.URL()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(m.hosts, u.Host)
+}
+
+// reuse keeps the results of checking the media links of prev, when it shows the same object,
+// so updating the node doesn't make the requests again.
+func (m *MediaModel) reuse(prev *MediaModel) {
+	if prev == nil || prev.ID != m.ID {
+		return
+	}
+	sizes := make(map[vocab.IRI]int64, len(prev.links))
+	for _, l := range prev.links {
+		sizes[l.href] = l.size
+	}
+	for i, l := range m.links {
+		if m.links[i].size == 0 {
+			m.links[i].size = sizes[l.href]
+		}
+	}
+	if m.img == nil {
+		m.img = prev.img
+	}
+	m.probed = prev.probed
+}
+
+// place receives the position and size of the pager, which change with the layout.
+func (m *MediaModel) place(x, y, w, h int) {
+	m.x, m.y, m.width, m.height = x, y, w, h
+}
+
+func isImageLink(l mediaLink, typ vocab.Typer) bool {
+	if l.mediaType != "" {
+		return strings.HasPrefix(string(l.mediaType), "image/")
+	}
+	return typ != nil && vocab.ActivityVocabularyTypes{vocab.ImageType}.Match(typ)
+}
+
+type mediaProbedMsg struct {
+	id    vocab.IRI
+	sizes map[vocab.IRI]int64
+	img   image.Image
+}
+
+// probe asks the servers for the sizes of the media links, and downloads the first image for the preview,
+// when the object doesn't contain it locally.
+func (m *MediaModel) probe(links []mediaLink) tea.Cmd {
+	links = links[:min(len(links), mediaMaxProbes)]
+	preview := len(m.data) == 0 && m.img == nil
+	typ := m.Type
+	id := m.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		msg := mediaProbedMsg{id: id, sizes: make(map[vocab.IRI]int64, len(links))}
+		for _, l := range links {
+			if preview && msg.img == nil && isImageLink(l, typ) {
+				if data, _, err := fetchMedia(ctx, l.href, mediaPreviewMaxSize); err == nil {
+					msg.sizes[l.href] = int64(len(data))
+					if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+						msg.img = img
+					}
+					continue
+				}
+			}
+			msg.sizes[l.href] = headMediaSize(ctx, l.href)
+		}
+		return msg
+	}
+}
+
+func headMediaSize(ctx context.Context, href vocab.IRI) int64 {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, href.String(), nil)
+	if err != nil {
+		return 0
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0
+	}
+	if res.ContentLength < 0 {
+		return 0
+	}
+	return res.ContentLength
+}
+
+// fetchMedia downloads the media at href, failing if it's larger than maxSize.
+func fetchMedia(ctx context.Context, href vocab.IRI, maxSize int64) ([]byte, vocab.MimeType, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unable to download %s: %s", href, res.Status)
+	}
+	if res.ContentLength > maxSize {
+		return nil, "", fmt.Errorf("%s is too large: %s", href, humanSize(int(res.ContentLength)))
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", fmt.Errorf("%s is larger than %s", href, humanSize(int(maxSize)))
+	}
+	return data, vocab.MimeType(res.Header.Get("Content-Type")), nil
+}
+
+func (m *MediaModel) updateObject(ob *vocab.Object) error {
+	if err := m.ObjectModel.updateObject(ob); err != nil {
+		return err
+	}
+	m.links = mediaLinks(*ob)
+	m.data, m.dataType, _ = localMediaContent(*ob)
+	if len(m.data) > 0 && strings.HasPrefix(string(m.dataType), "image/") {
+		if img, _, err := image.Decode(bytes.NewReader(m.data)); err == nil {
+			m.img = img
+		}
+	}
+	return nil
+}
+
+func (m *MediaModel) previewSize() (int, int) {
+	return m.width - 2, m.height - m.previewRow - 1
+}
+
+// graphics returns the escape sequences needed to draw the image at the position of the preview,
+// using the kitty or sixel protocols.
+func (m *MediaModel) graphics() (string, error) {
+	w, h := m.previewSize()
+	buf := bytes.Buffer{}
+	switch m.protocol {
+	case graphicsKitty:
+		opts := kitty.Options{Action: kitty.TransmitAndPut, Format: kitty.PNG, Columns: w, Rows: h, Quite: 2, Chunk: true}
+		if err := kitty.EncodeGraphics(&buf, m.img, &opts); err != nil {
+			return "", err
+		}
+	case graphicsSixel:
+		payload := bytes.Buffer{}
+		if err := new(sixel.Encoder).Encode(&payload, m.img); err != nil {
+			return "", err
+		}
+		buf.WriteString(ansi.SixelGraphics(0, 1, 0, payload.Bytes()))
+	default:
+		return "", fmt.Errorf("terminal graphics are not supported")
+	}
+	pos := ansi.CursorPosition(m.x+1, m.y+m.previewRow+1)
+	return ansi.SaveCursor + pos + buf.String() + ansi.RestoreCursor, nil
+}
+
+func (m *MediaModel) defaultSavePath() string {
+	base := filepath.Base(m.ID.String())
+	mimeType := m.dataType
+	if len(m.data) == 0 && len(m.links) > 0 {
+		base = filepath.Base(m.links[0].href.String())
+		mimeType = m.links[0].mediaType
+	}
+	if filepath.Ext(base) == "" && mimeType != "" {
+		if ext, err := mime.ExtensionsByType(string(mimeType)); err == nil && len(ext) > 0 {
+			base += ext[0]
+		}
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return filepath.Join(wd, base)
+	}
+	return base
+}
+
+type mediaSavedMsg struct {
+	path string
+	size int
+}
+
+// savePrompt asks for the path where to save the media, and for a confirmation if the file exists.
+func (m *MediaModel) savePrompt() tea.Cmd {
+	return promptCmd("Save to", m.defaultSavePath(), func(path string) tea.Cmd {
+		if _, err := os.Stat(path); err == nil {
+			return confirmCmd("Overwrite "+path+"?", func() tea.Cmd {
+				return m.save(path, true)
+			})
+		}
+		return m.save(path, false)
+	})
+}
+
+// writeFile writes data to a new file at path, or replaces the existing one when overwrite is set.
+func writeFile(path string, data []byte, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (m *MediaModel) save(path string, overwrite bool) tea.Cmd {
+	data := m.data
+	var href vocab.IRI
+	if len(m.links) > 0 {
+		href = m.links[0].href
+	}
+	return func() tea.Msg {
+		if len(data) == 0 {
+			if href == "" {
+				return fmt.Errorf("no media to save")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			var err error
+			if data, _, err = fetchMedia(ctx, href, mediaMaxSize); err != nil {
+				return err
+			}
+		}
+		if err := writeFile(path, data, overwrite); err != nil {
+			return err
+		}
+		return mediaSavedMsg{path: path, size: len(data)}
+	}
+}
+
+func (m *MediaModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case mediaSavedMsg:
+		return m, statusMessageCmd("saved %s (%s)", mm.path, humanSize(mm.size))
+	case mediaProbedMsg:
+		if mm.id != m.ID {
+			return m, noop
+		}
+		m.probed = true
+		for i, l := range m.links {
+			if size, ok := mm.sizes[l.href]; ok && size > 0 {
+				m.links[i].size = size
+			}
+		}
+		if m.img == nil {
+			m.img = mm.img
+		}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(mm, mediaSaveKey):
+			return m, m.savePrompt()
+		case key.Matches(mm, mediaFetchKey):
+			if len(m.links) == 0 {
+				return m, noop
+			}
+			return m, tea.Batch(m.probe(m.links), statusMessageCmd("fetching %d media links", min(len(m.links), mediaMaxProbes)))
+		case key.Matches(mm, mediaGraphicsKey):
+			if m.img == nil {
+				return m, noop
+			}
+			seq, err := m.graphics()
+			if err != nil {
+				return m, errCmd(err)
+			}
+			return m, tea.Raw(seq)
+		case key.Matches(mm, rawToggleKey):
+			m.raw = !m.raw
+		case key.Matches(mm, languageKey):
+			m.nextLanguage()
+		}
+	}
+	return m, noop
+}
+
+func (m *MediaModel) View() tea.View {
+	pieces := []string{m.ObjectModel.View().Content}
+	labelStyle := lipgloss.NewStyle().Bold(true).Width(9).MaxWidth(9).MarginRight(1)
+	if len(m.data) > 0 {
+		pieces = append(pieces, lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render("Local"),
+			fmt.Sprintf("%s, %s", m.dataType, humanSize(len(m.data)))))
+	}
+	if len(m.links) > 0 {
+		links := make([]string, 0, len(m.links))
+		for _, l := range m.links {
+			links = append(links, l.String())
+		}
+		pieces = append(pieces, lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render("Media"), lipgloss.JoinVertical(lipgloss.Left, links...)))
+	}
+	pieces = append(pieces, helpLine(mediaSaveKey, mediaFetchKey, mediaGraphicsKey))
+	header := lipgloss.JoinVertical(lipgloss.Left, pieces...)
+	m.previewRow = lipgloss.Height(header)
+	if m.img != nil {
+		w, h := m.previewSize()
+		header = lipgloss.JoinVertical(lipgloss.Left, header, renderHalfBlocks(m.img, w, h))
+	}
+	return tea.NewView(header)
+}
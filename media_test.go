@@ -0,0 +1,181 @@
+package motley
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	vocab "github.com/go-ap/activitypub"
+)
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int]string{
+		0:           "0 B",
+		1023:        "1023 B",
+		1024:        "1.0 KiB",
+		1536:        "1.5 KiB",
+		5 * 1 << 20: "5.0 MiB",
+	}
+	for size, want := range tests {
+		if got := humanSize(size); got != want {
+			t.Errorf("humanSize(%d) = %q, expected %q", size, got, want)
+		}
+	}
+}
+
+func TestLocalMediaContent(t *testing.T) {
+	payload := []byte("\x89PNG not really")
+	encoded := base64.StdEncoding.EncodeToString(payload)
+	tests := []struct {
+		name     string
+		ob       vocab.Object
+		wantType vocab.MimeType
+		wantOk   bool
+	}{
+		{
+			name:     "data URI",
+			ob:       vocab.Object{Content: vocab.DefaultNaturalLanguage("data:image/png;base64," + encoded)},
+			wantType: "image/png",
+			wantOk:   true,
+		},
+		{
+			name:     "base64 with binary media type",
+			ob:       vocab.Object{MediaType: "image/png", Content: vocab.DefaultNaturalLanguage(encoded)},
+			wantType: "image/png",
+			wantOk:   true,
+		},
+		{
+			name: "text content",
+			ob:   vocab.Object{MediaType: "text/html", Content: vocab.DefaultNaturalLanguage("<p>hello</p>")},
+		},
+		{
+			name: "data URI without base64",
+			ob:   vocab.Object{Content: vocab.DefaultNaturalLanguage("data:image/png,abc")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, typ, ok := localMediaContent(tt.ob)
+			if ok != tt.wantOk {
+				t.Fatalf("localMediaContent() ok = %t, expected %t", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if typ != tt.wantType || string(data) != string(payload) {
+				t.Errorf("localMediaContent() = %q, %s, expected %q, %s", data, typ, payload, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestFetchMediaLimit(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/chunked" {
+			// NOTE(marius): no Content-Length, so the limit has to be enforced while reading.
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	data, typ, err := fetchMedia(ctx, vocab.IRI(srv.URL+"/file"), 100)
+	if err != nil {
+		t.Fatalf("fetchMedia() errored: %s", err)
+	}
+	if len(data) != len(body) || typ != "image/png" {
+		t.Errorf("fetchMedia() = %d bytes %s, expected %d bytes image/png", len(data), typ, len(body))
+	}
+	for _, path := range []string{"/file", "/chunked"} {
+		if _, _, err := fetchMedia(ctx, vocab.IRI(srv.URL+path), 50); err == nil {
+			t.Errorf("fetchMedia(%s) should have failed for a file over the limit", path)
+		}
+	}
+	if size := headMediaSize(ctx, vocab.IRI(srv.URL+"/file")); size != int64(len(body)) {
+		t.Errorf("headMediaSize() = %d, expected %d", size, len(body))
+	}
+}
+
+func TestWriteMediaFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "media.png")
+	if err := writeFile(path, []byte("first"), false); err != nil {
+		t.Fatalf("writeFile() errored: %s", err)
+	}
+	if err := writeFile(path, []byte("second"), false); err == nil {
+		t.Errorf("writeFile() should not overwrite an existing file")
+	}
+	if err := writeFile(path, []byte("third"), true); err != nil {
+		t.Fatalf("writeFile() with overwrite errored: %s", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "third" {
+		t.Errorf("file contains %q, expected %q", data, "third")
+	}
+}
+
+func TestMediaModel_fetchOnlyLocal(t *testing.T) {
+	requests := make(map[string]int)
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests[name]++
+			_, _ = w.Write([]byte("data"))
+		})
+	}
+	local := httptest.NewServer(handler("local"))
+	defer local.Close()
+	remote := httptest.NewServer(handler("remote"))
+	defer remote.Close()
+
+	u, _ := vocab.IRI(local.URL).URL()
+	m := newMediaModel(0, 0, 80, 24, nil, []string{u.Host})
+	ob := vocab.Object{
+		ID:   "https://example.com/objects/1",
+		Type: vocab.AudioType,
+		URL:  vocab.ItemCollection{vocab.IRI(local.URL + "/1.mp3"), vocab.IRI(remote.URL + "/2.mp3")},
+	}
+	if err := m.updateObject(&ob); err != nil {
+		t.Fatalf("unable to update the media model: %s", err)
+	}
+
+	msg, ok := m.Init()().(mediaProbedMsg)
+	if !ok {
+		t.Fatalf("Init() should check the local media links")
+	}
+	if requests["local"] != 1 || requests["remote"] != 0 {
+		t.Errorf("Init() should make requests only to the open stores, got %v", requests)
+	}
+	m.Update(msg)
+	if m.links[0].size != 4 || m.links[1].size != 0 {
+		t.Errorf("only the size of the local link should be known, got %d and %d", m.links[0].size, m.links[1].size)
+	}
+
+	rebuilt := newMediaModel(0, 0, 80, 24, nil, []string{u.Host})
+	if err := rebuilt.updateObject(&ob); err != nil {
+		t.Fatalf("unable to update the media model: %s", err)
+	}
+	rebuilt.reuse(m)
+	if cmd := rebuilt.Init(); cmd != nil {
+		t.Errorf("the rebuilt model should reuse the checked links instead of making new requests")
+	}
+	if rebuilt.links[0].size != 4 {
+		t.Errorf("the rebuilt model should keep the size of the local link, got %d", rebuilt.links[0].size)
+	}
+
+	_, cmd := m.Update(tea.KeyPressMsg{Code: 'f', Text: "f"})
+	found, ok := findMsg[mediaProbedMsg](cmd)
+	if !ok {
+		t.Fatalf("the fetch key should check the remote media links")
+	}
+	m.Update(found)
+	if requests["remote"] != 1 || m.links[1].size != 4 {
+		t.Errorf("the fetch key should request the remote link, got %v requests and size %d", requests, m.links[1].size)
+	}
+}
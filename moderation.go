@@ -0,0 +1,384 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+	"github.com/go-ap/filters"
+)
+
+const moderationMaxItems = 500
+
+var (
+	moderationKey = key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "show moderation queue"),
+	)
+	listUpKey = key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "previous entry"),
+	)
+	listDownKey = key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "next entry"),
+	)
+	moderationDismissKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "dismiss report"),
+	)
+	moderationDeleteKey = key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "delete reported content"),
+	)
+	moderationBlockKey = key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "block reported actor"),
+	)
+)
+
+// report groups a Flag activity with its loaded reporting actor and reported items.
+type report struct {
+	store    Store
+	flag     *pub.Activity
+	actor    pub.Item
+	reported pub.ItemCollection
+}
+
+// reportedActors returns the actors that are the subject of the report: either flagged directly,
+// or as authors of the flagged objects.
+func (r report) reportedActors() pub.IRIs {
+	actors := make(pub.IRIs, 0)
+	for _, it := range r.reported {
+		if pub.ActorTypes.Match(it.GetType()) {
+			if !actors.Contains(it.GetLink()) {
+				actors = append(actors, it.GetLink())
+			}
+			continue
+		}
+		_ = pub.OnObject(it, func(ob *pub.Object) error {
+			if !pub.IsNil(ob.AttributedTo) && !actors.Contains(ob.AttributedTo.GetLink()) {
+				actors = append(actors, ob.AttributedTo.GetLink())
+			}
+			return nil
+		})
+	}
+	return actors
+}
+
+// reportedObjects returns the loaded objects that were flagged, which can be deleted.
+func (r report) reportedObjects() pub.ItemCollection {
+	objects := make(pub.ItemCollection, 0)
+	for _, it := range r.reported {
+		if pub.ActorTypes.Match(it.GetType()) || pub.IsIRI(it) {
+			continue
+		}
+		objects = append(objects, it)
+	}
+	return objects
+}
+
+// loadItems dereferences the IRIs in it, which can be a single item or a collection of items.
+func loadItems(f *fedbox, it pub.Item) pub.ItemCollection {
+	items := make(pub.ItemCollection, 0)
+	if pub.IsNil(it) {
+		return items
+	}
+	if pub.IsItemCollection(it) {
+		_ = pub.OnItemCollection(it, func(col *pub.ItemCollection) error {
+			for _, ob := range col.Collection() {
+				items = append(items, loadIfIRI(f, ob))
+			}
+			return nil
+		})
+		return items
+	}
+	return append(items, loadIfIRI(f, it))
+}
+
+// itemIRIs returns the IRIs of it, which can be a single item or a collection of items.
+func itemIRIs(it pub.Item) pub.IRIs {
+	if pub.IsNil(it) {
+		return nil
+	}
+	if pub.IsItemCollection(it) {
+		var iris pub.IRIs
+		_ = pub.OnItemCollection(it, func(col *pub.ItemCollection) error {
+			iris = col.IRIs()
+			return nil
+		})
+		return iris
+	}
+	return pub.IRIs{it.GetLink()}
+}
+
+// activityObjects returns the IRIs of the objects of the activities of the received types in the iri collection.
+func activityObjects(ctx context.Context, f *fedbox, iri pub.IRI, types ...pub.ActivityVocabularyType) pub.IRIs {
+	objects := make(pub.IRIs, 0)
+	accum := func(_ context.Context, col pub.CollectionInterface) error {
+		for _, it := range col.Collection() {
+			_ = pub.OnActivity(it, func(act *pub.Activity) error {
+				objects = append(objects, itemIRIs(act.Object)...)
+				return nil
+			})
+		}
+		return nil
+	}
+	ff := []filters.Check{filters.HasType(types...), filters.WithMaxCount(moderationMaxItems)}
+	_ = accumFn(accum).LoadFromSearch(ctx, f, iri, ff...)
+	return objects
+}
+
+// resolvedFlags returns the IRIs of the Flag activities which have already been accepted or rejected by the root actor of the store.
+func resolvedFlags(ctx context.Context, f *fedbox, st Store) pub.IRIs {
+	return activityObjects(ctx, f, pub.Outbox.IRI(st.root), pub.AcceptType, pub.RejectType)
+}
+
+func loadReports(ctx context.Context, f *fedbox) ([]report, error) {
+	reports := make([]report, 0)
+	errs := make([]error, 0)
+	for _, st := range f.stores {
+		resolved := resolvedFlags(ctx, f, st)
+		accum := func(_ context.Context, col pub.CollectionInterface) error {
+			for _, it := range col.Collection() {
+				if resolved.Contains(it.GetLink()) {
+					continue
+				}
+				_ = pub.OnActivity(it, func(act *pub.Activity) error {
+					reports = append(reports, report{
+						store:    st,
+						flag:     act,
+						actor:    loadIfIRI(f, act.Actor),
+						reported: loadItems(f, act.Object),
+					})
+					return nil
+				})
+			}
+			return nil
+		}
+		ff := []filters.Check{filters.HasType(pub.FlagType), filters.WithMaxCount(moderationMaxItems)}
+		if err := accumFn(accum).LoadFromSearch(ctx, f, filters.ActivitiesType.IRI(st.root), ff...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return reports, errors.Join(errs...)
+}
+
+type reportsLoadedMsg struct {
+	reports []report
+	err     error
+}
+
+type reportResolvedMsg struct {
+	flag pub.IRI
+	err  error
+}
+
+// ModerationModel lists the unresolved Flag activities in all the stores, and allows acting on them.
+// Every decision is recorded as an Accept or Reject of the Flag by the root actor of the store,
+// with the resulting Delete or Block activity as its result.
+type ModerationModel struct {
+	*commonModel
+
+	loading bool
+	cursor  int
+	reports []report
+	err     error
+}
+
+func newModerationModel(common *commonModel) *ModerationModel {
+	return &ModerationModel{commonModel: common}
+}
+
+func (m *ModerationModel) Init() tea.Cmd {
+	if m.f == nil {
+		return noop
+	}
+	m.loading = true
+	f := m.f
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		reports, err := loadReports(ctx, f)
+		return reportsLoadedMsg{reports: reports, err: err}
+	}
+}
+
+func (m *ModerationModel) current() *report {
+	if m.cursor < 0 || m.cursor >= len(m.reports) {
+		return nil
+	}
+	return &m.reports[m.cursor]
+}
+
+// resolve records the decision on the report, after executing the sideEffect function and emitting the result activity, if any.
+// The stores, other than the one of the report, are the ones changed by sideEffect.
+func (m *ModerationModel) resolve(r report, accept bool, result *pub.Activity, sideEffect func() error, stores ...Store) tea.Cmd {
+	f := m.f
+	stores = append([]Store{r.store}, stores...)
+	return confirmWrites(func() tea.Cmd {
+		return m.resolveCmd(f, r, accept, result, sideEffect, stores)
+	}, stores...)
+}
+
+func (m *ModerationModel) resolveCmd(f *fedbox, r report, accept bool, result *pub.Activity, sideEffect func() error, stores []Store) tea.Cmd {
+	return func() tea.Msg {
+		release, err := beginActions(stores...)
+		if err != nil {
+			return reportResolvedMsg{flag: r.flag.GetLink(), err: err}
+		}
+		defer release()
+		if sideEffect != nil {
+			if err := sideEffect(); err != nil {
+				return reportResolvedMsg{flag: r.flag.GetLink(), err: err}
+			}
+		}
+		var decision *pub.Activity
+		if accept {
+			decision = pub.AcceptNew("", r.flag.GetLink())
+		} else {
+			decision = pub.RejectNew("", r.flag.GetLink())
+		}
+		if result != nil {
+			var err error
+			if result, err = f.emit(r.store, result); err != nil {
+				return reportResolvedMsg{flag: r.flag.GetLink(), err: err}
+			}
+			decision.Result = result.GetLink()
+		}
+		_, err = f.emit(r.store, decision)
+		return reportResolvedMsg{flag: r.flag.GetLink(), err: err}
+	}
+}
+
+func (m *ModerationModel) dismiss(r report) tea.Cmd {
+	return m.resolve(r, false, nil, nil)
+}
+
+func (m *ModerationModel) deleteReported(r report) tea.Cmd {
+	objects := r.reportedObjects()
+	if len(objects) == 0 {
+		return errCmd(fmt.Errorf("no reported objects to delete"))
+	}
+	f := m.f
+	deleteFn := func() error {
+		for _, it := range objects {
+			if err := f.tombstone(it); err != nil {
+				return errors.Annotatef(err, "unable to delete %s", it.GetLink())
+			}
+		}
+		return nil
+	}
+	return m.resolve(r, true, pub.DeleteNew("", objects.IRIs()), deleteFn, f.storesFor(objects.IRIs()...)...)
+}
+
+func (m *ModerationModel) blockReported(r report) tea.Cmd {
+	actors := r.reportedActors()
+	if len(actors) == 0 {
+		return errCmd(fmt.Errorf("no reported actors to block"))
+	}
+	f := m.f
+	blockFn := func() error {
+		blocked := filters.BlockedType.IRI(r.store.root)
+		for _, act := range actors {
+			if err := f.AddTo(blocked, act); err != nil {
+				return errors.Annotatef(err, "unable to block %s", act)
+			}
+		}
+		return nil
+	}
+	return m.resolve(r, true, pub.BlockNew("", actors), blockFn)
+}
+
+func (m *ModerationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case reportsLoadedMsg:
+		m.loading = false
+		m.reports = mm.reports
+		m.err = mm.err
+		m.cursor = clamp(m.cursor, 0, max(0, len(m.reports)-1))
+	case reportResolvedMsg:
+		if mm.err != nil {
+			return m, errCmd(mm.err)
+		}
+		return m, m.Init()
+	case tea.KeyPressMsg:
+		r := m.current()
+		switch {
+		case key.Matches(mm, listUpKey):
+			m.cursor = clamp(m.cursor-1, 0, max(0, len(m.reports)-1))
+		case key.Matches(mm, listDownKey):
+			m.cursor = clamp(m.cursor+1, 0, max(0, len(m.reports)-1))
+		case key.Matches(mm, moderationDismissKey):
+			if r != nil {
+				return m, confirmCmd("Dismiss report "+r.flag.GetLink().String()+"?", func() tea.Cmd {
+					return m.dismiss(*r)
+				})
+			}
+		case key.Matches(mm, moderationDeleteKey):
+			if r != nil {
+				return m, confirmCmd(fmt.Sprintf("Delete %d reported objects?", len(r.reportedObjects())), func() tea.Cmd {
+					return m.deleteReported(*r)
+				})
+			}
+		case key.Matches(mm, moderationBlockKey):
+			if r != nil {
+				return m, confirmCmd(fmt.Sprintf("Block %d reported actors?", len(r.reportedActors())), func() tea.Cmd {
+					return m.blockReported(*r)
+				})
+			}
+		}
+	}
+	return m, noop
+}
+
+func (r report) View(selected bool) string {
+	reporter := "unknown actor"
+	if !pub.IsNil(r.actor) {
+		reporter = getNameFromItem(r.actor)
+	}
+	header := fmt.Sprintf("%s %s reported %d item(s)", r.flag.Published.Local().Format("2006-01-02 15:04"), reporter, len(r.reported))
+	if selected {
+		header = hintFg.Render(header)
+	}
+	lines := []string{header}
+	if reason := r.flag.Content.First().String(); reason != "" {
+		lines = append(lines, "  "+reason)
+	}
+	for _, it := range r.reported {
+		line := fmt.Sprintf("  • %s %s", getNameFromItem(it), it.GetLink())
+		_ = pub.OnObject(it, func(ob *pub.Object) error {
+			if content := ob.Content.First().String(); content != "" {
+				line += "\n    " + strings.ReplaceAll(content, "\n", " ")
+			}
+			return nil
+		})
+		lines = append(lines, line)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (m *ModerationModel) View() tea.View {
+	pieces := []string{viewTitleStyle.Render("Moderation queue")}
+	if m.err != nil {
+		pieces = append(pieces, faintRedFg.Render(m.err.Error()))
+	}
+	switch {
+	case m.loading:
+		pieces = append(pieces, "Loading reports"+ellipsis)
+	case len(m.reports) == 0:
+		pieces = append(pieces, "No pending reports")
+	default:
+		for i, r := range m.reports {
+			pieces = append(pieces, r.View(i == m.cursor), "")
+		}
+	}
+	pieces = append(pieces, helpLine(moderationDismissKey, moderationDeleteKey, moderationBlockKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,98 @@
+package motley
+
+import (
+	"context"
+	"testing"
+
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+)
+
+// newTestReport saves a Note by a spammer actor, and a Flag of the Note, and returns the loaded report.
+func newTestReport(t *testing.T) (*ModerationModel, Store, report) {
+	t.Helper()
+	f, st := newTestFedbox(t)
+	spammer := &pub.Actor{ID: filters.ActorsType.IRI(testRoot).AddPath("spammer"), Type: pub.PersonType}
+	note := &pub.Object{ID: filters.ObjectsType.IRI(testRoot).AddPath("spam"), Type: pub.NoteType, AttributedTo: spammer.ID}
+	flag := &pub.Activity{ID: filters.ActivitiesType.IRI(testRoot).AddPath("flag"), Type: pub.FlagType, Actor: testRoot, Object: note.ID}
+	for _, col := range []pub.IRI{filters.ActivitiesType.IRI(testRoot), filters.BlockedType.IRI(testRoot)} {
+		if _, err := st.s.Create(&pub.OrderedCollection{ID: col, Type: pub.OrderedCollectionType}); err != nil {
+			t.Fatalf("unable to create collection %s: %s", col, err)
+		}
+	}
+	for _, it := range []pub.Item{spammer, note, flag} {
+		if _, err := st.s.Save(it); err != nil {
+			t.Fatalf("unable to save %s: %s", it.GetLink(), err)
+		}
+	}
+	if err := st.s.AddTo(filters.ActivitiesType.IRI(testRoot), flag); err != nil {
+		t.Fatalf("unable to add the flag to the activities: %s", err)
+	}
+
+	reports, err := loadReports(context.Background(), f)
+	if err != nil {
+		t.Fatalf("unable to load reports: %s", err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+	return newModerationModel(&commonModel{f: f}), st, reports[0]
+}
+
+func pendingReports(t *testing.T, m *ModerationModel) int {
+	t.Helper()
+	reports, err := loadReports(context.Background(), m.f)
+	if err != nil {
+		t.Fatalf("unable to load reports: %s", err)
+	}
+	return len(reports)
+}
+
+func TestReport_reported(t *testing.T) {
+	_, _, r := newTestReport(t)
+	if objects := r.reportedObjects(); len(objects) != 1 || objects[0].GetType() != pub.NoteType {
+		t.Errorf("expected the flagged Note to be reported, got %v", objects)
+	}
+	if actors := r.reportedActors(); len(actors) != 1 || actors[0] != filters.ActorsType.IRI(testRoot).AddPath("spammer") {
+		t.Errorf("expected the author of the Note to be reported, got %v", actors)
+	}
+}
+
+func TestModeration_dismiss(t *testing.T) {
+	m, _, r := newTestReport(t)
+	msg, ok := findMsg[reportResolvedMsg](m.dismiss(r))
+	if !ok || msg.err != nil {
+		t.Fatalf("unable to dismiss the report: %v", msg.err)
+	}
+	if n := pendingReports(t, m); n != 0 {
+		t.Errorf("the dismissed report should be resolved, got %d pending", n)
+	}
+}
+
+func TestModeration_deleteReported(t *testing.T) {
+	m, st, r := newTestReport(t)
+	msg, ok := findMsg[reportResolvedMsg](m.deleteReported(r))
+	if !ok || msg.err != nil {
+		t.Fatalf("unable to delete the reported objects: %v", msg.err)
+	}
+	if it, err := st.s.Load(r.reportedObjects()[0].GetLink()); err != nil || it.GetType() != pub.TombstoneType {
+		t.Errorf("the reported Note should be replaced by a Tombstone, got %v", it)
+	}
+	if n := pendingReports(t, m); n != 0 {
+		t.Errorf("the report should be resolved, got %d pending", n)
+	}
+}
+
+func TestModeration_blockReported(t *testing.T) {
+	m, st, r := newTestReport(t)
+	msg, ok := findMsg[reportResolvedMsg](m.blockReported(r))
+	if !ok || msg.err != nil {
+		t.Fatalf("unable to block the reported actors: %v", msg.err)
+	}
+	if !inCollection(t, st, filters.BlockedType.IRI(testRoot), r.reportedActors()[0]) {
+		t.Errorf("the author of the Note should be blocked")
+	}
+	if n := pendingReports(t, m); n != 0 {
+		t.Errorf("the report should be resolved, got %d pending", n)
+	}
+}
@@ -0,0 +1,128 @@
+package motley
+
+import (
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	tree "github.com/mariusor/bubbles-tree"
+)
+
+// NOTE(marius): the panes are rendered with a border and one column of padding,
+// and the tree starts after the border and the breadcrumbs bar.
+const (
+	paneInset  = 2
+	crumbsRow  = 1
+	treeTopRow = 2
+)
+
+// visibleRows returns the nodes in the order they are shown in the tree, one per row.
+func visibleRows(nodes tree.Nodes) []*n {
+	rows := make([]*n, 0)
+	for _, nn := range nodes {
+		if nn == nil || nn.State().Is(tree.NodeHidden) {
+			continue
+		}
+		if node, ok := nn.(*n); ok {
+			rows = append(rows, node)
+		}
+		if nn.State().Is(tree.NodeCollapsible) && !nn.State().Is(tree.NodeCollapsed) {
+			rows = append(rows, visibleRows(nn.Children())...)
+		}
+	}
+	return rows
+}
+
+// onGlyph returns whether column x of the rendered tree row line is on the expand/collapse glyph.
+func onGlyph(line string, x int) bool {
+	line = ansi.Strip(line)
+	for _, glyph := range []string{Collapsed, Expanded} {
+		if i := strings.Index(line, glyph); i >= 0 {
+			col := ansi.StringWidth(line[:i])
+			return x >= col && x < col+ansi.StringWidth(glyph)
+		}
+	}
+	return false
+}
+
+func (m *model) handleMouseClick(mm tea.MouseClickMsg) tea.Cmd {
+	if mm.Button != tea.MouseLeft || m.prompt != nil || m.popup != nil {
+		return noop
+	}
+	if m.tabsHeight() > 0 && mm.Y == 0 {
+		if idx, ok := m.tabAt(mm.X); ok {
+			return m.switchTab(idx)
+		}
+		return noop
+	}
+	// NOTE(marius): the panes are positioned under the tab bar.
+	mm.Y -= m.tabsHeight()
+	if m.onDivider(mm.X, mm.Y) {
+		m.layout.dragging = true
+		return noop
+	}
+	if !m.inTree(mm.X, mm.Y) {
+		if m.tree.list.Focused() {
+			m.focusPager()
+		}
+		return noop
+	}
+	x := mm.X - paneInset
+	if mm.Y == crumbsRow {
+		if idx, ok := m.crumbAt(x); ok {
+			return m.JumpTo(idx)
+		}
+		return noop
+	}
+	row := mm.Y - treeTopRow
+	if row < 0 || row >= m.tree.height() {
+		return noop
+	}
+	pos := m.tree.list.YOffset() + row
+	if pos >= len(visibleRows(m.tree.list.Children())) {
+		return noop
+	}
+	if !m.tree.list.Focused() {
+		// NOTE(marius): we don't use focusTree, as it would move the cursor to the previous position first.
+		m.pager.Blur()
+		m.tree.list.Focus()
+	}
+	cmd := m.tree.list.SetCursor(pos)
+	if lines := strings.Split(m.tree.View().Content, "\n"); row < len(lines) && onGlyph(lines[row], x) {
+		m.tree.list.ToggleExpand()
+	}
+	return cmd
+}
+
+func (m *model) handleMouseMotion(mm tea.MouseMotionMsg) tea.Cmd {
+	if !m.layout.dragging || mm.Button != tea.MouseLeft {
+		return noop
+	}
+	m.dragDivider(mm.X, mm.Y-m.tabsHeight())
+	return noop
+}
+
+func (m *model) handleMouseRelease(mm tea.MouseReleaseMsg) tea.Cmd {
+	if !m.layout.dragging {
+		return noop
+	}
+	m.layout.dragging = false
+	m.dragDivider(mm.X, mm.Y-m.tabsHeight())
+	return m.setLayout(m.layout)
+}
+
+func (m *model) handleMouseWheel(mm tea.MouseWheelMsg) tea.Cmd {
+	if m.prompt != nil || m.popup != nil {
+		return noop
+	}
+	if m.tree.list.Focused() {
+		switch mm.Button {
+		case tea.MouseWheelUp:
+			return m.tree.list.MoveUp(1)
+		case tea.MouseWheelDown:
+			return m.tree.list.MoveDown(1)
+		}
+		return noop
+	}
+	return m.pager.scroll(mm)
+}
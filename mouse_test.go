@@ -0,0 +1,53 @@
+package motley
+
+import (
+	"fmt"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+func newTestInspector(props int) *InspectorModel {
+	i := newInspectorModel(M)
+	for idx := 0; idx < props; idx++ {
+		iri := pub.IRI(fmt.Sprintf("https://example.com/%d", idx))
+		i.add(fmt.Sprintf("prop%d", idx), iri.String(), iri)
+	}
+	return i
+}
+
+func TestMouseWheel_scrollsPager(t *testing.T) {
+	m, _ := newTestModel(t)
+	inspector := newTestInspector(100)
+	m.pager.show(inspector)
+	m.focusPager()
+
+	m.update(tea.MouseWheelMsg{Button: tea.MouseWheelDown})
+	if m.pager.viewport.YOffset() == 0 {
+		t.Errorf("the wheel should scroll the pager")
+	}
+	if inspector.cursor != -1 {
+		t.Errorf("the wheel should not move the inspector cursor, it is at %d", inspector.cursor)
+	}
+
+	m.update(tea.MouseWheelMsg{Button: tea.MouseWheelUp})
+	if m.pager.viewport.YOffset() != 0 {
+		t.Errorf("the wheel should scroll the pager back up, the offset is %d", m.pager.viewport.YOffset())
+	}
+}
+
+func TestPager_followsCursor(t *testing.T) {
+	m, _ := newTestModel(t)
+	inspector := newTestInspector(100)
+	m.pager.show(inspector)
+	m.focusPager()
+
+	for range 80 {
+		m.update(tea.KeyPressMsg{Code: tea.KeyDown})
+	}
+	line, top, h := inspector.cursorLine(), m.pager.viewport.YOffset(), m.pager.viewport.Height()
+	if line < top || line >= top+h {
+		t.Errorf("the cursor line %d should be in view, between %d and %d", line, top, top+h)
+	}
+}
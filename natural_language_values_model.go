@@ -1,6 +1,10 @@
 package motley
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -17,20 +21,38 @@ type NaturalLanguageValues struct {
 
 	view        viewport.Model
 	selectedRef vocab.LangRef
+	// selected marks that selectedRef was chosen explicitly, and it takes precedence over the preferred languages.
+	selected bool
+	// mediaType is the format of the values, which gets rendered to terminal text unless raw is set.
+	mediaType vocab.MimeType
+	raw       bool
+	// preferred holds the languages which are shown first, when the value has multiple translations.
+	preferred []vocab.LangRef
 }
 
 func nameModel(val vocab.NaturalLanguageValues) NaturalLanguageValues {
 	m := NewNaturalLanguageValues("Name", val)
+	m.mediaType = mimeTypePlain
 	m.view.SetHeight(1)
 	return m
 }
 
 func summaryModel(val vocab.NaturalLanguageValues) NaturalLanguageValues {
-	return NewNaturalLanguageValues("Summary", val)
+	m := NewNaturalLanguageValues("Summary", val)
+	m.mediaType = mimeTypeHTML
+	return m
+}
+
+func contentModel(val vocab.NaturalLanguageValues, mediaType vocab.MimeType) NaturalLanguageValues {
+	m := NewNaturalLanguageValues("Content", val)
+	m.mediaType = mediaType
+	return m
 }
 
-func contentModel(val vocab.NaturalLanguageValues) NaturalLanguageValues {
-	return NewNaturalLanguageValues("Content", val)
+func sourceModel(source vocab.Source) NaturalLanguageValues {
+	m := NewNaturalLanguageValues("Source", source.Content)
+	m.mediaType = source.MediaType
+	return m
 }
 
 func NewNaturalLanguageValues(label string, val vocab.NaturalLanguageValues) NaturalLanguageValues {
@@ -51,23 +73,92 @@ func (n NaturalLanguageValues) Update(msg tea.Msg) tea.Cmd {
 	return noop
 }
 
+// preferredLangRefs converts the language codes from the configuration, skipping the invalid ones.
+func preferredLangRefs(langs []string) []vocab.LangRef {
+	refs := make([]vocab.LangRef, 0, len(langs))
+	for _, l := range langs {
+		if ref := vocab.MakeRef([]byte(l)); ref.Valid() {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// langRefs returns the languages which have values, sorted.
+func langRefs(nlv vocab.NaturalLanguageValues) []vocab.LangRef {
+	refs := make([]vocab.LangRef, 0, len(nlv))
+	for k := range nlv {
+		refs = append(refs, k)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].String() < refs[j].String()
+	})
+	return refs
+}
+
+// defaultLangRef returns the first of the preferred languages that has a value, falling back to the value
+// without a language, and finally to the first language available.
+func defaultLangRef(nlv vocab.NaturalLanguageValues, preferred []vocab.LangRef) vocab.LangRef {
+	for _, ref := range preferred {
+		if _, ok := nlv[ref]; ok {
+			return ref
+		}
+	}
+	if _, ok := nlv[vocab.NilLangRef]; ok {
+		return vocab.NilLangRef
+	}
+	if refs := langRefs(nlv); len(refs) > 0 {
+		return refs[0]
+	}
+	return vocab.NilLangRef
+}
+
+func (n NaturalLanguageValues) refs() []vocab.LangRef {
+	return langRefs(n.NaturalLanguageValues)
+}
+
+// currentRef returns the language of the value that gets shown: the selected one if it has a value,
+// otherwise the default one.
+func (n NaturalLanguageValues) currentRef() vocab.LangRef {
+	if _, ok := n.NaturalLanguageValues[n.selectedRef]; ok && n.selected {
+		return n.selectedRef
+	}
+	return defaultLangRef(n.NaturalLanguageValues, n.preferred)
+}
+
+func (n *NaturalLanguageValues) selectLanguage(ref vocab.LangRef) {
+	n.selectedRef = ref
+	n.selected = true
+}
+
 func (n NaturalLanguageValues) renderLabel() string {
 	labelStyle := lipgloss.NewStyle().Bold(true).Width(9).MaxWidth(9).MarginRight(1)
-	return lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render(n.Label))
+	label := labelStyle.Render(n.Label)
+	if ref := n.currentRef(); ref.Valid() || len(n.NaturalLanguageValues) > 1 {
+		lang := ref.String()
+		if !ref.Valid() {
+			lang = "-"
+		}
+		if len(n.NaturalLanguageValues) > 1 {
+			lang = fmt.Sprintf("%s %d/%d", lang, slices.Index(n.refs(), ref)+1, len(n.NaturalLanguageValues))
+		}
+		label = lipgloss.JoinVertical(lipgloss.Left, label, labelStyle.Bold(false).Faint(true).Render(lang))
+	}
+	return label
 }
 
 func (n NaturalLanguageValues) renderContent() string {
 	contentStyle := lipgloss.NewStyle().MaxHeight(n.view.Height()).MaxWidth(n.view.Width())
 
-	if n.selectedRef == vocab.NilLangRef {
-		n.selectedRef = vocab.Und
-	}
+	current := n.currentRef()
 	for k, nlv := range n.NaturalLanguageValues {
-		if k != n.selectedRef {
+		if k != current {
 			continue
 		}
-		wrapped := wordwrap.String(nlv.String(), n.view.Width()-2)
-		return contentStyle.Render(wrapped)
+		if n.raw {
+			return contentStyle.Render(wordwrap.String(nlv.String(), n.view.Width()-2))
+		}
+		return contentStyle.Render(renderRichText(nlv.String(), n.mediaType, n.view.Width()-2))
 	}
 	return ""
 }
@@ -0,0 +1,73 @@
+package motley
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	vocab "github.com/go-ap/activitypub"
+)
+
+func TestNaturalLanguageValues_currentRef(t *testing.T) {
+	en, fr, ro := vocab.MakeRef([]byte("en")), vocab.MakeRef([]byte("fr")), vocab.MakeRef([]byte("ro"))
+	values := vocab.NaturalLanguageValues{
+		fr: vocab.Content("bonjour"),
+		ro: vocab.Content("salut"),
+	}
+	tests := []struct {
+		name      string
+		nlv       vocab.NaturalLanguageValues
+		preferred []string
+		selected  vocab.LangRef
+		want      vocab.LangRef
+	}{
+		{name: "first available", nlv: values, want: fr},
+		{name: "preferred", nlv: values, preferred: []string{"en", "ro"}, want: ro},
+		{name: "invalid preferences are skipped", nlv: values, preferred: []string{"", "ro"}, want: ro},
+		{name: "without language", nlv: vocab.NaturalLanguageValues{vocab.NilLangRef: vocab.Content("hi"), fr: vocab.Content("bonjour")}, want: vocab.NilLangRef},
+		{name: "selected", nlv: values, preferred: []string{"ro"}, selected: fr, want: fr},
+		{name: "selected without value", nlv: values, preferred: []string{"ro"}, selected: en, want: ro},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := contentModel(tt.nlv, mimeTypePlain)
+			n.preferred = preferredLangRefs(tt.preferred)
+			if tt.selected.Valid() {
+				n.selectLanguage(tt.selected)
+			}
+			if got := n.currentRef(); got != tt.want {
+				t.Errorf("currentRef() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectModel_nextLanguage(t *testing.T) {
+	en, fr := vocab.MakeRef([]byte("en")), vocab.MakeRef([]byte("fr"))
+	o := newObjectModel([]vocab.LangRef{fr})
+	o.Object.Name = vocab.NaturalLanguageValues{en: vocab.Content("hello")}
+	o.Object.Content = vocab.NaturalLanguageValues{en: vocab.Content("hello"), fr: vocab.Content("bonjour")}
+
+	if got := o.languages(); len(got) != 2 || got[0] != en || got[1] != fr {
+		t.Fatalf("languages() = %v, expected [en fr]", got)
+	}
+	o.nextLanguage()
+	if o.lang != en {
+		t.Errorf("after the preferred fr, the next language is %q, expected en", o.lang)
+	}
+	o.nextLanguage()
+	if o.lang != fr {
+		t.Errorf("the languages should cycle back to fr, got %q", o.lang)
+	}
+}
+
+func TestActivityModel_nextLanguage(t *testing.T) {
+	en, fr := vocab.MakeRef([]byte("en")), vocab.MakeRef([]byte("fr"))
+	a := newActivityModel([]vocab.LangRef{fr})
+	note := &vocab.Object{ID: "https://example.com/1", Type: vocab.NoteType, Content: vocab.NaturalLanguageValues{en: vocab.Content("hello"), fr: vocab.Content("bonjour")}}
+	_ = a.updateActivity(&vocab.Activity{ID: "https://example.com/2", Type: vocab.CreateType, Object: note})
+
+	m, _ := a.Update(tea.KeyPressMsg{Code: 'l', Text: "l"})
+	if got := m.(ActivityModel).Object.lang; got != en {
+		t.Errorf("switching the language should switch the object to %q, got %q", en, got)
+	}
+}
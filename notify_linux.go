@@ -0,0 +1,62 @@
+package motley
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_DELETE
+
+// dirNotifier reports changes in the watched directories using inotify.
+type dirNotifier struct {
+	fd      int
+	watches map[string]int
+	buf     []byte
+}
+
+func newDirNotifier() (*dirNotifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	return &dirNotifier{fd: fd, watches: make(map[string]int), buf: make([]byte, 64*unix.SizeofInotifyEvent)}, nil
+}
+
+func (d *dirNotifier) add(dir string) error {
+	if _, ok := d.watches[dir]; ok {
+		return nil
+	}
+	wd, err := unix.InotifyAddWatch(d.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	d.watches[dir] = wd
+	return nil
+}
+
+// changed drains the pending events, and returns the directories in which they happened.
+func (d *dirNotifier) changed() map[string]struct{} {
+	dirs := make(map[int]string, len(d.watches))
+	for dir, wd := range d.watches {
+		dirs[wd] = dir
+	}
+	changed := make(map[string]struct{})
+	for {
+		n, err := unix.Read(d.fd, d.buf)
+		if err != nil || n < unix.SizeofInotifyEvent {
+			return changed
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&d.buf[off]))
+			if dir, ok := dirs[int(ev.Wd)]; ok {
+				changed[dir] = struct{}{}
+			}
+			off += unix.SizeofInotifyEvent + int(ev.Len)
+		}
+	}
+}
+
+func (d *dirNotifier) Close() error {
+	return unix.Close(d.fd)
+}
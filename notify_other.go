@@ -0,0 +1,24 @@
+//go:build !linux
+
+package motley
+
+import "github.com/go-ap/errors"
+
+// dirNotifier is available only on Linux, elsewhere the storage is polled for changes.
+type dirNotifier struct{}
+
+func newDirNotifier() (*dirNotifier, error) {
+	return nil, errors.NotImplementedf("watching directories for changes")
+}
+
+func (d *dirNotifier) add(string) error {
+	return nil
+}
+
+func (d *dirNotifier) changed() map[string]struct{} {
+	return nil
+}
+
+func (d *dirNotifier) Close() error {
+	return nil
+}
@@ -0,0 +1,510 @@
+package motley
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+	"github.com/go-ap/filters"
+	"github.com/google/uuid"
+	"github.com/openshift/osin"
+)
+
+const oauthTimeFmt = "2006-01-02 15:04"
+
+var (
+	oauthKey = key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "show OAuth2 clients and tokens"),
+	)
+	oauthAddKey = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "create client"),
+	)
+	oauthRemoveKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "revoke token/delete client"),
+	)
+	oauthRevokeKey = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "revoke token by value"),
+	)
+)
+
+// oauthEntry is a client, an authorization code or an access token found in a store.
+// Only one of client, auth and access is set.
+type oauthEntry struct {
+	store  Store
+	client osin.Client
+	auth   *osin.AuthorizeData
+	access *osin.AccessData
+	// bucket and code identify the authorization or the access token, when the entry couldn't be loaded.
+	bucket string
+	code   string
+	// legacy is the path of the legacy OAuth2 database the entry was read from.
+	legacy string
+	err    error
+}
+
+func (e oauthEntry) clientID() string {
+	switch {
+	case e.client != nil:
+		return e.client.GetId()
+	case e.auth != nil && e.auth.Client != nil:
+		return e.auth.Client.GetId()
+	case e.access != nil && e.access.Client != nil:
+		return e.access.Client.GetId()
+	}
+	return ""
+}
+
+// oauthStore holds the OAuth2 data of a store.
+type oauthStore struct {
+	store   Store
+	entries []oauthEntry
+	// listable is set when the tokens of the storage can be enumerated.
+	listable bool
+	// legacy holds the paths of separate OAuth2 databases, used by older FedBOX versions, which exist on disk.
+	legacy []string
+	errs   []error
+}
+
+// oauthDir returns the directory where the fs storage keeps its OAuth2 data.
+func oauthDir(st Store) string {
+	if st.conf.Type != config.StorageFS {
+		return ""
+	}
+	base, err := st.conf.BaseStoragePath(st.env)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "oauth")
+}
+
+// legacyOAuthPaths returns the separate OAuth2 databases of the store, which the current storage doesn't use anymore.
+func legacyOAuthPaths(st Store) []string {
+	var p string
+	switch st.conf.Type {
+	case config.StorageBoltDB:
+		p, _ = st.conf.BoltDBOAuth2(st.env)
+	case config.StorageBadger:
+		p, _ = st.conf.BadgerOAuth2(st.env)
+	}
+	if p == "" {
+		return nil
+	}
+	if _, err := os.Stat(p); err != nil {
+		return nil
+	}
+	return []string{p}
+}
+
+// listOAuthDir returns the names of the entries in the sub directory of the fs storage OAuth2 folder.
+func listOAuthDir(st Store, bucket string) []string {
+	dir := oauthDir(st)
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, bucket))
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+func loadOAuthStore(st Store) oauthStore {
+	o := oauthStore{store: st, legacy: legacyOAuthPaths(st), listable: oauthListable(st)}
+	clients, err := st.s.ListClients()
+	if err != nil {
+		o.errs = append(o.errs, errors.Annotatef(err, "unable to list clients"))
+	}
+	for _, c := range clients {
+		o.entries = append(o.entries, oauthEntry{store: st, client: c})
+	}
+	codes, err := oauthCodes(st)
+	if err != nil {
+		o.errs = append(o.errs, errors.Annotatef(err, "unable to list tokens"))
+	}
+	for _, code := range codes["authorize"] {
+		auth, err := st.s.LoadAuthorize(code)
+		o.entries = append(o.entries, oauthEntry{store: st, auth: auth, bucket: "authorize", code: code, err: err})
+	}
+	for _, token := range codes["access"] {
+		access, err := st.s.LoadAccess(token)
+		o.entries = append(o.entries, oauthEntry{store: st, access: access, bucket: "access", code: token, err: err})
+	}
+	for _, p := range o.legacy {
+		entries, err := loadLegacyOAuth(st, p)
+		if err != nil {
+			o.errs = append(o.errs, err)
+		}
+		o.entries = append(o.entries, entries...)
+	}
+	return o
+}
+
+func randomSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// createClient creates an Application actor and an OAuth2 client linked to it, the way FedBOX's ctl does.
+func createClient(f *fedbox, st Store, redirect string) (osin.Client, error) {
+	u, err := url.Parse(strings.TrimSpace(redirect))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid redirect URI %q", redirect)
+	}
+	id := uuid.NewString()
+	app := pub.Actor{
+		ID:                filters.ActorsType.IRI(st.root).AddPath(id),
+		Type:              pub.ApplicationType,
+		Name:              pub.DefaultNaturalLanguage(u.Host),
+		PreferredUsername: pub.DefaultNaturalLanguage(u.Host),
+		URL:               pub.IRI(u.Scheme + "://" + u.Host),
+		AttributedTo:      st.root.GetLink(),
+		Published:         time.Now().UTC(),
+	}
+	app.Inbox = pub.Inbox.IRI(app.ID)
+	app.Outbox = pub.Outbox.IRI(app.ID)
+	if _, err := f.Save(&app); err != nil {
+		return nil, errors.Annotatef(err, "unable to save application actor")
+	}
+	c := &osin.DefaultClient{Id: id, Secret: randomSecret(), RedirectUri: u.String(), UserData: app.ID}
+	if err := st.write("create client", app.ID, func() error { return st.s.CreateClient(c) }); err != nil {
+		return nil, errors.Annotatef(err, "unable to save client")
+	}
+	f.recordClient(st, c, true)
+	_, err = f.emit(st, pub.CreateNew("", &app))
+	return c, err
+}
+
+func revokeToken(f *fedbox, st Store, token string) error {
+	access, err := st.s.LoadAccess(token)
+	if err != nil {
+		return errors.Annotatef(err, "unable to load token")
+	}
+	err = st.write("revoke token", st.root.GetLink(), func() error {
+		if access.RefreshToken != "" {
+			if err := st.s.RemoveRefresh(access.RefreshToken); err != nil {
+				return errors.Annotatef(err, "unable to remove refresh token")
+			}
+		}
+		return st.s.RemoveAccess(token)
+	})
+	if err != nil {
+		return err
+	}
+	f.recordRevoke(st, "access", userIRI(access.UserData))
+	return nil
+}
+
+func removeAuthorize(f *fedbox, st Store, code string) error {
+	if err := st.write("remove authorization", st.root.GetLink(), func() error { return st.s.RemoveAuthorize(code) }); err != nil {
+		return err
+	}
+	f.recordRevoke(st, "authorize", "")
+	return nil
+}
+
+type oauthLoadedMsg []oauthStore
+
+type oauthChangedMsg struct {
+	message string
+	err     error
+}
+
+// OAuthModel lists the OAuth2 clients of the stores, with their authorizations and access tokens,
+// and allows creating clients, deleting them and revoking tokens.
+type OAuthModel struct {
+	*commonModel
+
+	loading bool
+	cursor  int
+	stores  []oauthStore
+	entries []oauthEntry
+}
+
+func newOAuthModel(common *commonModel) *OAuthModel {
+	return &OAuthModel{commonModel: common}
+}
+
+func (o *OAuthModel) Init() tea.Cmd {
+	if o.f == nil {
+		return noop
+	}
+	o.loading = true
+	f := o.f
+	return func() tea.Msg {
+		stores := make([]oauthStore, 0, len(f.stores))
+		for _, st := range f.stores {
+			stores = append(stores, loadOAuthStore(st))
+		}
+		return oauthLoadedMsg(stores)
+	}
+}
+
+func (o *OAuthModel) current() (oauthEntry, bool) {
+	if o.cursor < 0 || o.cursor >= len(o.entries) {
+		return oauthEntry{}, false
+	}
+	return o.entries[o.cursor], true
+}
+
+// currentStore returns the store of the selected entry, falling back to the one of the root actor selected in the tree.
+func (o *OAuthModel) currentStore() (Store, error) {
+	if e, ok := o.current(); ok {
+		return e.store, nil
+	}
+	if !pub.IsNil(o.root) {
+		return o.f.storeFor(o.root.GetLink())
+	}
+	if len(o.f.stores) == 0 {
+		return Store{}, fmt.Errorf("no storage available")
+	}
+	return o.f.stores[0], nil
+}
+
+func (o *OAuthModel) create(redirect string) tea.Cmd {
+	st, err := o.currentStore()
+	if err != nil {
+		return errCmd(err)
+	}
+	f := o.f
+	return confirmWrites(func() tea.Cmd {
+		return func() tea.Msg {
+			var c osin.Client
+			err := st.action(func() (err error) {
+				c, err = createClient(f, st, redirect)
+				return err
+			})
+			if err != nil {
+				return oauthChangedMsg{err: err}
+			}
+			return oauthChangedMsg{message: fmt.Sprintf("created client %s with secret %s", c.GetId(), c.GetSecret())}
+		}
+	}, st)
+}
+
+// remove deletes the selected client together with its tokens, or revokes the selected token or authorization.
+func (o *OAuthModel) remove(e oauthEntry) tea.Cmd {
+	f := o.f
+	entries := o.entries
+	return confirmWrites(func() tea.Cmd {
+		return func() tea.Msg {
+			release, err := beginActions(e.store)
+			if err != nil {
+				return oauthChangedMsg{err: err}
+			}
+			defer release()
+			return removeEntry(f, e, entries)
+		}
+	}, e.store)
+}
+
+func removeEntry(f *fedbox, e oauthEntry, entries []oauthEntry) oauthChangedMsg {
+	if e.legacy != "" {
+		return oauthChangedMsg{err: fmt.Errorf("legacy OAuth2 database %s is only listed", e.legacy)}
+	}
+	switch {
+	case e.client != nil:
+		errs := make([]error, 0)
+		for _, other := range entries {
+			if !other.store.root.GetLink().Equals(e.store.root.GetLink(), false) || other.client != nil || other.legacy != "" || other.clientID() != e.client.GetId() {
+				continue
+			}
+			if other.access != nil {
+				if err := revokeToken(f, other.store, other.access.AccessToken); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if other.auth != nil {
+				if err := removeAuthorize(f, other.store, other.auth.Code); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		// NOTE(marius): we keep the client if any of its tokens is left, so the removal can be retried.
+		if len(errs) > 0 {
+			return oauthChangedMsg{err: errors.Annotatef(errors.Join(errs...), "unable to revoke the tokens of client %s", e.client.GetId())}
+		}
+		removeClient := func() error { return e.store.s.RemoveClient(e.client.GetId()) }
+		if err := e.store.write("remove client", e.store.root.GetLink(), removeClient); err != nil {
+			return oauthChangedMsg{err: errors.Annotatef(err, "unable to delete client")}
+		}
+		f.recordClient(e.store, e.client, false)
+		return oauthChangedMsg{message: fmt.Sprintf("deleted client %s", e.client.GetId())}
+	case e.auth != nil:
+		return oauthChangedMsg{err: removeAuthorize(f, e.store, e.auth.Code), message: "removed authorization"}
+	case e.access != nil:
+		return oauthChangedMsg{err: revokeToken(f, e.store, e.access.AccessToken), message: "revoked token"}
+	case e.bucket == "authorize":
+		// NOTE(marius): expired authorizations fail to load, but they can still be removed by their code.
+		return oauthChangedMsg{err: removeAuthorize(f, e.store, e.code), message: "removed authorization"}
+	}
+	removeAccess := func() error { return e.store.s.RemoveAccess(e.code) }
+	if err := e.store.write("revoke token", e.store.root.GetLink(), removeAccess); err != nil {
+		return oauthChangedMsg{err: err}
+	}
+	f.recordRevoke(e.store, "access", "")
+	return oauthChangedMsg{message: "revoked token"}
+}
+
+func (o *OAuthModel) revoke(token string) tea.Cmd {
+	st, err := o.currentStore()
+	if err != nil {
+		return errCmd(err)
+	}
+	token = strings.TrimSpace(token)
+	f := o.f
+	return confirmWrites(func() tea.Cmd {
+		return func() tea.Msg {
+			return oauthChangedMsg{err: st.action(func() error { return revokeToken(f, st, token) }), message: "revoked token"}
+		}
+	}, st)
+}
+
+func (o *OAuthModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case oauthLoadedMsg:
+		o.loading = false
+		o.stores = mm
+		o.entries = make([]oauthEntry, 0)
+		for _, st := range o.stores {
+			o.entries = append(o.entries, st.entries...)
+		}
+		o.cursor = clamp(o.cursor, 0, max(0, len(o.entries)-1))
+	case oauthChangedMsg:
+		if mm.err != nil {
+			return o, errCmd(mm.err)
+		}
+		return o, tea.Batch(o.Init(), statusMessageCmd("%s", mm.message))
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(mm, listUpKey):
+			o.cursor = clamp(o.cursor-1, 0, max(0, len(o.entries)-1))
+		case key.Matches(mm, listDownKey):
+			o.cursor = clamp(o.cursor+1, 0, max(0, len(o.entries)-1))
+		case key.Matches(mm, oauthAddKey):
+			return o, promptCmd("Client redirect URI", "", o.create)
+		case key.Matches(mm, oauthRevokeKey):
+			return o, promptCmd("Revoke access token", "", func(token string) tea.Cmd {
+				return confirmCmd(fmt.Sprintf("Revoke token %s?", strings.TrimSpace(token)), func() tea.Cmd {
+					return o.revoke(token)
+				})
+			})
+		case key.Matches(mm, oauthRemoveKey):
+			e, ok := o.current()
+			if !ok {
+				return o, noop
+			}
+			return o, confirmCmd(e.removeQuestion(), func() tea.Cmd {
+				return o.remove(e)
+			})
+		}
+	}
+	return o, noop
+}
+
+func (e oauthEntry) removeQuestion() string {
+	switch {
+	case e.client != nil:
+		return fmt.Sprintf("Delete client %s and its tokens?", e.client.GetId())
+	case e.auth != nil:
+		return fmt.Sprintf("Remove authorization %s?", e.auth.Code)
+	case e.access != nil:
+		return fmt.Sprintf("Revoke token %s?", e.access.AccessToken)
+	case e.bucket == "authorize":
+		return fmt.Sprintf("Remove authorization %s?", e.code)
+	}
+	return fmt.Sprintf("Revoke token %s?", e.code)
+}
+
+func expiry(created time.Time, expiresIn int32) string {
+	expires := created.Add(time.Duration(expiresIn) * time.Second)
+	if expires.Before(time.Now()) {
+		return faintRedFg.Render("expired " + expires.Local().Format(oauthTimeFmt))
+	}
+	return "expires " + expires.Local().Format(oauthTimeFmt)
+}
+
+func (e oauthEntry) line() string {
+	switch {
+	case e.client != nil:
+		line := fmt.Sprintf("client %s → %s", e.client.GetId(), e.client.GetRedirectUri())
+		if app, ok := e.client.GetUserData().(pub.IRI); ok && app != "" {
+			line += fmt.Sprintf(" (%s)", app)
+		} else if s, ok := e.client.GetUserData().(string); ok && s != "" {
+			line += fmt.Sprintf(" (%s)", s)
+		}
+		return line
+	case e.auth != nil:
+		line := fmt.Sprintf("  authorization %s for %s, %s", e.auth.Code, e.clientID(), expiry(e.auth.CreatedAt, e.auth.ExpiresIn))
+		if e.legacy != "" {
+			line += faintStyle.Render(" (legacy)")
+		}
+		return line
+	case e.access != nil:
+		line := fmt.Sprintf("  token %s for %s, %s", e.access.AccessToken, e.clientID(), expiry(e.access.CreatedAt, e.access.ExpiresIn))
+		if actor, ok := e.access.UserData.(pub.IRI); ok && actor != "" {
+			line += fmt.Sprintf(", actor %s", actor)
+		}
+		if e.legacy != "" {
+			line += faintStyle.Render(" (legacy)")
+		}
+		return line
+	}
+	return faintRedFg.Render(fmt.Sprintf("  %s: %s", e.code, e.err))
+}
+
+func (o *OAuthModel) View() tea.View {
+	pieces := []string{viewTitleStyle.Render("OAuth2")}
+	if o.loading {
+		pieces = append(pieces, "Loading clients"+ellipsis)
+		return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+	}
+	i := 0
+	for _, st := range o.stores {
+		pieces = append(pieces, "", lipgloss.NewStyle().Bold(true).Render(getNameFromItem(st.store.root)))
+		for _, err := range st.errs {
+			pieces = append(pieces, faintRedFg.Render(err.Error()))
+		}
+		for _, p := range st.legacy {
+			pieces = append(pieces, faintStyle.Render(fmt.Sprintf("legacy OAuth2 database at %s is not used by the current storage, its tokens are only listed", p)))
+		}
+		if !st.listable {
+			pieces = append(pieces, faintStyle.Render(fmt.Sprintf("tokens can't be listed for %s storage", st.store.conf.Type)))
+		}
+		if len(st.entries) == 0 {
+			pieces = append(pieces, "No clients")
+		}
+		for _, e := range st.entries {
+			line := e.line()
+			if i == o.cursor {
+				line = hintFg.Render(line)
+			}
+			pieces = append(pieces, line)
+			i++
+		}
+	}
+
+	pieces = append(pieces, "", helpLine(oauthAddKey, oauthRemoveKey, oauthRevokeKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,268 @@
+package motley
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"git.sr.ht/~mariusor/motley/internal/config"
+	"github.com/dgraph-io/badger/v4"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+	"github.com/openshift/osin"
+	bolt "go.etcd.io/bbolt"
+)
+
+var oauthBuckets = []string{"authorize", "access"}
+
+// oauthListable returns if the authorizations and the access tokens of the storage can be enumerated.
+func oauthListable(st Store) bool {
+	switch st.conf.Type {
+	case config.StorageFS, config.StorageSqlite, config.StorageBadger, config.StorageBoltDB:
+		return true
+	}
+	return false
+}
+
+// oauthCodes returns the codes of the authorizations and of the access tokens of the storage, by bucket.
+// NOTE(marius): the osin storage interface has no way of listing them, so we read the storage ourselves:
+// the directories of the fs storage, the sqlite database opened read-only, or the badger and boltdb databases
+// through the handles the storage holds, as they are locked for as long as it keeps them open.
+func oauthCodes(st Store) (map[string][]string, error) {
+	codes := make(map[string][]string)
+	switch st.conf.Type {
+	case config.StorageFS:
+		for _, bucket := range oauthBuckets {
+			codes[bucket] = listOAuthDir(st, bucket)
+		}
+		return codes, nil
+	case config.StorageSqlite:
+		base, err := st.conf.BaseStoragePath(st.env)
+		if err != nil {
+			return nil, err
+		}
+		return sqliteOAuthCodes(filepath.Join(base, "storage.sqlite"))
+	case config.StorageBadger:
+		db := storageHandle[badger.DB](st.s, "root")
+		if db == nil {
+			return nil, fmt.Errorf("badger storage is not open")
+		}
+		err := viewBadger(db, func(key, _ []byte) {
+			pieces := strings.Split(string(key), "/")
+			if len(pieces) == 3 && pieces[0] == "oauth" && slices.Contains(oauthBuckets, pieces[1]) {
+				codes[pieces[1]] = append(codes[pieces[1]], pieces[2])
+			}
+		})
+		return codes, err
+	case config.StorageBoltDB:
+		db := storageHandle[bolt.DB](st.s, "d")
+		if db == nil {
+			return nil, fmt.Errorf("boltdb storage is not open")
+		}
+		err := db.View(func(tx *bolt.Tx) error {
+			root := tx.Bucket([]byte(":"))
+			if root == nil {
+				return nil
+			}
+			for _, bucket := range oauthBuckets {
+				b := root.Bucket([]byte(bucket))
+				if b == nil {
+					continue
+				}
+				_ = b.ForEach(func(k, _ []byte) error {
+					codes[bucket] = append(codes[bucket], string(k))
+					return nil
+				})
+			}
+			return nil
+		})
+		return codes, err
+	}
+	return codes, nil
+}
+
+// storageHandle returns the database handle the storage keeps in its field, if it has the expected type.
+func storageHandle[T any](s any, field string) *T {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.Elem().FieldByName(field)
+	if !f.IsValid() || f.Type() != reflect.TypeFor[*T]() || f.IsNil() {
+		return nil
+	}
+	return (*T)(f.UnsafePointer())
+}
+
+func sqliteDriver() string {
+	if slices.Contains(sql.Drivers(), "sqlite") {
+		return "sqlite"
+	}
+	return "sqlite3"
+}
+
+func sqliteOAuthCodes(path string) (map[string][]string, error) {
+	db, err := sql.Open(sqliteDriver(), "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, errors.Annotatef(err, "unable to open %s", path)
+	}
+	defer db.Close()
+
+	codes := make(map[string][]string)
+	queries := map[string]string{
+		"authorize": `SELECT "code" FROM "authorize"`,
+		"access":    `SELECT "token" FROM "access"`,
+	}
+	for bucket, query := range queries {
+		rows, err := db.Query(query)
+		if err != nil {
+			return nil, errors.Annotatef(err, "unable to list %s codes", bucket)
+		}
+		for rows.Next() {
+			var code string
+			if err := rows.Scan(&code); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			codes[bucket] = append(codes[bucket], code)
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// readBadger calls fn with every key and value of the badger database at path, which it opens read-only.
+func readBadger(path string, fn func(key, value []byte)) error {
+	opts := badger.DefaultOptions(path).WithReadOnly(true).WithLogger(nil).WithMetricsEnabled(false)
+	db, err := badger.Open(opts)
+	if err != nil {
+		return errors.Annotatef(err, "unable to open %s", path)
+	}
+	defer db.Close()
+	return viewBadger(db, fn)
+}
+
+func viewBadger(db *badger.DB, fn func(key, value []byte)) error {
+	return db.View(func(tx *badger.Txn) error {
+		it := tx.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			err := item.Value(func(val []byte) error {
+				fn(item.KeyCopy(nil), bytes.Clone(val))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readBolt calls fn with the keys and values of the buckets named bucket, at any depth, of the boltdb database at path,
+// which it opens read-only.
+func readBolt(path string, fn func(bucket string, key, value []byte)) error {
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return errors.Annotatef(err, "unable to open %s", path)
+	}
+	defer db.Close()
+
+	var walk func(name []byte, b *bolt.Bucket) error
+	walk = func(name []byte, b *bolt.Bucket) error {
+		return b.ForEach(func(k, v []byte) error {
+			if v != nil {
+				fn(string(name), bytes.Clone(k), bytes.Clone(v))
+				return nil
+			}
+			if sub := b.Bucket(k); sub != nil {
+				return walk(k, sub)
+			}
+			return nil
+		})
+	}
+	return db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(walk)
+	})
+}
+
+// legacyOAuthData holds the fields of an authorization or an access token, as the older FedBOX versions saved them.
+type legacyOAuthData struct {
+	Client    json.RawMessage
+	ExpiresIn int64
+	CreatedAt time.Time
+	UserData  json.RawMessage
+	Extra     json.RawMessage
+}
+
+func (d legacyOAuthData) clientID() string {
+	var id string
+	if err := json.Unmarshal(d.Client, &id); err == nil {
+		return id
+	}
+	var c struct{ Id string }
+	_ = json.Unmarshal(d.Client, &c)
+	return c.Id
+}
+
+func (d legacyOAuthData) user() pub.IRI {
+	for _, raw := range []json.RawMessage{d.UserData, d.Extra} {
+		var iri string
+		if err := json.Unmarshal(raw, &iri); err == nil && iri != "" {
+			return pub.IRI(iri)
+		}
+	}
+	return ""
+}
+
+// legacyOAuthEntry decodes an authorization or an access token from a legacy OAuth2 database.
+func legacyOAuthEntry(st Store, path, bucket, code string, raw []byte) oauthEntry {
+	e := oauthEntry{store: st, bucket: bucket, code: code, legacy: path}
+	d := legacyOAuthData{}
+	if err := json.Unmarshal(raw, &d); err != nil {
+		e.err = errors.Annotatef(err, "unable to decode %s", bucket)
+		return e
+	}
+	client := &osin.DefaultClient{Id: d.clientID()}
+	if bucket == "authorize" {
+		e.auth = &osin.AuthorizeData{Code: code, Client: client, ExpiresIn: int32(d.ExpiresIn), CreatedAt: d.CreatedAt, UserData: d.user()}
+	} else {
+		e.access = &osin.AccessData{AccessToken: code, Client: client, ExpiresIn: int32(d.ExpiresIn), CreatedAt: d.CreatedAt, UserData: d.user()}
+	}
+	return e
+}
+
+// loadLegacyOAuth lists the authorizations and the access tokens of a legacy OAuth2 database, which it only reads.
+func loadLegacyOAuth(st Store, path string) ([]oauthEntry, error) {
+	entries := make([]oauthEntry, 0)
+	switch st.conf.Type {
+	case config.StorageBoltDB:
+		err := readBolt(path, func(bucket string, key, value []byte) {
+			if slices.Contains(oauthBuckets, bucket) {
+				entries = append(entries, legacyOAuthEntry(st, path, bucket, string(key), value))
+			}
+		})
+		return entries, err
+	case config.StorageBadger:
+		err := readBadger(path, func(key, value []byte) {
+			pieces := strings.Split(string(key), "/")
+			if len(pieces) < 2 || !slices.Contains(oauthBuckets, pieces[len(pieces)-2]) {
+				return
+			}
+			entries = append(entries, legacyOAuthEntry(st, path, pieces[len(pieces)-2], pieces[len(pieces)-1], value))
+		})
+		return entries, err
+	}
+	return entries, fmt.Errorf("unknown legacy OAuth2 database %s", path)
+}
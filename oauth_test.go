@@ -0,0 +1,208 @@
+package motley
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"git.sr.ht/~mariusor/lw"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	"git.sr.ht/~mariusor/storage-all"
+	pub "github.com/go-ap/activitypub"
+	"github.com/openshift/osin"
+	bolt "go.etcd.io/bbolt"
+)
+
+// newTestOAuthStore returns a store of the given type in a temporary directory, kept open like the ones motley uses.
+func newTestOAuthStore(t *testing.T, typ config.StorageType) Store {
+	t.Helper()
+	conf := config.Storage{Type: typ, Path: t.TempDir()}
+	if err := storage.Bootstrap(storage.WithPath(conf.Path), storage.WithType(storage.Type(typ))); err != nil {
+		t.Fatalf("unable to bootstrap storage: %s", err)
+	}
+	db, err := config.Open(conf, "", lw.Dev(lw.SetLevel(lw.ErrorLevel)))
+	if err != nil {
+		t.Fatalf("unable to open storage: %s", err)
+	}
+	if err := db.Open(); err != nil {
+		t.Fatalf("unable to open storage: %s", err)
+	}
+	t.Cleanup(func() {
+		if closer, ok := db.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	})
+	return Store{root: testRoot, s: db, conf: conf}
+}
+
+func TestOAuthCodes(t *testing.T) {
+	for _, typ := range []config.StorageType{config.StorageFS, config.StorageSqlite, config.StorageBadger, config.StorageBoltDB} {
+		t.Run(string(typ), func(t *testing.T) {
+			st := newTestOAuthStore(t, typ)
+			c := &osin.DefaultClient{Id: "client", Secret: "secret", RedirectUri: "https://example.com/callback", UserData: testRoot}
+			if err := st.s.CreateClient(c); err != nil {
+				t.Fatalf("unable to save client: %s", err)
+			}
+			auth := &osin.AuthorizeData{Client: c, Code: "code", ExpiresIn: 3600, RedirectUri: c.RedirectUri, CreatedAt: time.Now().UTC()}
+			if err := st.s.SaveAuthorize(auth); err != nil {
+				t.Fatalf("unable to save authorization: %s", err)
+			}
+			access := &osin.AccessData{Client: c, AccessToken: "token", ExpiresIn: 3600, RedirectUri: c.RedirectUri, CreatedAt: time.Now().UTC()}
+			if err := st.s.SaveAccess(access); err != nil {
+				t.Fatalf("unable to save token: %s", err)
+			}
+
+			codes, err := oauthCodes(st)
+			if err != nil {
+				t.Fatalf("unable to list tokens: %+v", err)
+			}
+			if !slices.Equal(codes["authorize"], []string{"code"}) || !slices.Equal(codes["access"], []string{"token"}) {
+				t.Errorf("expected the authorization and the token to be listed, got %v", codes)
+			}
+			o := loadOAuthStore(st)
+			if len(o.errs) > 0 || len(o.entries) != 3 {
+				t.Errorf("expected the client, the authorization and the token, got %d entries, errors %+v", len(o.entries), o.errs)
+			}
+		})
+	}
+}
+
+func TestRemoveEntry_keepsClientOnError(t *testing.T) {
+	f, st := newTestFedbox(t)
+	c := &osin.DefaultClient{Id: "client", Secret: "secret", RedirectUri: "https://example.com/callback", UserData: testRoot}
+	if err := st.s.CreateClient(c); err != nil {
+		t.Fatalf("unable to save client: %s", err)
+	}
+	// NOTE(marius): the token is listed, but it's not in the storage anymore, so revoking it fails.
+	missing := oauthEntry{store: st, access: &osin.AccessData{Client: c, AccessToken: "missing"}}
+	msg := removeEntry(f, oauthEntry{store: st, client: c}, []oauthEntry{missing})
+	if msg.err == nil {
+		t.Fatalf("expected the failure to revoke the token to be returned")
+	}
+	if _, err := st.s.GetClient(c.Id); err != nil {
+		t.Errorf("expected the client to be kept, got %s", err)
+	}
+}
+
+func TestRemoveEntry_legacyIsOnlyListed(t *testing.T) {
+	f, st := newTestFedbox(t)
+	saveTestToken(t, st, "token", testRoot)
+	e := oauthEntry{store: st, access: &osin.AccessData{AccessToken: "token"}, legacy: "oauth.bdb"}
+	if msg := removeEntry(f, e, nil); msg.err == nil {
+		t.Errorf("expected an error removing a legacy token")
+	}
+	if _, err := st.s.LoadAccess("token"); err != nil {
+		t.Errorf("expected the token to be kept, got %s", err)
+	}
+}
+
+func TestOAuthModel_removeConfirms(t *testing.T) {
+	f, st := newTestFedbox(t)
+	saveTestToken(t, st, "token", testRoot)
+	o := newOAuthModel(&commonModel{f: f})
+	o.Update(oauthLoadedMsg{loadOAuthStore(st)})
+	for o.cursor < len(o.entries) && o.entries[o.cursor].access == nil {
+		o.cursor++
+	}
+
+	_, cmd := o.Update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	prompt, ok := findMsg[promptMsg](cmd)
+	if !ok {
+		t.Fatalf("revoking the token should ask for confirmation")
+	}
+	if cmd := prompt.submitFn("n"); cmd != nil {
+		t.Errorf("declining should not revoke the token")
+	}
+	if _, err := st.s.LoadAccess("token"); err != nil {
+		t.Errorf("expected the token to be kept, got %s", err)
+	}
+}
+
+func TestLoadLegacyOAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oauth.bdb")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("unable to create database: %s", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists([]byte("example.com"))
+		if err != nil {
+			return err
+		}
+		b, err := root.CreateBucketIfNotExists([]byte("access"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("token"), []byte(`{"Client":"client","ExpiresIn":3600,"CreatedAt":"2020-01-01T00:00:00Z","UserData":"https://example.com/actors/jdoe"}`))
+	})
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("unable to save token: %s", err)
+	}
+
+	entries, err := loadLegacyOAuth(Store{conf: config.Storage{Type: config.StorageBoltDB}}, path)
+	if err != nil {
+		t.Fatalf("unable to list legacy tokens: %s", err)
+	}
+	if len(entries) != 1 || entries[0].access == nil {
+		t.Fatalf("expected the legacy token to be listed, got %v", entries)
+	}
+	e := entries[0]
+	if e.access.AccessToken != "token" || e.clientID() != "client" || userIRI(e.access.UserData) != "https://example.com/actors/jdoe" || e.legacy != path {
+		t.Errorf("unexpected legacy token %+v", e.access)
+	}
+}
+
+func TestCreateClient(t *testing.T) {
+	f, st := newTestFedbox(t)
+	if _, err := createClient(f, st, "example.org"); err == nil {
+		t.Errorf("a redirect URI without a host should be refused")
+	}
+
+	c, err := createClient(f, st, " https://example.org/callback ")
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	saved, err := st.s.GetClient(c.GetId())
+	if err != nil {
+		t.Fatalf("the client should be saved: %s", err)
+	}
+	if saved.GetRedirectUri() != "https://example.org/callback" || saved.GetSecret() == "" {
+		t.Errorf("unexpected client %s with secret %q", saved.GetRedirectUri(), saved.GetSecret())
+	}
+	app, err := st.s.Load(userIRI(saved.GetUserData()))
+	if err != nil || app.GetType() != pub.ApplicationType {
+		t.Fatalf("the client should be linked to its Application actor, got %v: %v", app, err)
+	}
+}
+
+func TestRemoveEntry_client(t *testing.T) {
+	f, st := newTestFedbox(t)
+	c, err := createClient(f, st, "https://example.org/callback")
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	access := &osin.AccessData{Client: c, AccessToken: "token", ExpiresIn: 3600, RedirectUri: c.GetRedirectUri(), CreatedAt: time.Now().UTC(), UserData: testRoot}
+	if err := st.s.SaveAccess(access); err != nil {
+		t.Fatalf("unable to save token: %s", err)
+	}
+
+	o := loadOAuthStore(st)
+	var client oauthEntry
+	for _, e := range o.entries {
+		if e.client != nil {
+			client = e
+		}
+	}
+	if msg := removeEntry(f, client, o.entries); msg.err != nil {
+		t.Fatalf("unable to delete client: %s", msg.err)
+	}
+	if _, err := st.s.GetClient(c.GetId()); err == nil {
+		t.Errorf("the client should be deleted")
+	}
+	if _, err := st.s.LoadAccess("token"); err == nil {
+		t.Errorf("the tokens of the client should be revoked")
+	}
+}
@@ -2,8 +2,11 @@ package motley
 
 import (
 	"mime"
+	"slices"
+	"sort"
 	"strings"
 
+	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	vocab "github.com/go-ap/activitypub"
@@ -22,10 +25,29 @@ type ObjectModel struct {
 	Name    NaturalLanguageValues
 	Summary NaturalLanguageValues
 	Content NaturalLanguageValues
+	Source  NaturalLanguageValues
+
+	// raw shows the summary and content unrendered, together with the source of the object.
+	raw bool
+	// lang is the language selected for the natural language values, if langSelected is set.
+	lang         vocab.LangRef
+	langSelected bool
+	// langs are the preferred languages for the natural language values.
+	langs []vocab.LangRef
 }
 
-func newObjectModel() ObjectModel {
-	return ObjectModel{}
+var rawToggleKey = key.NewBinding(
+	key.WithKeys("r"),
+	key.WithHelp("r", "toggle raw source"),
+)
+
+var languageKey = key.NewBinding(
+	key.WithKeys("l"),
+	key.WithHelp("l", "cycle content language"),
+)
+
+func newObjectModel(langs []vocab.LangRef) ObjectModel {
+	return ObjectModel{langs: langs}
 }
 
 func (o ObjectModel) Init() tea.Cmd {
@@ -33,9 +55,55 @@ func (o ObjectModel) Init() tea.Cmd {
 }
 
 func (o ObjectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if mm, ok := msg.(tea.KeyPressMsg); ok {
+		switch {
+		case key.Matches(mm, rawToggleKey):
+			o.raw = !o.raw
+		case key.Matches(mm, languageKey):
+			o.nextLanguage()
+		}
+	}
 	return o, noop
 }
 
+// languages returns all the languages used in the name, summary and content of the object.
+func (o ObjectModel) languages() []vocab.LangRef {
+	refs := make([]vocab.LangRef, 0)
+	for _, nlv := range []vocab.NaturalLanguageValues{o.Object.Name, o.Object.Summary, o.Object.Content} {
+		for _, ref := range langRefs(nlv) {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].String() < refs[j].String()
+	})
+	return refs
+}
+
+func (o *ObjectModel) nextLanguage() {
+	refs := o.languages()
+	if len(refs) == 0 {
+		return
+	}
+	current := o.lang
+	if !o.langSelected {
+		current = defaultLangRef(o.Object.Content, o.langs)
+	}
+	o.lang = refs[(slices.Index(refs, current)+1)%len(refs)]
+	o.langSelected = true
+}
+
+// contentMediaType returns the media type of the content of the object, which for binary objects
+// describes the attached media instead.
+func (o ObjectModel) contentMediaType() vocab.MimeType {
+	if mimeIsBinary(o.MediaType) {
+		return ""
+	}
+	return o.MediaType
+}
+
 func mimeIsBinary(mimeType vocab.MimeType) bool {
 	var binContentMimeTypes = []string{"image", "audio", "video", "application"}
 	justType, _, _ := mime.ParseMediaType(string(mimeType))
@@ -70,8 +138,26 @@ func (o ObjectModel) View() tea.View {
 		o.Summary = summaryModel(o.Object.Summary)
 	}
 	if ll := len(o.Object.Content); ll > 0 {
-		o.Content = contentModel(o.Object.Content)
+		o.Content = contentModel(o.Object.Content, o.contentMediaType())
+	} else if len(o.Object.Source.Content) > 0 && !o.raw {
+		o.Content = sourceModel(o.Object.Source)
+		o.Content.Label = "Content"
+	}
+	if len(o.Object.Source.Content) > 0 && o.raw {
+		o.Source = sourceModel(o.Object.Source)
 	}
+	if o.langSelected {
+		o.Name.selectLanguage(o.lang)
+		o.Summary.selectLanguage(o.lang)
+		o.Content.selectLanguage(o.lang)
+	}
+	o.Summary.raw = o.raw
+	o.Content.raw = o.raw
+	o.Source.raw = o.raw
+	o.Name.preferred = o.langs
+	o.Summary.preferred = o.langs
+	o.Content.preferred = o.langs
+	o.Source.preferred = o.langs
 
 	typeStyle := lipgloss.NewStyle().Bold(true).BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
 	title := typeStyle.Render(ItemType(o))
@@ -90,6 +176,9 @@ func (o ObjectModel) View() tea.View {
 		if content := o.Content.View(); len(content) > 0 {
 			pieces = append(pieces, content)
 		}
+		if source := o.Source.View(); len(source) > 0 {
+			pieces = append(pieces, source)
+		}
 	}
 
 	return tea.NewView(lipgloss.JoinVertical(lipgloss.Top, pieces...))
@@ -0,0 +1,138 @@
+package motley
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// promptModel is a single line input that replaces the status bar while it's active.
+// When the user submits a value, the submitFn is called with it and its resulting tea.Cmd is returned.
+type promptModel struct {
+	input    textinput.Model
+	submitFn func(string) tea.Cmd
+}
+
+type promptMsg struct {
+	label    string
+	value    string
+	submitFn func(string) tea.Cmd
+	// secret hides the typed value, for passwords.
+	secret bool
+}
+
+var (
+	promptSubmitKey = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "submit"),
+	)
+	promptCancelKey = key.NewBinding(
+		key.WithKeys("esc", "ctrl+c"),
+		key.WithHelp("esc", "cancel"),
+	)
+)
+
+func promptCmd(label, value string, submitFn func(string) tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return promptMsg{label: label, value: value, submitFn: submitFn}
+	}
+}
+
+// passwordPromptCmd is like promptCmd, but the typed value is not shown.
+func passwordPromptCmd(label string, submitFn func(string) tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return promptMsg{label: label, submitFn: submitFn, secret: true}
+	}
+}
+
+// confirmCmd asks the question in a prompt, and returns the result of confirmedFn only if the user answers "y".
+func confirmCmd(question string, confirmedFn func() tea.Cmd) tea.Cmd {
+	return promptCmd(question+" (y/N)", "", func(s string) tea.Cmd {
+		if !strings.EqualFold(strings.TrimSpace(s), "y") {
+			return noop
+		}
+		return confirmedFn()
+	})
+}
+
+// editorCmd opens content in the user's $VISUAL or $EDITOR, and when the editor exits,
+// it returns the result of editedFn called with the saved content.
+func editorCmd(pattern string, content []byte, editedFn func([]byte) tea.Msg) tea.Cmd {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return errCmd(fmt.Errorf("unable to create file for editing: %w", err))
+	}
+	path := f.Name()
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return errCmd(fmt.Errorf("unable to create file for editing: %w", err))
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editedMsg(path, err, editedFn)
+	})
+}
+
+func editedMsg(path string, err error, editedFn func([]byte) tea.Msg) tea.Msg {
+	defer os.Remove(path)
+	if err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read edited file: %w", err)
+	}
+	return editedFn(content)
+}
+
+func newPromptModel(msg promptMsg, width int) *promptModel {
+	in := textinput.New()
+	in.Prompt = msg.label + ": "
+	in.SetValue(msg.value)
+	if msg.secret {
+		in.EchoMode = textinput.EchoPassword
+		in.EchoCharacter = '•'
+	}
+	in.SetWidth(max(0, width-lipgloss.Width(in.Prompt)-1))
+	in.Focus()
+	return &promptModel{input: in, submitFn: msg.submitFn}
+}
+
+// Update returns true as the second value if the prompt has finished, either by being submitted or cancelled.
+func (p *promptModel) Update(msg tea.Msg) (tea.Cmd, bool) {
+	if mm, ok := msg.(tea.KeyPressMsg); ok {
+		switch {
+		case key.Matches(mm, promptSubmitKey):
+			if p.submitFn == nil {
+				return noop, true
+			}
+			return p.submitFn(p.input.Value()), true
+		case key.Matches(mm, promptCancelKey):
+			return noop, true
+		}
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return cmd, false
+}
+
+func (p *promptModel) View() string {
+	return statusBarMessageStyle(p.input.View())
+}
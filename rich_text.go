@@ -0,0 +1,459 @@
+package motley
+
+import (
+	"fmt"
+	"mime"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"charm.land/lipgloss/v2"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/muesli/reflow/wordwrap"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	richLinkStyle    = lipgloss.NewStyle().Foreground(Indigo).Underline(true)
+	richMentionStyle = lipgloss.NewStyle().Foreground(Fuchsia)
+	richHashtagStyle = lipgloss.NewStyle().Foreground(Green)
+	richCodeStyle    = lipgloss.NewStyle().Foreground(YellowGreen)
+	richHrefStyle    = lipgloss.NewStyle().Faint(true)
+)
+
+// richTextMaxWidth is the widest rich text content gets wrapped at, regardless of the terminal width.
+const richTextMaxWidth = 800
+
+const (
+	mimeTypeHTML     = "text/html"
+	mimeTypeMarkdown = "text/markdown"
+	mimeTypePlain    = "text/plain"
+)
+
+func baseMimeType(mimeType vocab.MimeType) string {
+	justType, _, err := mime.ParseMediaType(string(mimeType))
+	if err != nil {
+		return strings.ToLower(string(mimeType))
+	}
+	return justType
+}
+
+func looksLikeHTML(s string) bool {
+	return strings.Contains(s, "<") && strings.Contains(s, ">") || strings.Contains(s, "&")
+}
+
+// renderRichText converts the HTML or Markdown content to styled terminal text, wrapped at width.
+// Content without a media type is treated as HTML, as the ActivityPub vocabulary specifies, if it looks like it.
+func renderRichText(s string, mediaType vocab.MimeType, width int) string {
+	width = min(width, richTextMaxWidth)
+	switch baseMimeType(mediaType) {
+	case mimeTypeMarkdown:
+		return renderMarkdown(s, width)
+	case mimeTypeHTML:
+		return renderHTML(s, width)
+	case "":
+		if looksLikeHTML(s) {
+			return renderHTML(s, width)
+		}
+	}
+	return wrapText(s, width)
+}
+
+func wrapText(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return wordwrap.String(s, width)
+}
+
+// richRenderer accumulates styled inline text into lines, keeping track of the nesting of block elements.
+type richRenderer struct {
+	width int
+	lines []string
+
+	cur   strings.Builder
+	style lipgloss.Style
+
+	// prefixes hold the indentation of the enclosing block quotes and lists.
+	prefixes []string
+	// marker is the bullet or number of the current list item, rendered in front of its first line.
+	marker string
+	lists  []int
+	// space marks that the next piece of text needs to be separated from the current one.
+	space bool
+}
+
+func newRichRenderer(width int) *richRenderer {
+	return &richRenderer{width: width}
+}
+
+func (r *richRenderer) prefix() string {
+	return strings.Join(r.prefixes, "")
+}
+
+// writeText writes text collapsing the whitespace, the way a browser would.
+func (r *richRenderer) writeText(s string) {
+	if s == "" {
+		return
+	}
+	leading := strings.TrimLeftFunc(s, unicode.IsSpace) != s
+	trailing := strings.TrimRightFunc(s, unicode.IsSpace) != s
+	words := strings.Join(strings.Fields(s), " ")
+	if words == "" {
+		r.space = r.space || leading
+		return
+	}
+	r.space = r.space || leading
+	r.writeStyled(lipgloss.NewStyle(), words)
+	r.space = trailing
+}
+
+func (r *richRenderer) writeStyled(st lipgloss.Style, s string) {
+	if r.space && r.cur.Len() > 0 {
+		r.cur.WriteString(" ")
+	}
+	r.space = false
+	r.cur.WriteString(st.Inherit(r.style).Render(s))
+}
+
+// flush wraps the current inline text and appends it to the lines.
+func (r *richRenderer) flush() {
+	if r.cur.Len() == 0 {
+		return
+	}
+	prefix := r.prefix()
+	first := prefix + r.marker
+	rest := prefix + strings.Repeat(" ", lipgloss.Width(r.marker))
+	text := wrapText(r.cur.String(), r.width-lipgloss.Width(first))
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			r.lines = append(r.lines, first+line)
+		} else {
+			r.lines = append(r.lines, rest+line)
+		}
+	}
+	r.cur.Reset()
+	r.marker = ""
+	r.space = false
+}
+
+// paragraph ends the current block, separating it from the next one with an empty line.
+func (r *richRenderer) paragraph() {
+	r.flush()
+	if r.marker != "" {
+		return
+	}
+	if len(r.lines) > 0 && !r.lastLineEmpty() {
+		r.lines = append(r.lines, strings.TrimRight(r.prefix(), " "))
+	}
+}
+
+// lastLineEmpty checks if the last line contains nothing besides the prefixes of the enclosing blocks.
+func (r *richRenderer) lastLineEmpty() bool {
+	last := strings.TrimSpace(r.lines[len(r.lines)-1])
+	return last == "" || last == strings.TrimSpace(r.prefix())
+}
+
+func (r *richRenderer) withStyle(st lipgloss.Style, fn func()) {
+	old := r.style
+	r.style = st.Inherit(old)
+	fn()
+	r.style = old
+}
+
+func (r *richRenderer) withPrefix(p string, fn func()) {
+	r.prefixes = append(r.prefixes, p)
+	fn()
+	r.flush()
+	for len(r.lines) > 0 && r.lastLineEmpty() {
+		r.lines = r.lines[:len(r.lines)-1]
+	}
+	r.prefixes = r.prefixes[:len(r.prefixes)-1]
+}
+
+func (r *richRenderer) codeBlock(code string) {
+	r.paragraph()
+	code = strings.Trim(code, "\n")
+	for _, line := range strings.Split(code, "\n") {
+		r.lines = append(r.lines, r.prefix()+"  "+richCodeStyle.Render(line))
+	}
+	r.paragraph()
+}
+
+func (r *richRenderer) String() string {
+	r.flush()
+	for len(r.lines) > 0 && strings.TrimSpace(r.lines[len(r.lines)-1]) == "" {
+		r.lines = r.lines[:len(r.lines)-1]
+	}
+	return strings.Join(r.lines, "\n")
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeText(n *html.Node) string {
+	s := strings.Builder{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			s.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return s.String()
+}
+
+// richLink is an anchor found in HTML content.
+type richLink struct {
+	text string
+	href vocab.IRI
+}
+
+// htmlLinks returns the anchors in the HTML content, in the order they appear, without duplicates.
+func htmlLinks(s string) []richLink {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return nil
+	}
+	links := make([]richLink, 0)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			if href := vocab.IRI(attr(n, "href")); href != "" && !slices.ContainsFunc(links, func(l richLink) bool { return l.href == href }) {
+				links = append(links, richLink{text: strings.Join(strings.Fields(nodeText(n)), " "), href: href})
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+func renderHTML(s string, width int) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return wrapText(s, width)
+	}
+	r := newRichRenderer(width)
+	r.walkHTML(doc)
+	return r.String()
+}
+
+func (r *richRenderer) walkChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walkHTML(c)
+	}
+}
+
+func (r *richRenderer) walkHTML(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.writeText(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.walkChildren(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head:
+	case atom.Br:
+		r.flush()
+	case atom.P, atom.Div, atom.Section, atom.Article:
+		r.paragraph()
+		r.walkChildren(n)
+		r.paragraph()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.paragraph()
+		r.withStyle(lipgloss.NewStyle().Bold(true), func() { r.walkChildren(n) })
+		r.paragraph()
+	case atom.Strong, atom.B:
+		r.withStyle(lipgloss.NewStyle().Bold(true), func() { r.walkChildren(n) })
+	case atom.Em, atom.I:
+		r.withStyle(lipgloss.NewStyle().Italic(true), func() { r.walkChildren(n) })
+	case atom.U:
+		r.withStyle(lipgloss.NewStyle().Underline(true), func() { r.walkChildren(n) })
+	case atom.S, atom.Del:
+		r.withStyle(lipgloss.NewStyle().Strikethrough(true), func() { r.walkChildren(n) })
+	case atom.Code:
+		r.withStyle(richCodeStyle, func() { r.walkChildren(n) })
+	case atom.Pre:
+		r.codeBlock(nodeText(n))
+	case atom.Blockquote:
+		r.paragraph()
+		r.withPrefix("│ ", func() { r.walkChildren(n) })
+		r.paragraph()
+	case atom.Ul, atom.Ol:
+		r.flush()
+		r.lists = append(r.lists, 0)
+		if n.DataAtom == atom.Ul {
+			r.lists[len(r.lists)-1] = -1
+		}
+		indent := ""
+		if len(r.lists) > 1 {
+			indent = "  "
+		}
+		r.withPrefix(indent, func() { r.walkChildren(n) })
+		r.lists = r.lists[:len(r.lists)-1]
+		if len(r.lists) == 0 {
+			r.paragraph()
+		}
+	case atom.Li:
+		r.flush()
+		r.marker = "• "
+		if l := len(r.lists); l > 0 && r.lists[l-1] >= 0 {
+			r.lists[l-1]++
+			r.marker = fmt.Sprintf("%d. ", r.lists[l-1])
+		}
+		r.walkChildren(n)
+		r.flush()
+	case atom.A:
+		r.writeLink(n)
+	case atom.Img:
+		alt := attr(n, "alt")
+		if alt == "" {
+			alt = attr(n, "src")
+		}
+		r.writeStyled(richHrefStyle, "[image: "+alt+"]")
+	default:
+		r.walkChildren(n)
+	}
+}
+
+func (r *richRenderer) writeLink(n *html.Node) {
+	text := strings.Join(strings.Fields(nodeText(n)), " ")
+	href := attr(n, "href")
+	switch {
+	case hasClass(n, "mention") || hasClass(n, "u-url") && strings.HasPrefix(text, "@"):
+		r.writeStyled(richMentionStyle, text)
+	case hasClass(n, "hashtag") || attr(n, "rel") == "tag" || strings.HasPrefix(text, "#"):
+		r.writeStyled(richHashtagStyle, text)
+	default:
+		if text == "" {
+			text = href
+		}
+		r.writeStyled(richLinkStyle, text)
+		if href != "" && href != text && !strings.HasSuffix(href, text) {
+			r.space = true
+			r.writeStyled(richHrefStyle, "<"+href+">")
+		}
+	}
+}
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBullet   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdNumbered = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	// NOTE(marius): underscore emphasis needs word boundaries, so snake_case_words are left alone.
+	mdInline = regexp.MustCompile("`[^`]+`" +
+		`|\*\*[^*]+\*\*|\b__[^_]+__\b|\*[^*\s][^*]*\*|\b_[^_\s][^_]*_\b` +
+		`|\[[^\]]*\]\([^)\s]+\)` +
+		`|@[\w.-]+(?:@[\w.-]+\w)?|(?:^|\B)#\w+` +
+		`|https?://[^\s)>]+`)
+	mdLink = regexp.MustCompile(`^\[([^\]]*)\]\(([^)\s]+)\)$`)
+)
+
+func renderMarkdown(s string, width int) string {
+	r := newRichRenderer(width)
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			code := make([]string, 0)
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			r.codeBlock(strings.Join(code, "\n"))
+		case trimmed == "":
+			r.paragraph()
+		case mdHeading.MatchString(trimmed):
+			r.paragraph()
+			r.withStyle(lipgloss.NewStyle().Bold(true), func() {
+				r.inlineMarkdown(mdHeading.FindStringSubmatch(trimmed)[2])
+			})
+			r.paragraph()
+		case strings.HasPrefix(trimmed, ">"):
+			r.flush()
+			r.withPrefix("│ ", func() {
+				r.inlineMarkdown(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+			})
+		case mdBullet.MatchString(line):
+			m := mdBullet.FindStringSubmatch(line)
+			r.flush()
+			r.withPrefix(strings.Repeat(" ", len(m[1])), func() {
+				r.marker = "• "
+				r.inlineMarkdown(m[2])
+			})
+		case mdNumbered.MatchString(line):
+			m := mdNumbered.FindStringSubmatch(line)
+			r.flush()
+			r.withPrefix(strings.Repeat(" ", len(m[1])), func() {
+				r.marker = m[2] + ". "
+				r.inlineMarkdown(m[3])
+			})
+		default:
+			r.space = true
+			r.inlineMarkdown(trimmed)
+		}
+	}
+	return r.String()
+}
+
+// inlineMarkdown writes the text, styling the emphasis, code spans, links, mentions and hashtags.
+func (r *richRenderer) inlineMarkdown(s string) {
+	last := 0
+	for _, loc := range mdInline.FindAllStringIndex(s, -1) {
+		r.writeText(s[last:loc[0]])
+		last = loc[1]
+
+		tok := s[loc[0]:loc[1]]
+		switch {
+		case strings.HasPrefix(tok, "`"):
+			r.writeStyled(richCodeStyle, strings.Trim(tok, "`"))
+		case strings.HasPrefix(tok, "**"), strings.HasPrefix(tok, "__"):
+			r.writeStyled(lipgloss.NewStyle().Bold(true), tok[2:len(tok)-2])
+		case strings.HasPrefix(tok, "*"), strings.HasPrefix(tok, "_"):
+			r.writeStyled(lipgloss.NewStyle().Italic(true), tok[1:len(tok)-1])
+		case strings.HasPrefix(tok, "["):
+			m := mdLink.FindStringSubmatch(tok)
+			r.writeStyled(richLinkStyle, m[1])
+			if m[2] != m[1] {
+				r.space = true
+				r.writeStyled(richHrefStyle, "<"+m[2]+">")
+			}
+		case strings.HasPrefix(tok, "@"):
+			r.writeStyled(richMentionStyle, tok)
+		case strings.HasPrefix(tok, "#"):
+			r.writeStyled(richHashtagStyle, tok)
+		default:
+			r.writeStyled(richLinkStyle, tok)
+		}
+	}
+	r.writeText(s[last:])
+}
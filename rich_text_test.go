@@ -0,0 +1,87 @@
+package motley
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "heading", in: "# Title\ntext", want: "Title\n\ntext"},
+		{name: "bullets", in: "- one\n- two\n  - nested", want: "• one\n• two\n  • nested"},
+		{name: "numbered", in: "1. one\n2) two", want: "1. one\n2. two"},
+		{name: "link", in: "see [docs](https://example.com/docs)", want: "see docs <https://example.com/docs>"},
+		{name: "link with same text", in: "[https://example.com](https://example.com)", want: "https://example.com"},
+		{name: "inline code", in: "run `go test` now", want: "run go test now"},
+		{name: "emphasis", in: "*some* _emphasis_ and **bold**", want: "some emphasis and bold"},
+		{name: "underscores inside words", in: "call snake_case_words and __init__", want: "call snake_case_words and init"},
+		{name: "code block", in: "```\nfoo_bar_baz\n```", want: "  foo_bar_baz"},
+		{name: "quote", in: "> quoted", want: "│ quoted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ansi.Strip(renderMarkdown(tt.in, 80)); got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, expected %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown_styles(t *testing.T) {
+	const italic = "\x1b[3m"
+	tests := []struct {
+		in     string
+		italic bool
+	}{
+		{in: "snake_case_words", italic: false},
+		{in: "a_b_c d_e_f", italic: false},
+		{in: "_emphasis_", italic: true},
+		{in: "some (_emphasis_).", italic: true},
+		{in: "*emphasis*", italic: true},
+	}
+	for _, tt := range tests {
+		got := renderMarkdown(tt.in, 80)
+		if strings.Contains(got, italic) != tt.italic {
+			t.Errorf("renderMarkdown(%q) = %q, italic expected %t", tt.in, got, tt.italic)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "paragraphs", in: "<p>one</p><p>two</p>", want: "one\n\ntwo"},
+		{name: "heading", in: "<h2>Title</h2><p>text</p>", want: "Title\n\ntext"},
+		{name: "unordered list", in: "<ul><li>one</li><li>two</li></ul>", want: "• one\n• two"},
+		{name: "ordered list", in: "<ol><li>one</li><li>two</li></ol>", want: "1. one\n2. two"},
+		{name: "link", in: `<p>see <a href="https://example.com/docs">the docs</a></p>`, want: "see the docs <https://example.com/docs>"},
+		{name: "mention", in: `<a class="mention" href="https://example.com/~jdoe">@jdoe</a>`, want: "@jdoe"},
+		{name: "hashtag", in: `<a rel="tag" href="https://example.com/t/go">#go</a>`, want: "#go"},
+		{name: "inline code", in: "<p>run <code>go test</code></p>", want: "run go test"},
+		{name: "underscores inside words", in: "<p>snake_case_words</p>", want: "snake_case_words"},
+		{name: "line break", in: "one<br>two", want: "one\ntwo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ansi.Strip(renderHTML(tt.in, 80)); got != tt.want {
+				t.Errorf("renderHTML(%q) = %q, expected %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderRichText_wraps(t *testing.T) {
+	got := ansi.Strip(renderRichText("<p>one two three four</p>", "text/html", 9))
+	if got != "one two\nthree\nfour" {
+		t.Errorf("renderRichText wrapped to %q", got)
+	}
+}
@@ -0,0 +1,432 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/errors"
+	"github.com/go-ap/jsonld"
+	tree "github.com/mariusor/bubbles-tree"
+)
+
+const bulkTimeout = 5 * time.Minute
+
+var (
+	markKey = key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "mark/unmark node"),
+	)
+	markRangeKey = key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "mark nodes since the last marked one"),
+	)
+	markChildrenKey = key.NewBinding(
+		key.WithKeys("ctrl+a"),
+		key.WithHelp("ctrl+a", "mark/unmark all children of current node"),
+	)
+	bulkKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "bulk actions on marked nodes"),
+	)
+	bulkDeleteKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "delete"),
+	)
+	bulkMoveKey = key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "move to collection"),
+	)
+	bulkAddKey = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add to collection"),
+	)
+	bulkExportKey = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export"),
+	)
+	bulkDerefKey = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reload and dereference"),
+	)
+	bulkUnmarkKey = key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "unmark all"),
+	)
+)
+
+// markedNodes returns the marked nodes, including the ones under collapsed parents.
+func markedNodes(nodes tree.Nodes) []*n {
+	marked := make([]*n, 0)
+	for _, nn := range nodes {
+		node, ok := nn.(*n)
+		if !ok || node == nil {
+			continue
+		}
+		if node.s.Is(NodeMarked) {
+			marked = append(marked, node)
+		}
+		marked = append(marked, markedNodes(node.Children())...)
+	}
+	return marked
+}
+
+func (m *model) markedCountCmd() tea.Cmd {
+	return statusMessageCmd("%d nodes marked", len(markedNodes(m.tree.list.Children())))
+}
+
+// toggleMark marks the current node and moves to the next one, so consecutive nodes can be marked quickly.
+func (m *model) toggleMark() tea.Cmd {
+	if m.currentNode == nil {
+		return noop
+	}
+	m.currentNode.s ^= NodeMarked
+	m.markAnchor = m.currentNode
+	return tea.Batch(m.tree.list.MoveDown(1), m.markedCountCmd())
+}
+
+// markRange marks the visible nodes between the last marked node and the current one.
+func (m *model) markRange() tea.Cmd {
+	rows := visibleRows(m.tree.list.Children())
+	from := slices.Index(rows, m.markAnchor)
+	to := slices.Index(rows, m.currentNode)
+	if from < 0 || to < 0 {
+		return errCmd(fmt.Errorf("mark a node first, then move to the end of the range"))
+	}
+	if from > to {
+		from, to = to, from
+	}
+	for _, row := range rows[from : to+1] {
+		row.s |= NodeMarked
+	}
+	m.markAnchor = m.currentNode
+	return m.markedCountCmd()
+}
+
+// markChildren marks the loaded children of the current node, or unmarks them if they're all marked already.
+func (m *model) markChildren() tea.Cmd {
+	if m.currentNode == nil || len(m.currentNode.c) == 0 {
+		return errCmd(fmt.Errorf("current node has no loaded children"))
+	}
+	all := true
+	for _, child := range m.currentNode.c {
+		all = all && child.s.Is(NodeMarked)
+	}
+	for _, child := range m.currentNode.c {
+		if all {
+			child.s &^= NodeMarked
+		} else {
+			child.s |= NodeMarked
+		}
+	}
+	return m.markedCountCmd()
+}
+
+func (m *model) showBulk() tea.Cmd {
+	marked := markedNodes(m.tree.list.Children())
+	if len(marked) == 0 {
+		return errCmd(fmt.Errorf("no marked nodes, use %s to mark them", markKey.Help().Key))
+	}
+	m.focusPager()
+	return m.pager.show(newBulkModel(m.commonModel, marked))
+}
+
+// bulkResultMsg is handled by the main model, which updates the tree, and then by the BulkModel which shows it.
+type bulkResultMsg struct {
+	b      *BulkModel
+	action string
+	done   []*n
+	// removed is set when the done nodes are not in their parent collections anymore.
+	removed bool
+	// items holds the reloaded items of the done nodes.
+	items map[*n]pub.Item
+	errs  []error
+}
+
+// bulkApplied updates the tree after a bulk action.
+func (m *model) bulkApplied(msg bulkResultMsg) tea.Cmd {
+	for _, node := range msg.done {
+		node.s &^= NodeMarked
+		if it, ok := msg.items[node]; ok {
+			node.Item = it
+			node.n = getNameFromItem(it)
+			node.s &^= NodeSynced | NodeError
+		}
+	}
+	if !msg.removed {
+		return noop
+	}
+	for _, node := range msg.done {
+		if node.p != nil {
+			node.p.c = slices.DeleteFunc(node.p.c, func(c *n) bool { return c == node })
+		}
+	}
+	rows := visibleRows(m.tree.list.Children())
+	if slices.Contains(rows, m.currentNode) {
+		return m.keepCursor()
+	}
+	if cursor := m.tree.list.Cursor(); cursor >= len(rows) {
+		return m.tree.list.SetCursor(max(0, len(rows)-1))
+	}
+	return noop
+}
+
+// BulkModel lists the marked nodes and runs actions on all of them.
+type BulkModel struct {
+	*commonModel
+
+	nodes   []*n
+	running string
+	result  *bulkResultMsg
+}
+
+func newBulkModel(common *commonModel, nodes []*n) *BulkModel {
+	return &BulkModel{commonModel: common, nodes: nodes}
+}
+
+func (b *BulkModel) Init() tea.Cmd {
+	return noop
+}
+
+// write runs fn for the marked items, after confirming the writes to the stores of the items, of their parent
+// collections and of the targets.
+func (b *BulkModel) write(action string, removes bool, fn func(context.Context, *n) (pub.Item, error), targets ...pub.IRI) tea.Cmd {
+	iris := slices.Clone(targets)
+	for _, node := range b.nodes {
+		iris = append(iris, node.GetLink())
+		if col, err := parentCollection(node); err == nil {
+			iris = append(iris, col)
+		}
+	}
+	stores := b.f.storesFor(iris...)
+	return confirmWrites(func() tea.Cmd {
+		return b.run(action, removes, fn, stores...)
+	}, stores...)
+}
+
+// run applies fn to the marked items, holding the permission to write to the stores until all of them are done.
+func (b *BulkModel) run(action string, removes bool, fn func(context.Context, *n) (pub.Item, error), stores ...Store) tea.Cmd {
+	b.running = action
+	b.result = nil
+	nodes := slices.Clone(b.nodes)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), bulkTimeout)
+		defer cancel()
+
+		msg := bulkResultMsg{b: b, action: action, removed: removes, items: make(map[*n]pub.Item)}
+		release, err := beginActions(stores...)
+		if err != nil {
+			msg.errs = append(msg.errs, err)
+			return msg
+		}
+		defer release()
+		for _, node := range nodes {
+			if err := ctx.Err(); err != nil {
+				msg.errs = append(msg.errs, err)
+				break
+			}
+			it, err := fn(ctx, node)
+			if err != nil {
+				msg.errs = append(msg.errs, fmt.Errorf("%s: %w", node.GetLink(), err))
+				continue
+			}
+			if it != nil {
+				msg.items[node] = it
+			}
+			msg.done = append(msg.done, node)
+		}
+		return msg
+	}
+}
+
+func parentCollection(node *n) (pub.IRI, error) {
+	if node.p == nil || !iriIsCollection(node.p.GetLink()) {
+		return "", fmt.Errorf("not in a collection")
+	}
+	return node.p.GetLink(), nil
+}
+
+func parseCollectionIRI(s string) (pub.IRI, error) {
+	iri := pub.IRI(strings.TrimSpace(s))
+	if !iriIsCollection(iri) {
+		return "", fmt.Errorf("%q is not a collection IRI", s)
+	}
+	return iri, nil
+}
+
+func (b *BulkModel) delete() tea.Cmd {
+	f := b.f
+	// NOTE(marius): the deleted items are replaced by tombstones, which stay in their collections.
+	return b.write("delete", false, func(_ context.Context, node *n) (pub.Item, error) {
+		if iriIsCollection(node.GetLink()) {
+			return nil, fmt.Errorf("collections can't be deleted")
+		}
+		if err := f.tombstone(node.Item); err != nil {
+			return nil, err
+		}
+		return f.Load(node.GetLink())
+	})
+}
+
+func (b *BulkModel) move(target pub.IRI) tea.Cmd {
+	f := b.f
+	return b.write("move to "+target.String(), true, func(_ context.Context, node *n) (pub.Item, error) {
+		from, err := parentCollection(node)
+		if err != nil {
+			return nil, err
+		}
+		if from.Equals(target, false) {
+			return nil, fmt.Errorf("already in %s", target)
+		}
+		if err := f.AddTo(target, node.GetLink()); err != nil {
+			return nil, err
+		}
+		return nil, f.RemoveFrom(from, node.GetLink())
+	}, target)
+}
+
+func (b *BulkModel) add(target pub.IRI) tea.Cmd {
+	f := b.f
+	return b.write("add to "+target.String(), false, func(_ context.Context, node *n) (pub.Item, error) {
+		return nil, f.AddTo(target, node.GetLink())
+	}, target)
+}
+
+func (b *BulkModel) dereference() tea.Cmd {
+	f := b.f
+	return b.run("reload", false, func(ctx context.Context, node *n) (pub.Item, error) {
+		if iriIsCollection(node.GetLink()) {
+			return nil, fmt.Errorf("collections are reloaded when expanded")
+		}
+		it, err := f.Load(node.GetLink())
+		if err != nil {
+			return nil, err
+		}
+		if err := dereferenceItemProperties(ctx, f, &it); err != nil {
+			return it, err
+		}
+		return it, nil
+	})
+}
+
+func defaultExportPath() string {
+	name := fmt.Sprintf("motley-export-%s.json", time.Now().Format("20060102-150405"))
+	if wd, err := os.Getwd(); err == nil {
+		return filepath.Join(wd, name)
+	}
+	return name
+}
+
+// exportPrompt asks for the path where to export the marked items, and for a confirmation if the file exists.
+func (b *BulkModel) exportPrompt() tea.Cmd {
+	return promptCmd("Export to", defaultExportPath(), func(path string) tea.Cmd {
+		if _, err := os.Stat(path); err == nil {
+			return confirmCmd("Overwrite "+path+"?", func() tea.Cmd {
+				return b.export(path, true)
+			})
+		}
+		return b.export(path, false)
+	})
+}
+
+// export saves the marked items as a JSON-LD array.
+func (b *BulkModel) export(path string, overwrite bool) tea.Cmd {
+	items := make(pub.ItemCollection, 0, len(b.nodes))
+	for _, node := range b.nodes {
+		items = append(items, node.Item)
+	}
+	nodes := slices.Clone(b.nodes)
+	b.running = "export"
+	b.result = nil
+	return func() tea.Msg {
+		msg := bulkResultMsg{b: b, action: "export to " + path}
+		data, err := jsonld.WithContext(jsonld.IRI(pub.ActivityBaseURI)).Marshal(items)
+		if err == nil {
+			err = writeFile(path, data, overwrite)
+		}
+		if err != nil {
+			msg.errs = append(msg.errs, errors.Annotatef(err, "unable to export"))
+			return msg
+		}
+		msg.done = nodes
+		return msg
+	}
+}
+
+func (b *BulkModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case bulkResultMsg:
+		if mm.b != b {
+			return b, noop
+		}
+		b.running = ""
+		b.result = &mm
+		b.nodes = slices.DeleteFunc(b.nodes, func(node *n) bool { return !node.s.Is(NodeMarked) })
+		return b, statusMessageCmd("%s: %d done, %d failed", mm.action, len(mm.done), len(mm.errs))
+	case tea.KeyPressMsg:
+		if b.running != "" {
+			return b, noop
+		}
+		switch {
+		case key.Matches(mm, bulkDeleteKey):
+			return b, confirmCmd(fmt.Sprintf("Delete %d items?", len(b.nodes)), func() tea.Cmd {
+				return b.delete()
+			})
+		case key.Matches(mm, bulkMoveKey):
+			return b, promptCmd("Move to collection", "", func(s string) tea.Cmd {
+				target, err := parseCollectionIRI(s)
+				if err != nil {
+					return errCmd(err)
+				}
+				return b.move(target)
+			})
+		case key.Matches(mm, bulkAddKey):
+			return b, promptCmd("Add to collection", "", func(s string) tea.Cmd {
+				target, err := parseCollectionIRI(s)
+				if err != nil {
+					return errCmd(err)
+				}
+				return b.add(target)
+			})
+		case key.Matches(mm, bulkExportKey):
+			return b, b.exportPrompt()
+		case key.Matches(mm, bulkDerefKey):
+			return b, b.dereference()
+		case key.Matches(mm, bulkUnmarkKey):
+			for _, node := range b.nodes {
+				node.s &^= NodeMarked
+			}
+			b.nodes = b.nodes[:0]
+			return b, statusMessageCmd("unmarked all nodes")
+		}
+	}
+	return b, noop
+}
+
+func (b *BulkModel) View() tea.View {
+	pieces := []string{viewTitleStyle.Render("Bulk actions"), fmt.Sprintf("%d marked nodes", len(b.nodes))}
+	if b.running != "" {
+		pieces = append(pieces, "", "Running "+b.running+ellipsis)
+	}
+	if r := b.result; r != nil {
+		pieces = append(pieces, "", fmt.Sprintf("%s: %d done, %d failed", r.action, len(r.done), len(r.errs)))
+		for _, err := range r.errs {
+			pieces = append(pieces, faintRedFg.Render(err.Error()))
+		}
+	}
+	pieces = append(pieces, "")
+	for _, node := range b.nodes {
+		pieces = append(pieces, fmt.Sprintf("%s %s", ItemType(node.Item), node.GetLink()))
+	}
+
+	pieces = append(pieces, "", helpLine(bulkDeleteKey, bulkMoveKey, bulkAddKey, bulkExportKey, bulkDerefKey, bulkUnmarkKey))
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
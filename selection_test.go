@@ -0,0 +1,127 @@
+package motley
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+func newTestSelection(t *testing.T, count int) (*model, *n) {
+	t.Helper()
+	m, st := newTestModel(t)
+	parent := node(pub.Outbox.IRI(st.root))
+	children := make([]*n, 0, count)
+	for i := range count {
+		children = append(children, node(&pub.Object{ID: pub.IRI(fmt.Sprintf("https://example.com/%d", i)), Type: pub.NoteType}))
+	}
+	parent.setChildren(children...)
+	m.tree.Advance(parent)
+	m.currentNode = parent.c[0]
+	m.currentNodePosition = 1
+	m.focusTree()
+	return m, parent
+}
+
+func TestMark_space(t *testing.T) {
+	m, parent := newTestSelection(t, 3)
+
+	m.update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	if !parent.c[0].s.Is(NodeMarked) {
+		t.Fatalf("space should mark the current node")
+	}
+	if marked := markedNodes(m.tree.list.Children()); len(marked) != 1 || marked[0] != parent.c[0] {
+		t.Errorf("expected only the current node to be marked, got %d", len(marked))
+	}
+}
+
+func TestMark_range(t *testing.T) {
+	m, parent := newTestSelection(t, 4)
+
+	m.toggleMark()
+	m.currentNode = parent.c[2]
+	m.markRange()
+	for i, child := range parent.c {
+		if marked := child.s.Is(NodeMarked); marked != (i <= 2) {
+			t.Errorf("node %d marked: %t, expected %t", i, marked, i <= 2)
+		}
+	}
+}
+
+func TestMark_children(t *testing.T) {
+	m, parent := newTestSelection(t, 3)
+	m.currentNode = parent
+
+	m.markChildren()
+	if marked := markedNodes(m.tree.list.Children()); len(marked) != 3 {
+		t.Errorf("all children should be marked, got %d", len(marked))
+	}
+	m.markChildren()
+	if marked := markedNodes(m.tree.list.Children()); len(marked) != 0 {
+		t.Errorf("marking the children again should unmark them, got %d", len(marked))
+	}
+}
+
+func TestBulkApplied_removesNodes(t *testing.T) {
+	m, parent := newTestSelection(t, 3)
+	removed := parent.c[1]
+	removed.s |= NodeMarked
+
+	m.bulkApplied(bulkResultMsg{action: "move to https://example.com/inbox", done: []*n{removed}, removed: true})
+	if len(parent.c) != 2 {
+		t.Fatalf("the removed node should be dropped from its parent, got %d children", len(parent.c))
+	}
+	if removed.s.Is(NodeMarked) {
+		t.Errorf("the done nodes should be unmarked")
+	}
+}
+
+func TestBulk_exportConfirmsOverwrite(t *testing.T) {
+	m, parent := newTestSelection(t, 2)
+	b := newBulkModel(m.commonModel, parent.c)
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+		t.Fatalf("unable to create file: %s", err)
+	}
+
+	_, cmd := b.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	prompt, ok := findMsg[promptMsg](cmd)
+	if !ok {
+		t.Fatalf("exporting should ask for the path")
+	}
+	confirm, ok := findMsg[promptMsg](prompt.submitFn(path))
+	if !ok {
+		t.Fatalf("exporting to an existing file should ask for confirmation")
+	}
+	if cmd := confirm.submitFn("n"); cmd != nil {
+		t.Errorf("declining should not export")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Errorf("the existing file should be kept, got %q", data)
+	}
+}
+
+func TestBulk_deleteKeepsTombstones(t *testing.T) {
+	m, st := newTestModel(t)
+	note := &pub.Object{ID: "https://example.com/notes/1", Type: pub.NoteType}
+	if _, err := st.s.Save(note); err != nil {
+		t.Fatalf("unable to save note: %s", err)
+	}
+	parent := node(pub.Outbox.IRI(st.root))
+	parent.setChildren(node(note))
+	b := newBulkModel(m.commonModel, parent.c)
+
+	msg, ok := findMsg[bulkResultMsg](b.delete())
+	if !ok {
+		t.Fatalf("deleting should return the result")
+	}
+	if msg.removed || len(msg.done) != 1 {
+		t.Fatalf("the tombstones should stay in their collection, removed %t, %d done, errors %v", msg.removed, len(msg.done), msg.errs)
+	}
+	if it := msg.items[parent.c[0]]; it == nil || it.GetType() != pub.TombstoneType {
+		t.Errorf("the deleted node should show the tombstone, got %v", it)
+	}
+}
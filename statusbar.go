@@ -29,6 +29,7 @@ func (s statusState) Is(st statusState) bool {
 const (
 	statusBarHeight = 1
 	lockIcon        = "🔒"
+	readOnlyIcon    = "⛔"
 )
 
 const (
@@ -44,6 +45,7 @@ type statusModel struct {
 	width int
 	state statusState
 	env   env.Type
+	guard *writeGuard
 
 	spinner spinner.Model
 	percent float64
@@ -102,6 +104,9 @@ func (s *statusModel) statusBarView(b *strings.Builder) {
 
 	spinner := s.spinner.View()
 	logo := logoView(name(s.root), s.env)
+	if s.guard.locked() {
+		logo += readOnlyView()
+	}
 
 	// Empty space
 	w := max(0, s.width-lipgloss.Width(spinner)-lipgloss.Width(logo)-lipgloss.Width(scrollPercent)-1)
@@ -139,6 +144,9 @@ func (a statusNode) View() string {
 	case pub.ItemCollection:
 		fmt.Fprintf(&s, "%s %s: %d items", ItemType(it), a.n, len(a.c))
 	case pub.Item:
+		if a.s.Is(NodeLocked) {
+			s.WriteString(lockIcon + " ")
+		}
 		fmt.Fprintf(&s, "%s: %s", ItemType(it), it.GetID())
 	}
 	return s.String()
@@ -156,6 +164,18 @@ func (s *statusModel) Update(msg tea.Msg) tea.Cmd {
 		}
 	case nodeUpdateMsg:
 		cmd = s.showStatusMessage(statusNode(mm).View())
+	case nodeRefreshMsg:
+		cmd = s.showStatusMessage(statusNode(mm).View())
+	case statusMessageMsg:
+		cmd = s.showStatusMessage(string(mm))
+	case loadingMsg:
+		if mm {
+			s.state |= statusBusy
+			cmd = s.spinner.Tick
+		} else {
+			s.state &^= statusBusy
+			s.spinner = initializeSpinner()
+		}
 	case statusState:
 		s.state |= mm
 		if !s.state.Is(statusBusy) {
@@ -251,6 +271,11 @@ func logoView(text string, e env.Type) string {
 	return lipgloss.NewStyle().Bold(true).Foreground(fg).Background(bg).Render(withPadding(text, len(text)))
 }
 
+func readOnlyView() string {
+	text := readOnlyIcon + " RO"
+	return lipgloss.NewStyle().Bold(true).Reverse(true).Render(withPadding(text, lipgloss.Width(text)))
+}
+
 // Lightweight version of reflow's indent function.
 func indent(b *strings.Builder, s string, n int) {
 	if n <= 0 || s == "" {
@@ -264,6 +289,14 @@ func indent(b *strings.Builder, s string, n int) {
 	}
 }
 
+type statusMessageMsg string
+
+func statusMessageCmd(format string, args ...any) tea.Cmd {
+	return func() tea.Msg {
+		return statusMessageMsg(fmt.Sprintf(format, args...))
+	}
+}
+
 func showHelpCmd() tea.Cmd {
 	return func() tea.Msg {
 		return statusHelp
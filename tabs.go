@@ -0,0 +1,218 @@
+package motley
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"git.sr.ht/~mariusor/motley/internal/env"
+	"github.com/charmbracelet/x/ansi"
+	vocab "github.com/go-ap/activitypub"
+)
+
+var (
+	newTabKey = key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "open a new tab"),
+	)
+	closeTabKey = key.NewBinding(
+		key.WithKeys("ctrl+w"),
+		key.WithHelp("ctrl+w", "close the current tab"),
+	)
+	nextTabKey = key.NewBinding(
+		key.WithKeys("ctrl+n", "ctrl+pgdown"),
+		key.WithHelp("ctrl+n", "move to the next tab"),
+	)
+	prevTabKey = key.NewBinding(
+		key.WithKeys("ctrl+p", "ctrl+pgup"),
+		key.WithHelp("ctrl+p", "move to the previous tab"),
+	)
+)
+
+const maxTabTitleWidth = 24
+
+// tab holds the state of a browsing session.
+// The state of the active tab lives in the model, the tab is updated only when moving away from it.
+type tab struct {
+	tree                treeModel
+	pager               pagerModel
+	currentNode         *n
+	currentNodePosition int
+	history             history
+	root                vocab.Item
+	env                 env.Type
+	guard               *writeGuard
+	loaders             loaders
+	watch               *watcher
+	// pending holds the results of the loading operations which finished while the tab was stashed.
+	pending []tea.Msg
+}
+
+// backgroundMsg is implemented by the messages of the loops which keep running in the views of the stashed tabs.
+type backgroundMsg interface {
+	background()
+}
+
+func (m *model) stashTab() {
+	m.tabs[m.activeTab] = tab{
+		tree:                m.tree,
+		pager:               m.pager,
+		currentNode:         m.currentNode,
+		currentNodePosition: m.currentNodePosition,
+		history:             m.history,
+		root:                m.root,
+		env:                 m.status.env,
+		guard:               m.status.guard,
+		loaders:             m.loaders,
+		watch:               m.watch,
+	}
+}
+
+// loadTab makes tab i the active one, and returns the results of the loading operations which finished
+// while it was stashed.
+func (m *model) loadTab(i int) tea.Cmd {
+	t := m.tabs[i]
+	m.activeTab = i
+	m.tree = t.tree
+	m.pager = t.pager
+	m.currentNode = t.currentNode
+	m.currentNodePosition = t.currentNodePosition
+	m.history = t.history
+	m.root = t.root
+	m.status.env = t.env
+	m.status.guard = t.guard
+	m.loaders = t.loaders
+	m.watch = t.watch
+	m.tabs[i].pending = nil
+	m.setSize(m.width, m.height)
+
+	cmds := []tea.Cmd{loadingCmd(m.loaders.busy())}
+	for _, msg := range t.pending {
+		cmds = append(cmds, func() tea.Msg { return msg })
+	}
+	return tea.Batch(cmds...)
+}
+
+// deferToTab keeps the result of a loading operation of a stashed tab until the tab becomes active again.
+func (m *model) deferToTab(gen uint64, msg tea.Msg) bool {
+	for i := range m.tabs {
+		if i != m.activeTab && m.tabs[i].loaders.owns(gen) {
+			m.tabs[i].pending = append(m.tabs[i].pending, msg)
+			return true
+		}
+	}
+	return false
+}
+
+// updateStashedPagers sends msg to the views of the stashed tabs.
+func (m *model) updateStashedPagers(msg tea.Msg) tea.Cmd {
+	cmds := make([]tea.Cmd, 0)
+	for i := range m.tabs {
+		if i == m.activeTab {
+			continue
+		}
+		p, cmd := m.tabs[i].pager.Update(msg)
+		m.tabs[i].pager, _ = p.(pagerModel)
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *model) switchTab(i int) tea.Cmd {
+	if len(m.tabs) < 2 {
+		return noop
+	}
+	i = (i + len(m.tabs)) % len(m.tabs)
+	if i == m.activeTab {
+		return noop
+	}
+	m.stashTab()
+	cmd := m.loadTab(i)
+	if m.currentNode != nil {
+		return tea.Batch(cmd, nodeUpdateCmd(*m.currentNode))
+	}
+	return cmd
+}
+
+func (m *model) openTab() tea.Cmd {
+	if m.f == nil {
+		return noop
+	}
+	m.stashTab()
+	m.tabs = append(m.tabs, tab{
+		tree:  newTreeModel(m.commonModel, initNodes(m.f)),
+		pager: newItemModel(m.commonModel),
+	})
+	cmd := m.loadTab(len(m.tabs) - 1)
+	return tea.Batch(cmd, m.tree.Init(), m.pager.show(newDashboardModel(m.commonModel)), m.focusTree())
+}
+
+func (m *model) closeTab() tea.Cmd {
+	if len(m.tabs) < 2 {
+		return errCmd(fmt.Errorf("unable to close the last tab"))
+	}
+	m.abortLoading()
+	m.stopWatching()
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	cmd := m.loadTab(min(m.activeTab, len(m.tabs)-1))
+	m.saveHistory()
+	if m.currentNode != nil {
+		return tea.Batch(cmd, nodeUpdateCmd(*m.currentNode))
+	}
+	return cmd
+}
+
+// tabsHeight returns the height of the tab bar, which is shown only when there are multiple tabs.
+func (m *model) tabsHeight() int {
+	if len(m.tabs) < 2 {
+		return 0
+	}
+	return 1
+}
+
+func (m *model) tabTitle(i int) string {
+	current := m.tabs[i].currentNode
+	if i == m.activeTab {
+		current = m.currentNode
+	}
+	title := "new tab"
+	if current != nil {
+		title = current.n
+	}
+	title = fmt.Sprintf("%d %s", i+1, title)
+	return ansi.Truncate(title, maxTabTitleWidth, ellipsis)
+}
+
+// updateTabs renders the tab bar, and records the position of each tab so they can be clicked.
+func (m *model) updateTabs(width int) {
+	activeStyle := lipgloss.NewStyle().Bold(true).Foreground(hintColor).Padding(0, 1)
+	inactiveStyle := lipgloss.NewStyle().Faint(true).Padding(0, 1)
+
+	spans := make([]crumbSpan, 0, len(m.tabs))
+	pieces := make([]string, 0, len(m.tabs))
+	pos := 0
+	for i := range m.tabs {
+		style := inactiveStyle
+		if i == m.activeTab {
+			style = activeStyle
+		}
+		label := style.Render(m.tabTitle(i))
+		w := lipgloss.Width(label)
+		spans = append(spans, crumbSpan{start: pos, end: pos + w, index: i})
+		pieces = append(pieces, label)
+		pos += w
+	}
+	m.tabsBar = lipgloss.NewStyle().Width(width).MaxWidth(width).Render(strings.Join(pieces, ""))
+	m.tabSpans = spans
+}
+
+func (m *model) tabAt(x int) (int, bool) {
+	for _, s := range m.tabSpans {
+		if x >= s.start && x < s.end {
+			return s.index, true
+		}
+	}
+	return -1, false
+}
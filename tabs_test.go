@@ -0,0 +1,90 @@
+package motley
+
+import (
+	"testing"
+
+	"git.sr.ht/~mariusor/motley/internal/env"
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestTabs_deferLoading(t *testing.T) {
+	m, st := newTestModel(t)
+	parent := node(pub.Outbox.IRI(st.root))
+	_, gen := m.startLoading(&m.loaders.preview)
+
+	m.openTab()
+	if m.loaders.busy() {
+		t.Fatalf("the new tab should not be loading")
+	}
+	msg := childrenLoadedMsg{gen: gen, node: parent, children: []*n{node(pub.IRI("https://example.com/1"))}}
+	m.update(msg)
+	if len(parent.c) != 0 {
+		t.Errorf("the results of the stashed tab should not be applied while it's stashed")
+	}
+	if len(m.tabs[0].pending) != 1 {
+		t.Fatalf("the results of the stashed tab should be kept, got %d pending", len(m.tabs[0].pending))
+	}
+
+	if cmd := m.switchTab(0); cmd == nil {
+		t.Fatalf("switching to the tab should replay its pending results")
+	}
+	if m.loaders.preview.gen != gen || len(m.tabs[0].pending) != 0 {
+		t.Errorf("the tab should keep its loader and consume its pending results")
+	}
+	m.update(msg)
+	if len(parent.c) != 1 {
+		t.Errorf("the replayed results should be applied to the active tab")
+	}
+}
+
+func TestTabs_tailKeepsRunning(t *testing.T) {
+	m, st := newTestModel(t)
+	tail := newTailModel(m.commonModel, pub.Outbox.IRI(st.root), 10)
+	m.pager.show(tail)
+	m.openTab()
+
+	it := &pub.Activity{ID: "https://example.com/1", Type: pub.CreateType}
+	cmd := m.update(tailLoadedMsg{t: tail, items: pub.ItemCollection{it}})
+	if len(tail.items) != 1 {
+		t.Errorf("the tail of the stashed tab should receive its results, got %d items", len(tail.items))
+	}
+	if cmd == nil {
+		t.Errorf("the tail of the stashed tab should schedule its next tick")
+	}
+}
+
+func TestTabs_watchPerTab(t *testing.T) {
+	m, _ := newTestModel(t)
+	m.toggleWatch()
+	t.Cleanup(func() {
+		if w, _, _ := m.watcherFor(m.watchGen); w != nil && w.notifier != nil {
+			_ = w.notifier.Close()
+		}
+	})
+	gen := m.watch.gen
+
+	m.openTab()
+	if m.watch != nil {
+		t.Errorf("the new tab should not be watching")
+	}
+	w, tr, active := m.watcherFor(gen)
+	if w == nil || active || tr != &m.tabs[0].tree {
+		t.Errorf("the watcher should belong to the stashed tab")
+	}
+	if cmd := m.watchTick(gen); cmd == nil {
+		t.Errorf("the watcher of the stashed tab should keep ticking")
+	}
+}
+
+func TestTabs_keepGuard(t *testing.T) {
+	m, st := newTestModel(t)
+	guard := newWriteGuard(st.root, env.PROD, true, 0)
+	m.status.guard = guard
+
+	m.openTab()
+	m.status.guard = nil
+	m.switchTab(0)
+	if m.status.guard != guard {
+		t.Errorf("switching back to the tab should restore the guard of its store")
+	}
+}
@@ -0,0 +1,262 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+)
+
+const (
+	tailInterval  = 2 * time.Second
+	tailHeadCount = 50
+	tailMaxItems  = 1000
+)
+
+var (
+	tailKey = key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "follow new activities in current collection"),
+	)
+	tailPauseKey = key.NewBinding(
+		key.WithKeys("space", "p"),
+		key.WithHelp("space", "pause/resume"),
+	)
+	tailFilterKey = key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter by type"),
+	)
+)
+
+type tailTickMsg struct {
+	t *TailModel
+}
+
+type tailLoadedMsg struct {
+	t     *TailModel
+	items pub.ItemCollection
+	err   error
+}
+
+// NOTE(marius): the tail keeps following the collection while its tab is stashed.
+func (tailTickMsg) background()   {}
+func (tailLoadedMsg) background() {}
+
+// TailModel follows a collection, showing one line per activity, in the order they arrive.
+type TailModel struct {
+	*commonModel
+
+	iri    pub.IRI
+	height int
+
+	items   pub.ItemCollection
+	pending pub.ItemCollection
+	seen    map[pub.IRI]struct{}
+	types   pub.ActivityVocabularyTypes
+	paused  bool
+	err     error
+}
+
+func newTailModel(common *commonModel, iri pub.IRI, height int) *TailModel {
+	return &TailModel{
+		commonModel: common,
+		iri:         iri,
+		height:      height,
+		seen:        make(map[pub.IRI]struct{}),
+	}
+}
+
+func (t *TailModel) Init() tea.Cmd {
+	return t.load()
+}
+
+func (t *TailModel) tick() tea.Cmd {
+	return tea.Tick(tailInterval, func(time.Time) tea.Msg {
+		return tailTickMsg{t: t}
+	})
+}
+
+// load fetches the head of the collection, the new items are picked when the result is received.
+func (t *TailModel) load() tea.Cmd {
+	f := t.f
+	iri := t.iri
+	seen := maps.Clone(t.seen)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), tailInterval)
+		defer cancel()
+
+		items := make(pub.ItemCollection, 0)
+		accum := func(ctx context.Context, col pub.CollectionInterface) error {
+			for _, it := range col.Collection() {
+				if _, ok := seen[it.GetLink()]; !ok {
+					items = append(items, it)
+				}
+			}
+			if len(items) >= tailHeadCount {
+				return StopLoad{}
+			}
+			return nil
+		}
+		err := accumFn(accum).LoadFromSearch(ctx, f, iri, filters.WithMaxCount(tailHeadCount))
+		for _, it := range items {
+			_ = pub.OnActivity(it, func(act *pub.Activity) error {
+				act.Actor = loadIfIRI(f, act.Actor)
+				act.Object = loadIfIRI(f, act.Object)
+				return nil
+			})
+		}
+		return tailLoadedMsg{t: t, items: items, err: err}
+	}
+}
+
+// received appends the items we haven't seen yet, oldest first.
+func (t *TailModel) received(items pub.ItemCollection) {
+	fresh := make(pub.ItemCollection, 0)
+	for _, it := range items {
+		if _, ok := t.seen[it.GetLink()]; ok {
+			continue
+		}
+		t.seen[it.GetLink()] = struct{}{}
+		fresh = append(fresh, it)
+	}
+	sortByPublished(fresh)
+	for i := len(fresh) - 1; i >= 0; i-- {
+		if t.paused {
+			t.pending = append(t.pending, fresh[i])
+		} else {
+			t.items = append(t.items, fresh[i])
+		}
+	}
+	if over := len(t.items) - tailMaxItems; over > 0 {
+		t.items = t.items[over:]
+	}
+}
+
+func (t *TailModel) togglePause() {
+	t.paused = !t.paused
+	if !t.paused {
+		t.items = append(t.items, t.pending...)
+		t.pending = t.pending[:0]
+	}
+}
+
+func parseTypes(s string) pub.ActivityVocabularyTypes {
+	types := make(pub.ActivityVocabularyTypes, 0)
+	for _, typ := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		types = append(types, pub.ActivityVocabularyType(typ))
+	}
+	return types
+}
+
+func (t *TailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case tailLoadedMsg:
+		if mm.t != t {
+			return t, noop
+		}
+		t.err = mm.err
+		t.received(mm.items)
+		return t, t.tick()
+	case tailTickMsg:
+		if mm.t != t {
+			return t, noop
+		}
+		return t, t.load()
+	case tailTypesMsg:
+		t.types = pub.ActivityVocabularyTypes(mm)
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(mm, tailPauseKey):
+			t.togglePause()
+		case key.Matches(mm, tailFilterKey):
+			return t, promptCmd("Filter by type", joinTypes(t.types), func(s string) tea.Cmd {
+				return tailTypesCmd(parseTypes(s))
+			})
+		}
+	}
+	return t, noop
+}
+
+type tailTypesMsg pub.ActivityVocabularyTypes
+
+func tailTypesCmd(types pub.ActivityVocabularyTypes) tea.Cmd {
+	return func() tea.Msg {
+		return tailTypesMsg(types)
+	}
+}
+
+func joinTypes(types pub.ActivityVocabularyTypes) string {
+	s := make([]string, 0, len(types))
+	for _, typ := range types {
+		s = append(s, string(typ))
+	}
+	return strings.Join(s, ",")
+}
+
+func (t *TailModel) line(it pub.Item) string {
+	verbStyle := lipgloss.NewStyle().Bold(true).Foreground(Indigo)
+
+	actor, object := "", ""
+	_ = pub.OnIntransitiveActivity(it, func(act *pub.IntransitiveActivity) error {
+		if !pub.IsNil(act.Actor) {
+			actor = getNameFromItem(act.Actor)
+		}
+		return nil
+	})
+	_ = pub.OnActivity(it, func(act *pub.Activity) error {
+		if !pub.IsNil(act.Object) {
+			object = getNameFromItem(act.Object)
+		}
+		return nil
+	})
+	pieces := []string{faintStyle.Render(published(it).Local().Format(timelineTimeFmt))}
+	if actor != "" {
+		pieces = append(pieces, actor)
+	}
+	pieces = append(pieces, verbStyle.Render(ItemType(it)))
+	if object != "" {
+		pieces = append(pieces, object)
+	}
+	return strings.Join(pieces, " ")
+}
+
+func (t *TailModel) View() tea.View {
+	state := "following"
+	if t.paused {
+		state = fmt.Sprintf("paused, %d new", len(t.pending))
+	}
+	if len(t.types) > 0 {
+		state += ", only " + joinTypes(t.types)
+	}
+	header := []string{viewTitleStyle.Render("Tail"), fmt.Sprintf("%s: %s", t.iri, state)}
+	if t.err != nil {
+		header = append(header, faintRedFg.Render(t.err.Error()))
+	}
+
+	footer := []string{"", helpLine(tailPauseKey, tailFilterKey)}
+
+	lines := make([]string, 0, len(t.items))
+	for _, it := range t.items {
+		if len(t.types) > 0 && !t.types.Match(it.GetType()) {
+			continue
+		}
+		lines = append(lines, t.line(it))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "", "Waiting for activities")
+	}
+	// NOTE(marius): like tail, we show the last lines that fit in the pager.
+	if room := t.height - len(header) - len(footer) - 1; room > 0 && len(lines) > room {
+		lines = lines[len(lines)-room:]
+	}
+
+	pieces := append(append(header, lines...), footer...)
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
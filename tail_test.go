@@ -0,0 +1,22 @@
+package motley
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestTail_pauseWithSpace(t *testing.T) {
+	m, st := newTestModel(t)
+	tail := newTailModel(m.commonModel, pub.Outbox.IRI(st.root), 10)
+
+	tail.Update(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	if !tail.paused {
+		t.Fatalf("space should pause the tail")
+	}
+	tail.Update(tea.KeyPressMsg{Code: 'p', Text: "p"})
+	if tail.paused {
+		t.Errorf("p should resume the tail")
+	}
+}
@@ -0,0 +1,363 @@
+package motley
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	pub "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+)
+
+const (
+	timelineMaxItems = 200
+	timelineDayFmt   = "Monday, 02 Jan 2006"
+	timelineTimeFmt  = "15:04:05"
+)
+
+var (
+	timelineKey = key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "show timeline for current collection"),
+	)
+	timelineJumpKey = key.NewBinding(
+		key.WithKeys("@"),
+		key.WithHelp("@", "jump to date"),
+	)
+	timelinePrevKey = key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous time range"),
+	)
+	timelineNextKey = key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next time range"),
+	)
+)
+
+// publishedBetween is a filters.Check that matches items with their Published property in the [from, to) interval.
+// A zero value for any of the ends leaves the interval open.
+type publishedBetween struct {
+	from time.Time
+	to   time.Time
+}
+
+func (p publishedBetween) Match(it pub.Item) bool {
+	if pub.IsNil(it) {
+		return false
+	}
+	published := time.Time{}
+	_ = pub.OnObject(it, func(ob *pub.Object) error {
+		published = ob.Published
+		return nil
+	})
+	if published.IsZero() {
+		return false
+	}
+	if !p.from.IsZero() && published.Before(p.from) {
+		return false
+	}
+	if !p.to.IsZero() && !published.Before(p.to) {
+		return false
+	}
+	return true
+}
+
+func (p publishedBetween) isZero() bool {
+	return p.from.IsZero() && p.to.IsZero()
+}
+
+func (p publishedBetween) String() string {
+	if p.isZero() {
+		return "all time"
+	}
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "…"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("%s – %s", format(p.from), format(p.to))
+}
+
+// shift moves the interval by its own width, backwards for negative values of dir, forwards otherwise.
+func (p publishedBetween) shift(dir int) publishedBetween {
+	if p.from.IsZero() || p.to.IsZero() {
+		return p
+	}
+	d := p.to.Sub(p.from)
+	if dir < 0 {
+		d = -d
+	}
+	return publishedBetween{from: p.from.Add(d), to: p.to.Add(d)}
+}
+
+var timelineDateFormats = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"15:04",
+}
+
+// parseTimelineRange converts a user supplied date to a time interval.
+// A date without time covers the whole day, a date with an hour covers one hour before and after it.
+// The date can be prefixed with "today" or "yesterday" instead of an explicit day, eg: "yesterday 14:00".
+// Two dates separated by ".." cover the interval from the start of the first to the end of the second.
+func parseTimelineRange(s string, now time.Time) (publishedBetween, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return publishedBetween{}, nil
+	}
+	if start, end, ok := strings.Cut(s, ".."); ok {
+		from, err := parseTimelineDate(start, now)
+		if err != nil {
+			return publishedBetween{}, err
+		}
+		to, err := parseTimelineDate(end, now)
+		if err != nil {
+			return publishedBetween{}, err
+		}
+		if !to.to.After(from.from) {
+			return publishedBetween{}, fmt.Errorf("invalid range %q, the end is before the start", s)
+		}
+		return publishedBetween{from: from.from, to: to.to}, nil
+	}
+	return parseTimelineDate(s, now)
+}
+
+func parseTimelineDate(s string, now time.Time) (publishedBetween, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return publishedBetween{}, fmt.Errorf("missing date, expected YYYY-MM-DD [HH:MM]")
+	}
+
+	day := now
+	switch {
+	case strings.HasPrefix(s, "today"):
+		s = strings.TrimSpace(strings.TrimPrefix(s, "today"))
+	case strings.HasPrefix(s, "yesterday"):
+		s = strings.TrimSpace(strings.TrimPrefix(s, "yesterday"))
+		day = now.AddDate(0, 0, -1)
+	}
+	startOfDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, now.Location())
+	if s == "" {
+		return publishedBetween{from: startOfDay, to: startOfDay.AddDate(0, 0, 1)}, nil
+	}
+
+	for _, format := range timelineDateFormats {
+		t, err := time.ParseInLocation(format, s, now.Location())
+		if err != nil {
+			continue
+		}
+		switch format {
+		case "2006-01-02":
+			return publishedBetween{from: t, to: t.AddDate(0, 0, 1)}, nil
+		case "15:04":
+			t = startOfDay.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+		}
+		return publishedBetween{from: t.Add(-time.Hour), to: t.Add(time.Hour)}, nil
+	}
+	return publishedBetween{}, fmt.Errorf("unable to parse date %q, expected YYYY-MM-DD [HH:MM]", s)
+}
+
+func timelineEligible(nn *n) bool {
+	if nn == nil || pub.IsNil(nn.Item) {
+		return false
+	}
+	if nn.p != nil && nn.p.n == "streams" {
+		return true
+	}
+	iri := nn.GetLink()
+	if _, typ := pub.Split(iri); pub.ValidActivityCollection(typ) {
+		return true
+	}
+	if _, typ := filters.FedBOXCollections.Split(iri); filters.ValidActivityCollection(typ) {
+		return true
+	}
+	return false
+}
+
+type timelineLoadedMsg struct {
+	iri   pub.IRI
+	rng   publishedBetween
+	items pub.ItemCollection
+	err   error
+}
+
+// TimelineModel shows the activities in a collection sorted by their Published date and grouped by day.
+type TimelineModel struct {
+	*commonModel
+
+	iri   pub.IRI
+	rng   publishedBetween
+	items pub.ItemCollection
+	err   error
+}
+
+func newTimelineModel(common *commonModel, iri pub.IRI) *TimelineModel {
+	return &TimelineModel{commonModel: common, iri: iri}
+}
+
+func (t *TimelineModel) Init() tea.Cmd {
+	return t.load(t.rng)
+}
+
+func (t *TimelineModel) load(rng publishedBetween) tea.Cmd {
+	f := t.f
+	iri := t.iri
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// NOTE(marius): the published interval is part of the storage query, so it gets applied on the whole
+		// collection before it's paginated with the max count, not only on the first page of results.
+		ff := make([]filters.Check, 0, 2)
+		if !rng.isZero() {
+			ff = append(ff, rng)
+		}
+		ff = append(ff, filters.WithMaxCount(timelineMaxItems))
+		items := make(pub.ItemCollection, 0)
+		accum := func(ctx context.Context, col pub.CollectionInterface) error {
+			items = append(items, col.Collection()...)
+			if len(items) >= timelineMaxItems {
+				return StopLoad{}
+			}
+			return nil
+		}
+		err := accumFn(accum).LoadFromSearch(ctx, f, iri, ff...)
+		for _, it := range items {
+			_ = pub.OnActivity(it, func(act *pub.Activity) error {
+				act.Actor = loadIfIRI(f, act.Actor)
+				act.Object = loadIfIRI(f, act.Object)
+				return nil
+			})
+		}
+		sortByPublished(items)
+		return timelineLoadedMsg{iri: iri, rng: rng, items: items, err: err}
+	}
+}
+
+func loadIfIRI(f *fedbox, it pub.Item) pub.Item {
+	if pub.IsNil(it) || !pub.IsIRI(it) {
+		return it
+	}
+	if pub.PublicNS.Equals(it.GetLink(), false) {
+		return it
+	}
+	if loaded, err := f.Load(it.GetLink()); err == nil && !pub.IsNil(loaded) {
+		return loaded
+	}
+	return it
+}
+
+func published(it pub.Item) time.Time {
+	var t time.Time
+	_ = pub.OnObject(it, func(ob *pub.Object) error {
+		t = ob.Published
+		return nil
+	})
+	return t
+}
+
+func sortByPublished(items pub.ItemCollection) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return published(items[i]).After(published(items[j]))
+	})
+}
+
+func (t *TimelineModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch mm := msg.(type) {
+	case timelineLoadedMsg:
+		if !mm.iri.Equals(t.iri, true) {
+			return t, noop
+		}
+		t.rng = mm.rng
+		t.items = mm.items
+		t.err = mm.err
+		if mm.err != nil {
+			return t, errCmd(mm.err)
+		}
+	case timelineRangeMsg:
+		return t, t.load(publishedBetween(mm))
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(mm, timelinePrevKey):
+			return t, t.load(t.rng.shift(-1))
+		case key.Matches(mm, timelineNextKey):
+			return t, t.load(t.rng.shift(1))
+		case key.Matches(mm, timelineJumpKey):
+			return t, promptCmd("Jump to date (YYYY-MM-DD [HH:MM] or FROM..TO)", "", func(s string) tea.Cmd {
+				rng, err := parseTimelineRange(s, time.Now())
+				if err != nil {
+					return errCmd(err)
+				}
+				return timelineRangeCmd(rng)
+			})
+		}
+	}
+	return t, noop
+}
+
+type timelineRangeMsg publishedBetween
+
+func timelineRangeCmd(rng publishedBetween) tea.Cmd {
+	return func() tea.Msg {
+		return timelineRangeMsg(rng)
+	}
+}
+
+func summarizeActivity(it pub.Item) string {
+	verb := ItemType(it)
+	actor := ""
+	object := ""
+	_ = pub.OnIntransitiveActivity(it, func(act *pub.IntransitiveActivity) error {
+		if !pub.IsNil(act.Actor) {
+			actor = getNameFromItem(act.Actor)
+		}
+		return nil
+	})
+	_ = pub.OnActivity(it, func(act *pub.Activity) error {
+		if !pub.IsNil(act.Object) {
+			object = getNameFromItem(act.Object)
+		}
+		return nil
+	})
+	pieces := make([]string, 0, 3)
+	if actor != "" {
+		pieces = append(pieces, actor)
+	}
+	pieces = append(pieces, verb)
+	if object != "" {
+		pieces = append(pieces, object)
+	}
+	return strings.Join(pieces, " → ")
+}
+
+func (t *TimelineModel) View() tea.View {
+	dayStyle := lipgloss.NewStyle().Bold(true).Foreground(Indigo)
+
+	pieces := make([]string, 0, len(t.items)+2)
+	pieces = append(pieces, viewTitleStyle.Render("Timeline"))
+	pieces = append(pieces, fmt.Sprintf("%s: %s", t.iri, t.rng))
+	if t.err != nil {
+		pieces = append(pieces, faintRedFg.Render(t.err.Error()))
+	}
+	if len(t.items) == 0 {
+		pieces = append(pieces, "", "No items")
+	}
+
+	day := ""
+	for _, it := range t.items {
+		pubTime := published(it).Local()
+		if d := pubTime.Format(timelineDayFmt); d != day {
+			day = d
+			pieces = append(pieces, "", dayStyle.Render(day))
+		}
+		pieces = append(pieces, fmt.Sprintf("%s %s", faintStyle.Render(pubTime.Format(timelineTimeFmt)), summarizeActivity(it)))
+	}
+	return tea.NewView(lipgloss.JoinVertical(lipgloss.Left, pieces...))
+}
@@ -0,0 +1,98 @@
+package motley
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestParseTimelineRange(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		in      string
+		want    publishedBetween
+		wantErr bool
+	}{
+		{in: "", want: publishedBetween{}},
+		{in: "today", want: publishedBetween{from: day(2024, 3, 15), to: day(2024, 3, 16)}},
+		{in: "yesterday", want: publishedBetween{from: day(2024, 3, 14), to: day(2024, 3, 15)}},
+		{in: "yesterday 14:00", want: publishedBetween{from: day(2024, 3, 14).Add(13 * time.Hour), to: day(2024, 3, 14).Add(15 * time.Hour)}},
+		{in: "2024-01-02", want: publishedBetween{from: day(2024, 1, 2), to: day(2024, 1, 3)}},
+		{in: " 2024-01-02 12:00 ", want: publishedBetween{from: day(2024, 1, 2).Add(11 * time.Hour), to: day(2024, 1, 2).Add(13 * time.Hour)}},
+		{in: "2024-01-02..2024-01-05", want: publishedBetween{from: day(2024, 1, 2), to: day(2024, 1, 6)}},
+		{in: "2024-01-05..2024-01-02", wantErr: true},
+		{in: "2024-01-02..", wantErr: true},
+		{in: "tomorrow", wantErr: true},
+		{in: "2024-13-01", wantErr: true},
+		{in: "25:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTimelineRange(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimelineRange(%q) error = %v, expected error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !got.from.Equal(tt.want.from) || !got.to.Equal(tt.want.to) {
+			t.Errorf("parseTimelineRange(%q) = %s, expected %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPublishedBetween_shift(t *testing.T) {
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	rng := publishedBetween{from: from, to: from.AddDate(0, 0, 1)}
+	if prev := rng.shift(-1); !prev.from.Equal(from.AddDate(0, 0, -1)) || !prev.to.Equal(from) {
+		t.Errorf("shift(-1) = %s, expected the previous day", prev)
+	}
+	if next := rng.shift(1); !next.from.Equal(from.AddDate(0, 0, 1)) || !next.to.Equal(from.AddDate(0, 0, 2)) {
+		t.Errorf("shift(1) = %s, expected the next day", next)
+	}
+	if open := (publishedBetween{from: from}).shift(1); !open.from.Equal(from) || !open.to.IsZero() {
+		t.Errorf("shift(1) on an open interval = %s, expected it unchanged", open)
+	}
+}
+
+func TestTimelineLoadOlderRange(t *testing.T) {
+	f, st := newTestFedbox(t)
+	outbox := pub.Outbox.IRI(st.root)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// NOTE(marius): more activities than a timeline page, the oldest ones are the ones we look for.
+	for i := 0; i < timelineMaxItems+50; i++ {
+		act := &pub.Activity{
+			ID:        testRoot.AddPath("activities", fmt.Sprintf("%d", i)),
+			Type:      pub.CreateType,
+			Actor:     testRoot,
+			Object:    testRoot,
+			Published: start.Add(time.Duration(i) * time.Hour),
+		}
+		if _, err := st.s.Save(act); err != nil {
+			t.Fatalf("unable to save activity: %s", err)
+		}
+		if err := st.s.AddTo(outbox, act.GetLink()); err != nil {
+			t.Fatalf("unable to add activity to outbox: %s", err)
+		}
+	}
+
+	rng := publishedBetween{from: start, to: start.Add(3 * time.Hour)}
+	tm := newTimelineModel(&commonModel{f: f, logFn: t.Logf}, outbox)
+	msg, ok := tm.load(rng)().(timelineLoadedMsg)
+	if !ok {
+		t.Fatalf("load() didn't return a %T", timelineLoadedMsg{})
+	}
+	if msg.err != nil {
+		t.Fatalf("load() errored: %s", msg.err)
+	}
+	if len(msg.items) != 3 {
+		t.Fatalf("load() returned %d items, expected 3", len(msg.items))
+	}
+	for _, it := range msg.items {
+		if !rng.Match(it) {
+			t.Errorf("item %s published %s is outside of %s", it.GetLink(), published(it), rng)
+		}
+	}
+}
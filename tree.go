@@ -16,6 +16,8 @@ func newTreeModel(common *commonModel, t tree.Nodes) treeModel {
 	ls := tree.New(t)
 	ls.Symbols = tree.RoundedSymbols()
 	ls.KeyMap = tree.DefaultKeyMap()
+	// NOTE(marius): space is used for marking nodes.
+	ls.KeyMap.PageDown.SetKeys("f", "pgdown")
 
 	return treeModel{
 		commonModel: common,
@@ -84,7 +86,8 @@ func (t *treeModel) Back(previous *tree.Model) (tea.Model, tea.Cmd) {
 	return t, noop
 }
 
-func (t *treeModel) Advance(current *n) *tree.Model {
+// newTree creates a tree with current as its root, using the same settings as the current tree.
+func (t *treeModel) newTree(current *n) *tree.Model {
 	current.p = nil
 
 	current.s |= tree.NodeSelected
@@ -94,9 +97,12 @@ func (t *treeModel) Advance(current *n) *tree.Model {
 	newTree.Styles = t.list.Styles
 	newTree.SetWidth(t.list.Width())
 	newTree.SetHeight(t.list.Height())
+	return newTree
+}
 
+func (t *treeModel) Advance(current *n) *tree.Model {
 	oldTree := t.list
-	t.list = newTree
+	t.list = t.newTree(current)
 	return oldTree
 }
 
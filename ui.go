@@ -1,10 +1,10 @@
 package motley
 
 import (
-	"context"
 	"fmt"
 	"image/color"
 	"os"
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/key"
@@ -31,6 +31,13 @@ var (
 
 	hintFg    = lipgloss.NewStyle().Background(hintColor)
 	hintDimFg = lipgloss.NewStyle().Background(hintDimColor)
+
+	// viewTitleStyle is used for the underlined titles of the views in the pager.
+	viewTitleStyle = lipgloss.NewStyle().Bold(true).BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
+	// fieldStyle is used for the names of the fields in the views.
+	fieldStyle = lipgloss.NewStyle().Bold(true)
+	// faintStyle is used for secondary information, like help lines and missing values.
+	faintStyle = lipgloss.NewStyle().Faint(true)
 )
 
 var (
@@ -77,11 +84,6 @@ var (
 	FaintRedFg     = lipgloss.Style{}.Foreground(FaintRed).Render
 )
 
-var (
-	GlamourStyle    = "auto"
-	GlamourMaxWidth = 800
-)
-
 var (
 	mintGreen = NewColorPair("#89F0CB", "#89F0CB")
 	darkGreen = NewColorPair("#1C8760", "#1C8760")
@@ -101,12 +103,6 @@ func Launch(conf config.Options, l lw.Logger) error {
 var _ tea.Model = new(model)
 
 func Model(l lw.Logger, st ...Store) *model {
-	if HasDarkBackground {
-		GlamourStyle = "dark"
-	} else {
-		GlamourStyle = "light"
-	}
-
 	m := new(model)
 	m.commonModel = new(commonModel)
 	m.commonModel.logFn = l.Debugf
@@ -118,19 +114,16 @@ func Model(l lw.Logger, st ...Store) *model {
 	m.f.stores = st
 	m.f.logFn = l.Debugf
 	m.tree = newTreeModel(m.commonModel, initNodes(m.f))
+	m.layout = layoutFromConfig(config.Layout{})
+	m.tabs = make([]tab, 1)
 	return m
 }
 
 func newModel(conf config.Options, l lw.Logger) *model {
-	if HasDarkBackground {
-		GlamourStyle = "dark"
-	} else {
-		GlamourStyle = "light"
-	}
-
 	m := new(model)
 	m.commonModel = new(commonModel)
 	m.commonModel.logFn = l.Debugf
+	m.commonModel.langs = preferredLangRefs(conf.Languages)
 
 	m.pager = newItemModel(m.commonModel)
 	m.status = newStatusModel(m.commonModel)
@@ -138,19 +131,23 @@ func newModel(conf config.Options, l lw.Logger) *model {
 	var err error
 	var nodes tree.Nodes
 
-	m.f, err = fedBOX(conf.URLs, conf.Storage, l)
+	m.f, err = fedBOX(conf.URLs, conf.Storage, conf.ReadOnly, conf.ProdWriteWindow, l)
 	if err != nil {
 		m.status.showError(err)
 	} else {
 		nodes = initNodes(m.f)
 	}
 	m.tree = newTreeModel(m.commonModel, nodes)
+	m.layout = layoutFromConfig(conf.Layout)
+	m.tabs = make([]tab, 1)
 	return m
 }
 
 type commonModel struct {
 	f    *fedbox
 	root vocab.Item
+	// langs are the preferred languages for showing natural language values.
+	langs []vocab.LangRef
 
 	logFn func(string, ...interface{})
 }
@@ -163,18 +160,33 @@ type model struct {
 
 	currentNode         *n
 	currentNodePosition int
-	breadCrumbs         []*tree.Model
+	history             history
+	loaders             loaders
+	loadGen             uint64
+	watch               *watcher
+	watchGen            uint64
+	layout              layout
+	tabs                []tab
+	activeTab           int
+	tabsBar             string
+	tabSpans            []crumbSpan
+	markAnchor          *n
 
 	tree   treeModel
 	pager  pagerModel
 	status statusModel
+	prompt *promptModel
+	popup  tea.Model
 }
 
 func (m *model) Init() tea.Cmd {
 	m.logFn("UI init")
-	m.breadCrumbs = make([]*tree.Model, 0)
 
-	return tea.Batch(m.tree.Init(), m.pager.Init(), m.status.Init())
+	cmds := []tea.Cmd{m.tree.Init(), m.pager.Init(), m.status.Init()}
+	if m.f != nil && len(m.f.stores) > 0 {
+		cmds = append(cmds, m.pager.show(newDashboardModel(m.commonModel)), m.restoreHistory())
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m *model) setSize(w, h int) {
@@ -183,14 +195,10 @@ func (m *model) setSize(w, h int) {
 
 	m.logFn("UI wxh: %dx%d", w, h)
 
-	h = h - m.status.Height() - 2 // 2 for border
 	m.status.width = w
-
-	w = w - 2 - 2 // 1 for padding, 1 for border
-
-	tw := max(minTreeWidth, int(0.28*float32(w)))
-	m.tree.setSize(tw-1-1, h)
-	m.pager.setSize(w-tw-1-1, h)
+	m.setPaneSizes(w, h-m.status.Height()-m.tabsHeight())
+	m.pager.y += m.tabsHeight()
+	m.pager.placeContent()
 
 	m.logFn("Statusbar wxh: %dx%d", m.status.width, m.status.Height())
 
@@ -212,6 +220,16 @@ func nodeUpdateCmd(n n) tea.Cmd {
 	}
 }
 
+// nodeRefreshMsg is sent when the current node changed after loading it or watching it,
+// it updates the pager only while it shows the node, unlike nodeUpdateMsg which replaces the open view.
+type nodeRefreshMsg n
+
+func nodeRefreshCmd(n n) tea.Cmd {
+	return func() tea.Msg {
+		return nodeRefreshMsg(n)
+	}
+}
+
 func skipMessageFromLogs(msg tea.Msg) bool {
 	if _, ok := msg.(*n); ok {
 		return true
@@ -225,6 +243,9 @@ func skipMessageFromLogs(msg tea.Msg) bool {
 	if _, ok := msg.(nodeUpdateMsg); ok {
 		return true
 	}
+	if _, ok := msg.(nodeRefreshMsg); ok {
+		return true
+	}
 	return false
 }
 
@@ -237,47 +258,171 @@ func (m *model) logMessage(msg tea.Msg) {
 func (m *model) update(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, 0)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
-	defer cancel()
-
 	m.logMessage(msg)
+	if lm, ok := msg.(loadResultMsg); ok && m.deferToTab(lm.generation(), msg) {
+		return noop
+	}
 	switch mm := msg.(type) {
 	case *n:
 		if mm != nil {
 			m.currentNodePosition = m.tree.list.Cursor()
 			m.currentNode = mm
-			m.tree.state |= stateBusy
-			cmd := m.loadDepsForNode(ctx, m.currentNode)
+			m.currentNode.s &^= NodeChanged
+			cmd := m.loadDepsCmd(m.currentNode)
 			for _, st := range m.f.stores {
 				if mm.GetLink().Contains(st.root.GetLink(), true) {
 					m.root = st.root
 					m.status.env = st.env
+					m.status.guard = st.guard
 					break
 				}
 			}
 			m.logFn("Moved to node[%d]: %s:%s, is collection: %t", m.currentNodePosition, mm.n, mm.s, mm.IsCollection())
 			cmds = append(cmds, nodeUpdateCmd(*m.currentNode), cmd)
 		}
+	case depsLoadedMsg:
+		cmds = append(cmds, m.depsLoaded(mm))
+	case childrenLoadedMsg:
+		cmds = append(cmds, m.childrenLoaded(mm))
 	case advanceMsg:
 		cmds = append(cmds, m.Advance(mm))
+	case advanceLoadedMsg:
+		cmds = append(cmds, m.advanceLoaded(mm))
+	case historyLoadedMsg:
+		cmds = append(cmds, m.historyLoaded(mm))
+	case watchTickMsg:
+		cmds = append(cmds, m.watchTick(uint64(mm)))
+	case watchResultMsg:
+		cmds = append(cmds, m.watchResult(mm))
+	case bulkResultMsg:
+		cmds = append(cmds, m.bulkApplied(mm))
+	case followMsg:
+		return m.Follow(vocab.IRI(mm))
+	case promptMsg:
+		m.prompt = newPromptModel(mm, m.width)
+		return noop
+	case closePopupMsg:
+		m.popup = nil
+		return noop
 	case tea.KeyMsg:
+		if m.prompt != nil {
+			cmd, done := m.prompt.Update(msg)
+			if done {
+				m.prompt = nil
+			}
+			return cmd
+		}
+		if m.popup != nil {
+			var cmd tea.Cmd
+			m.popup, cmd = m.popup.Update(msg)
+			return cmd
+		}
 		switch {
 		case key.Matches(mm, movePane):
 			if m.tree.list.Focused() {
-				m.tree.list.Blur()
+				m.focusPager()
 			} else {
-				m.tree.list.Focus()
-				// the model.Tree sets cursor to -1 when bluring, so we need to add an extra +1
-				cmds = append(cmds, m.tree.list.SetCursor(m.currentNodePosition))
+				cmds = append(cmds, m.focusTree())
 			}
 		case key.Matches(mm, quitKey):
 			return quitCmd
 		case key.Matches(mm, helpKey):
 			return tea.Batch(showHelpCmd(), resizeCmd(m.width, m.height))
-		case key.Matches(mm, advanceKey):
+		case key.Matches(mm, advanceKey) && m.tree.list.Focused():
 			return advanceCmd(*m.currentNode)
+		case key.Matches(mm, markKey) && m.tree.list.Focused():
+			return m.toggleMark()
+		case key.Matches(mm, markRangeKey) && m.tree.list.Focused():
+			return m.markRange()
+		case key.Matches(mm, markChildrenKey) && m.tree.list.Focused():
+			return m.markChildren()
+		case key.Matches(mm, bulkKey) && m.tree.list.Focused():
+			return m.showBulk()
 		case key.Matches(mm, backKey):
 			return m.Back(mm)
+		case key.Matches(mm, bookmarkAddKey):
+			return m.addBookmark()
+		case key.Matches(mm, bookmarksKey):
+			if m.f == nil {
+				return noop
+			}
+			m.popup = newBookmarksModel(m.commonModel)
+			return m.popup.Init()
+		case key.Matches(mm, watchKey):
+			return m.toggleWatch()
+		case key.Matches(mm, cancelLoadingKey):
+			return m.cancelLoading()
+		case key.Matches(mm, newTabKey):
+			return m.openTab()
+		case key.Matches(mm, closeTabKey):
+			return m.closeTab()
+		case key.Matches(mm, nextTabKey):
+			return m.switchTab(m.activeTab + 1)
+		case key.Matches(mm, prevTabKey):
+			return m.switchTab(m.activeTab - 1)
+		case key.Matches(mm, growTreeKey):
+			return m.resizeSplit(splitStep)
+		case key.Matches(mm, shrinkTreeKey):
+			return m.resizeSplit(-splitStep)
+		case key.Matches(mm, orientationKey):
+			return m.switchOrientation()
+		case key.Matches(mm, zoomKey):
+			return m.toggleZoom()
+		case key.Matches(mm, forwardKey):
+			return m.Forward()
+		case key.Matches(mm, crumbKey):
+			return m.JumpTo(int(mm.String()[0] - '1'))
+		case key.Matches(mm, timelineKey):
+			return m.showTimeline()
+		case key.Matches(mm, tailKey):
+			return m.showTail()
+		case key.Matches(mm, deliverKey):
+			if m.currentNode == nil || !isActivity(m.currentNode.Item) {
+				return errCmd(fmt.Errorf("delivery is available only for activities"))
+			}
+			m.focusPager()
+			return m.pager.show(newDeliveryModel(m.commonModel, m.currentNode.Item))
+		case key.Matches(mm, dashboardKey):
+			return m.pager.show(newDashboardModel(m.commonModel))
+		case key.Matches(mm, moderationKey):
+			m.focusPager()
+			return m.pager.show(newModerationModel(m.commonModel))
+		case key.Matches(mm, blocksKey):
+			m.focusPager()
+			return m.pager.show(newBlocksModel(m.commonModel))
+		case key.Matches(mm, writeToggleKey):
+			if vocab.IsNil(m.root) {
+				return errCmd(fmt.Errorf("no store selected"))
+			}
+			st, err := m.f.storeFor(m.root.GetLink())
+			if err != nil {
+				return errCmd(err)
+			}
+			return toggleWrites(st)
+		case key.Matches(mm, journalKey):
+			m.focusPager()
+			return m.pager.show(newJournalModel(m.commonModel))
+		case key.Matches(mm, oauthKey):
+			m.focusPager()
+			return m.pager.show(newOAuthModel(m.commonModel))
+		case key.Matches(mm, keysKey):
+			if m.currentNode == nil || vocab.IsNil(m.currentNode.Item) || !vocab.ActorTypes.Match(m.currentNode.GetType()) {
+				return errCmd(fmt.Errorf("keys are available only for actors"))
+			}
+			m.focusPager()
+			return m.pager.show(newKeysModel(m.commonModel, m.currentNode.GetLink()))
+		case key.Matches(mm, accountKey):
+			if m.currentNode == nil || vocab.IsNil(m.currentNode.Item) || !vocab.ActorTypes.Match(m.currentNode.GetType()) {
+				return errCmd(fmt.Errorf("account management is available only for actors"))
+			}
+			m.focusPager()
+			return m.pager.show(newAccountModel(m.commonModel, m.currentNode))
+		case key.Matches(mm, discoveryKey):
+			if m.currentNode == nil || vocab.IsNil(m.currentNode.Item) || !vocab.ActorTypes.Match(m.currentNode.GetType()) {
+				return errCmd(fmt.Errorf("discovery preview is available only for actors"))
+			}
+			m.focusPager()
+			return m.pager.show(newDiscoveryModel(m.commonModel, m.currentNode.GetLink()))
 		}
 
 		if m.currentNodePosition < m.height-3 && m.currentNode != nil {
@@ -285,9 +430,17 @@ func (m *model) update(msg tea.Msg) tea.Cmd {
 			if parent != nil && parent.IsCollection() {
 				count := filters.WithMaxCount(m.height)
 				after := filters.After(filters.SameID(m.currentNode.GetLink()))
-				_ = m.loadNode(ctx, parent, after, count)
+				cmds = append(cmds, m.loadChildrenCmd(parent, after, count))
 			}
 		}
+	case tea.MouseClickMsg:
+		cmds = append(cmds, m.handleMouseClick(mm))
+	case tea.MouseMotionMsg:
+		cmds = append(cmds, m.handleMouseMotion(mm))
+	case tea.MouseReleaseMsg:
+		cmds = append(cmds, m.handleMouseRelease(mm))
+	case tea.MouseWheelMsg:
+		cmds = append(cmds, m.handleMouseWheel(mm))
 	case tea.WindowSizeMsg:
 		m.setSize(mm.Width, mm.Height)
 		return m.tree.list.SetCursor(m.currentNodePosition)
@@ -296,8 +449,10 @@ func (m *model) update(msg tea.Msg) tea.Cmd {
 	}
 
 	cmds = append(cmds, m.updateTree(msg))
-	if !m.tree.IsSyncing() {
-		cmds = append(cmds, m.updatePager(msg))
+	// NOTE(marius): the pager keeps receiving messages while nodes are loading, as the loading happens in the background.
+	cmds = append(cmds, m.updatePager(msg))
+	if _, ok := msg.(backgroundMsg); ok {
+		cmds = append(cmds, m.updateStashedPagers(msg))
 	}
 	cmds = append(cmds, m.updateStatusBar(msg))
 	return tea.Batch(cmds...)
@@ -344,14 +499,44 @@ var (
 	)
 )
 
+func (m *model) showTimeline() tea.Cmd {
+	if !timelineEligible(m.currentNode) {
+		return errCmd(fmt.Errorf("timeline is available only for inbox, outbox and streams collections"))
+	}
+	m.focusPager()
+	return m.pager.show(newTimelineModel(m.commonModel, m.currentNode.GetLink()))
+}
+
+func (m *model) showTail() tea.Cmd {
+	if !timelineEligible(m.currentNode) {
+		return errCmd(fmt.Errorf("tail is available only for inbox, outbox and streams collections"))
+	}
+	m.focusPager()
+	return m.pager.show(newTailModel(m.commonModel, m.currentNode.GetLink(), m.pager.viewport.Height()))
+}
+
+func (m *model) focusPager() {
+	m.tree.list.Blur()
+	m.pager.Focus()
+}
+
+func (m *model) focusTree() tea.Cmd {
+	m.pager.Blur()
+	m.tree.list.Focus()
+	// the model.Tree sets cursor to -1 when bluring, so we need to add an extra +1
+	return m.tree.list.SetCursor(m.currentNodePosition)
+}
+
 func (m *model) Back(msg tea.Msg) tea.Cmd {
-	if len(m.breadCrumbs) == 0 {
+	if len(m.history.back) == 0 {
 		m.logFn("No previous tree to go back to.")
 		return noop
 	}
-	if oldTree := m.breadCrumbs[len(m.breadCrumbs)-1]; oldTree != nil {
-		m.tree.Back(oldTree)
-		m.breadCrumbs = m.breadCrumbs[:len(m.breadCrumbs)-1]
+	if oldTree := m.history.back[len(m.history.back)-1]; oldTree != nil {
+		m.history.forward = append(m.history.forward, m.tree.list)
+		m.switchTree(oldTree)
+		m.history.back = m.history.back[:len(m.history.back)-1]
+		m.saveHistory()
 	}
 	return noop
 }
@@ -391,16 +576,53 @@ func (m *model) Advance(msg advanceMsg) tea.Cmd {
 	name := getRootNodeName(&nn)
 	newNode := node(msg.Item, withParent(&nn), withName(name))
 
+	ctx, gen := m.startLoading(&m.loaders.advance)
+	f := m.f
+	it := newNode.Item
 	count := filters.WithMaxCount(m.height)
-	if err := m.loadNode(context.Background(), newNode, count); err != nil {
-		return errCmd(fmt.Errorf("unable to advance to %q: %w", nn.n, err))
+	return tea.Batch(loadingCmd(true), func() tea.Msg {
+		children, err := loadChildren(ctx, f, it, count)
+		return advanceLoadedMsg{gen: gen, node: newNode, children: children, err: err}
+	})
+}
+
+type advanceLoadedMsg struct {
+	gen      uint64
+	node     *n
+	children []*n
+	err      error
+}
+
+func (m *model) advanceLoaded(msg advanceLoadedMsg) tea.Cmd {
+	newNode := msg.node
+	if !m.finishLoading(&m.loaders.advance, msg.gen) {
+		m.logFn("Dropping stale advance to: %s", newNode.n)
+		return noop
+	}
+	if msg.err != nil {
+		return tea.Batch(loadingCmd(false), errCmd(fmt.Errorf("unable to advance to %q: %w", newNode.n, msg.err)))
 	}
+	newNode.setChildren(msg.children...)
 	if newNode.s.Is(tree.NodeCollapsible) && len(newNode.c) == 0 {
-		return errCmd(fmt.Errorf("no items in collection %s", name))
+		return tea.Batch(loadingCmd(false), errCmd(fmt.Errorf("no items in collection %s", newNode.n)))
 	}
 	oldTree := m.tree.Advance(newNode)
-	m.breadCrumbs = append(m.breadCrumbs, oldTree)
-	return nodeCmd(newNode)
+	m.history.back = append(m.history.back, oldTree)
+	m.history.forward = m.history.forward[:0]
+	m.saveHistory()
+	return tea.Batch(loadingCmd(false), nodeCmd(newNode))
+}
+
+// Follow loads the item at iri and advances the tree to it, the same as for the current node.
+func (m *model) Follow(iri vocab.IRI) tea.Cmd {
+	f := m.f
+	return tea.Batch(m.focusTree(), func() tea.Msg {
+		it, err := f.Load(iri)
+		if err != nil {
+			return fmt.Errorf("unable to follow %s: %w", iri, err)
+		}
+		return advanceMsg(*node(it))
+	})
 }
 
 func errCmd(err error) tea.Cmd {
@@ -426,6 +648,8 @@ func resizeCmd(w, h int) tea.Cmd {
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmd := m.update(msg)
+	m.updateCrumbs(m.tree.width())
+	m.updateTabs(m.width)
 	return m, cmd
 }
 
@@ -459,16 +683,25 @@ func renderTree(t treeModel) string {
 }
 
 func (m *model) View() tea.View {
-	renderedTree := renderWithBorder(renderTree(m.tree), m.tree.list.Focused())
-	return tea.NewView(lipgloss.JoinVertical(
-		lipgloss.Top,
-		lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			renderedTree,
-			renderWithBorder(m.pager.View().Content, !m.tree.list.Focused()),
-		),
-		lipgloss.NewStyle().Render(m.status.View()),
-	))
+	treeWithCrumbs := lipgloss.JoinVertical(lipgloss.Left, m.history.bar, renderTree(m.tree))
+	renderedTree := renderWithBorder(treeWithCrumbs, m.tree.list.Focused())
+	main := m.panesView(renderedTree, renderWithBorder(m.pager.View().Content, !m.tree.list.Focused()))
+	if m.tabsHeight() > 0 {
+		main = lipgloss.JoinVertical(lipgloss.Left, m.tabsBar, main)
+	}
+	if m.popup != nil {
+		main = renderPopup(main, m.popup.View().Content, m.width, lipgloss.Height(main))
+	}
+	v := tea.NewView(lipgloss.JoinVertical(lipgloss.Top, main, m.statusView()))
+	v.MouseMode = tea.MouseModeCellMotion
+	return v
+}
+
+func (m *model) statusView() string {
+	if m.prompt != nil {
+		return m.prompt.View()
+	}
+	return lipgloss.NewStyle().Render(m.status.View())
 }
 
 func (m *model) IsBusy() bool {
@@ -493,6 +726,15 @@ func newStyle(fg, bg ColorPair, bold bool) func(...string) string {
 	return s.Render
 }
 
+// helpLine renders the key bindings with their descriptions on a single faint line.
+func helpLine(keys ...key.Binding) string {
+	help := make([]string, 0, len(keys))
+	for _, k := range keys {
+		help = append(help, k.Help().Key+" "+k.Help().Desc)
+	}
+	return faintStyle.Render(strings.Join(help, " • "))
+}
+
 // Returns a new termenv style with background options only.
 func newFgStyle(c ColorPair) lipgloss.Style {
 	return lipgloss.Style{}.Foreground(c)
@@ -0,0 +1,373 @@
+package motley
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"git.sr.ht/~mariusor/motley/internal/config"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/go-ap/filters"
+	tree "github.com/mariusor/bubbles-tree"
+)
+
+const (
+	// watchPollInterval is how often the collections are reloaded for storages which can't notify us of changes.
+	watchPollInterval = 5 * time.Second
+	// watchNotifyInterval is how often we check for changes notified by the fs storage.
+	watchNotifyInterval = 500 * time.Millisecond
+	watchHeadCount      = 20
+)
+
+var watchKey = key.NewBinding(
+	key.WithKeys("W"),
+	key.WithHelp("W", "toggle watching the storage for changes"),
+)
+
+// watcher periodically checks the collections shown in the tree of a tab for new or modified items.
+// It keeps running while the tab is stashed.
+type watcher struct {
+	gen      uint64
+	notifier *dirNotifier
+	lastPoll time.Time
+}
+
+type watchTickMsg uint64
+
+func watchTickCmd(gen uint64, d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return watchTickMsg(gen)
+	})
+}
+
+type collectionHead struct {
+	node *n
+	iri  vocab.IRI
+	col  vocab.Item
+}
+
+type watchResultMsg struct {
+	gen   uint64
+	heads []collectionHead
+}
+
+func (m *model) toggleWatch() tea.Cmd {
+	if m.f == nil {
+		return noop
+	}
+	if m.watch != nil {
+		m.stopWatching()
+		return statusMessageCmd("stopped watching for changes")
+	}
+	m.watchGen++
+	m.watch = &watcher{gen: m.watchGen}
+	if notifier, err := newDirNotifier(); err == nil {
+		m.watch.notifier = notifier
+	} else {
+		m.logFn("unable to watch directories, falling back to polling: %s", err)
+	}
+	return tea.Batch(statusMessageCmd("watching for changes"), watchTickCmd(m.watch.gen, watchNotifyInterval))
+}
+
+func (m *model) stopWatching() {
+	if m.watch == nil {
+		return
+	}
+	if m.watch.notifier != nil {
+		_ = m.watch.notifier.Close()
+	}
+	m.watch = nil
+}
+
+// watcherFor returns the watcher with generation gen and the tree it watches, and whether it belongs to the active tab.
+func (m *model) watcherFor(gen uint64) (*watcher, *treeModel, bool) {
+	if m.watch != nil && m.watch.gen == gen {
+		return m.watch, &m.tree, true
+	}
+	for i := range m.tabs {
+		if t := &m.tabs[i]; i != m.activeTab && t.watch != nil && t.watch.gen == gen {
+			return t.watch, &t.tree, false
+		}
+	}
+	return nil, nil, false
+}
+
+// watchedNodes returns the collection nodes in the tree which have their items loaded.
+func watchedNodes(nodes tree.Nodes) []*n {
+	result := make([]*n, 0)
+	for _, nn := range nodes {
+		node, ok := nn.(*n)
+		if !ok || node == nil {
+			continue
+		}
+		if len(node.c) > 0 && iriIsCollection(node.GetLink()) {
+			result = append(result, node)
+		}
+		result = append(result, watchedNodes(node.Children())...)
+	}
+	return result
+}
+
+// collectionDir returns the directory where the fs storage keeps the collection at iri.
+func collectionDir(st Store, iri vocab.IRI) string {
+	u, err := url.Parse(iri.String())
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(st.conf.Path, u.Host, filepath.FromSlash(u.Path))
+}
+
+// staleNodes returns the watched nodes which need to be reloaded.
+// For fs storages, if we receive notifications, that is only the nodes with changes in their directories,
+// for the others it's all of them, but only once every watchPollInterval.
+func (m *model) staleNodes(w *watcher, nodes tree.Nodes) []*n {
+	poll := time.Since(w.lastPoll) >= watchPollInterval
+	if poll {
+		w.lastPoll = time.Now()
+	}
+	var changed map[string]struct{}
+	if w.notifier != nil {
+		changed = w.notifier.changed()
+	}
+
+	stale := make([]*n, 0)
+	for _, node := range watchedNodes(nodes) {
+		st, err := m.f.storeFor(node.GetLink())
+		if err != nil {
+			continue
+		}
+		if w.notifier == nil || st.conf.Type != config.StorageFS {
+			if poll {
+				stale = append(stale, node)
+			}
+			continue
+		}
+		dir := collectionDir(st, node.GetLink())
+		if err := w.notifier.add(dir); err != nil {
+			m.logFn("unable to watch %s: %s", dir, err)
+			if poll {
+				stale = append(stale, node)
+			}
+			continue
+		}
+		if _, ok := changed[dir]; ok {
+			stale = append(stale, node)
+		}
+	}
+	return stale
+}
+
+func (m *model) watchTick(gen uint64) tea.Cmd {
+	w, t, _ := m.watcherFor(gen)
+	if w == nil {
+		return noop
+	}
+	stale := m.staleNodes(w, t.list.Children())
+	if len(stale) == 0 {
+		return watchTickCmd(gen, watchNotifyInterval)
+	}
+
+	// NOTE(marius): the nodes are only read and changed by the UI, so we need their IRIs before loading in the background.
+	todo := make([]collectionHead, 0, len(stale))
+	for _, node := range stale {
+		todo = append(todo, collectionHead{node: node, iri: node.GetLink()})
+	}
+	f := m.f
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), watchPollInterval)
+		defer cancel()
+
+		heads := make([]collectionHead, 0, len(todo))
+		for _, h := range todo {
+			if ctx.Err() != nil {
+				break
+			}
+			col, err := f.Load(h.iri, filters.WithMaxCount(watchHeadCount))
+			if err != nil {
+				continue
+			}
+			h.col = col
+			heads = append(heads, h)
+		}
+		return watchResultMsg{gen: gen, heads: heads}
+	}
+}
+
+func updatedTime(it vocab.Item) time.Time {
+	var updated time.Time
+	_ = vocab.OnObject(it, func(ob *vocab.Object) error {
+		updated = ob.Updated
+		return nil
+	})
+	return updated
+}
+
+// mergeItems returns the items of head missing from items followed by items, with the ones modified in head replaced.
+func mergeItems(items, head vocab.ItemCollection) vocab.ItemCollection {
+	inHead := make(map[vocab.IRI]vocab.Item, len(head))
+	for _, it := range head {
+		inHead[it.GetLink()] = it
+	}
+	existing := make(map[vocab.IRI]struct{}, len(items))
+	for _, it := range items {
+		existing[it.GetLink()] = struct{}{}
+	}
+	merged := make(vocab.ItemCollection, 0, len(items)+len(head))
+	for _, it := range head {
+		if _, ok := existing[it.GetLink()]; !ok {
+			merged = append(merged, it)
+		}
+	}
+	for _, it := range items {
+		if updated, ok := inHead[it.GetLink()]; ok && vocab.IsObject(updated) {
+			it = updated
+		}
+		merged = append(merged, it)
+	}
+	return merged
+}
+
+// mergeHead returns a copy of the collection col with the items of head merged into the ones already loaded.
+func mergeHead(col, head vocab.Item) vocab.Item {
+	var items vocab.ItemCollection
+	_ = vocab.OnCollectionIntf(head, func(c vocab.CollectionInterface) error {
+		items = c.Collection()
+		return nil
+	})
+	if vocab.IsNil(col) {
+		return head
+	}
+	total, hasTotal := totalItems(head)
+	cp := copyItem(col)
+	switch c := cp.(type) {
+	case vocab.ItemCollection:
+		return mergeItems(c, items)
+	case *vocab.OrderedCollection:
+		c.OrderedItems = mergeItems(c.OrderedItems, items)
+		if hasTotal {
+			c.TotalItems = total
+		}
+	case *vocab.OrderedCollectionPage:
+		c.OrderedItems = mergeItems(c.OrderedItems, items)
+		if hasTotal {
+			c.TotalItems = total
+		}
+	case *vocab.Collection:
+		c.Items = mergeItems(c.Items, items)
+		if hasTotal {
+			c.TotalItems = total
+		}
+	case *vocab.CollectionPage:
+		c.Items = mergeItems(c.Items, items)
+		if hasTotal {
+			c.TotalItems = total
+		}
+	}
+	return cp
+}
+
+// totalItems returns the number of items the collection reports, which an item collection doesn't have.
+func totalItems(col vocab.Item) (uint, bool) {
+	switch c := col.(type) {
+	case *vocab.OrderedCollection:
+		return c.TotalItems, true
+	case *vocab.OrderedCollectionPage:
+		return c.TotalItems, true
+	case *vocab.Collection:
+		return c.TotalItems, true
+	case *vocab.CollectionPage:
+		return c.TotalItems, true
+	}
+	return 0, false
+}
+
+// applyHead adds the items in the head of the collection missing from the parent, and replaces the modified ones.
+// It returns whether the parent was changed.
+func applyHead(parent *n, col vocab.Item) bool {
+	existing := make(map[vocab.IRI]*n, len(parent.c))
+	for _, child := range parent.c {
+		existing[child.GetLink()] = child
+	}
+
+	changed := false
+	added := make([]*n, 0)
+	_ = vocab.OnCollectionIntf(col, func(c vocab.CollectionInterface) error {
+		for _, it := range c.Collection() {
+			child, ok := existing[it.GetLink()]
+			if !ok {
+				added = append(added, node(it, withParent(parent), withState(tree.NodeCollapsed|NodeChanged)))
+				continue
+			}
+			if vocab.IsObject(it) && !updatedTime(it).Equal(updatedTime(child.Item)) {
+				child.Item = it
+				child.s |= NodeChanged
+				child.s &^= NodeSynced
+				changed = true
+			}
+		}
+		return nil
+	})
+	if len(added) > 0 {
+		parent.c = append(added, parent.c...)
+		changed = true
+	}
+	if changed {
+		parent.Item = mergeHead(parent.Item, col)
+	}
+	return changed
+}
+
+// keepCursor moves the tree cursor to the current node, after nodes were added before it.
+func (m *model) keepCursor() tea.Cmd {
+	rows := visibleRows(m.tree.list.Children())
+	cursor := m.tree.list.Cursor()
+	for pos, row := range rows {
+		if row != m.currentNode || pos == cursor {
+			continue
+		}
+		if cursor < 0 {
+			// NOTE(marius): the tree is not focused, the position will be restored when focusing it.
+			m.currentNodePosition = pos
+			return noop
+		}
+		// NOTE(marius): the tree toggles the selected state of the node found at the old cursor position,
+		// which is not the current node anymore, so we need to clear it afterwards.
+		var displaced *n
+		if cursor >= 0 && cursor < len(rows) {
+			displaced = rows[cursor]
+		}
+		cmd := m.tree.list.SetCursor(pos)
+		if displaced != nil && displaced != m.currentNode {
+			displaced.s &^= tree.NodeSelected
+		}
+		m.currentNodePosition = pos
+		return cmd
+	}
+	return noop
+}
+
+func (m *model) watchResult(msg watchResultMsg) tea.Cmd {
+	w, _, active := m.watcherFor(msg.gen)
+	if w == nil {
+		return noop
+	}
+	cmds := []tea.Cmd{watchTickCmd(msg.gen, watchNotifyInterval)}
+	changed := false
+	for _, h := range msg.heads {
+		if !applyHead(h.node, h.col) {
+			continue
+		}
+		changed = true
+		m.logFn("Collection changed: %s", h.node.n)
+		if active && h.node == m.currentNode {
+			cmds = append(cmds, nodeRefreshCmd(*h.node))
+		}
+	}
+	if changed && active && m.currentNode != nil {
+		cmds = append(cmds, m.keepCursor())
+	}
+	return tea.Batch(cmds...)
+}
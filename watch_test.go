@@ -0,0 +1,62 @@
+package motley
+
+import (
+	"testing"
+
+	pub "github.com/go-ap/activitypub"
+)
+
+func TestApplyHead_keepsLoadedItems(t *testing.T) {
+	old := make(pub.ItemCollection, 0)
+	for _, iri := range []pub.IRI{"https://example.com/2", "https://example.com/3"} {
+		old = append(old, &pub.Object{ID: iri, Type: pub.NoteType})
+	}
+	col := &pub.OrderedCollection{ID: "https://example.com/outbox", Type: pub.OrderedCollectionType, OrderedItems: old, TotalItems: 2}
+	parent := node(col)
+	for _, it := range old {
+		parent.c = append(parent.c, node(it, withParent(parent)))
+	}
+
+	added := &pub.Object{ID: "https://example.com/1", Type: pub.NoteType}
+	head := &pub.OrderedCollection{ID: col.ID, Type: pub.OrderedCollectionType, OrderedItems: pub.ItemCollection{added, old[0]}, TotalItems: 3}
+	if !applyHead(parent, head) {
+		t.Fatalf("the new item should change the parent")
+	}
+	if len(parent.c) != 3 || parent.c[0].GetLink() != added.ID {
+		t.Errorf("the new item should be added before the loaded ones, got %d children", len(parent.c))
+	}
+	merged, ok := parent.Item.(*pub.OrderedCollection)
+	if !ok {
+		t.Fatalf("the parent should keep its collection, got %T", parent.Item)
+	}
+	if len(merged.OrderedItems) != 3 || merged.TotalItems != 3 {
+		t.Errorf("the collection should keep the loaded items, got %d of %d", len(merged.OrderedItems), merged.TotalItems)
+	}
+	if len(col.OrderedItems) != 2 {
+		t.Errorf("the original collection should not be changed, got %d items", len(col.OrderedItems))
+	}
+}
+
+func TestMergeHead_collection(t *testing.T) {
+	loaded := &pub.Object{ID: "https://example.com/2", Type: pub.NoteType}
+	added := &pub.Object{ID: "https://example.com/1", Type: pub.NoteType}
+	for _, col := range []pub.Item{
+		&pub.Collection{ID: "https://example.com/followers", Type: pub.CollectionType, Items: pub.ItemCollection{loaded}, TotalItems: 1},
+		&pub.CollectionPage{ID: "https://example.com/followers?page=1", Type: pub.CollectionPageType, Items: pub.ItemCollection{loaded}, TotalItems: 1},
+	} {
+		head := &pub.Collection{ID: col.GetLink(), Type: pub.CollectionType, Items: pub.ItemCollection{added}, TotalItems: 2}
+		merged := mergeHead(col, head)
+		if merged.GetType() != col.GetType() {
+			t.Fatalf("the merged collection should keep the %s type, got %s", col.GetType(), merged.GetType())
+		}
+		_ = pub.OnCollection(merged, func(c *pub.Collection) error {
+			if len(c.Items) != 2 || c.TotalItems != 2 {
+				t.Errorf("the %s should have the items of the head merged, got %d of %d", col.GetType(), len(c.Items), c.TotalItems)
+			}
+			return nil
+		})
+		if col.(pub.CollectionInterface).Count() != 1 {
+			t.Errorf("the original %s should not be changed", col.GetType())
+		}
+	}
+}